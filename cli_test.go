@@ -0,0 +1,167 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/grid"
+	"github.com/asdf8601/takt-go/pkg/settings"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata/cli")
+
+// goldenNow is the pinned time of the golden files, during the open session
+// of testdata/cli/records.csv.
+const goldenNow = "2025-01-10T15:00:00Z"
+
+// runCLI runs takt with args over a copy of testdata/cli/records.csv in UTC
+// and returns what it printed.
+func runCLI(t *testing.T, args ...string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", "cli", "records.csv"))
+	if err != nil {
+		t.Fatalf("Failed to read records: %v", err)
+	}
+	fileName := filepath.Join(t.TempDir(), "takt.csv")
+	if err := os.WriteFile(fileName, data, 0o644); err != nil {
+		t.Fatalf("Failed to write records: %v", err)
+	}
+
+	cfg, err := settings.Load()
+	if err != nil {
+		t.Fatalf("settings.Load() failed: %v", err)
+	}
+	cfg.FileName = fileName
+	cfg.TargetHours = 8.0
+	cfg.GridSymbols = grid.SymbolsASCII
+	cfg.GridThresholds = nil
+	originalConfig, originalClock, originalLocal, originalStdout := config, clock, time.Local, os.Stdout
+	config, time.Local = cfg, time.UTC
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	os.Stdout = writer
+	output := make(chan string)
+	go func() {
+		out, _ := io.ReadAll(reader)
+		output <- string(out)
+	}()
+
+	rootCmd.SetArgs(args)
+	err = rootCmd.Execute()
+
+	_ = writer.Close()
+	os.Stdout = originalStdout
+	out := <-output
+	config, clock, time.Local = originalConfig, originalClock, originalLocal
+	resetFlags(rootCmd)
+	if err != nil {
+		t.Fatalf("takt %s failed: %v", strings.Join(args, " "), err)
+	}
+	return out
+}
+
+// resetFlags restores the default value of every flag of cmd and its
+// subcommands, which cobra keeps between executions.
+func resetFlags(cmd *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		_ = f.Value.Set(f.DefValue)
+		f.Changed = false
+	}
+	cmd.Flags().VisitAll(reset)
+	cmd.PersistentFlags().VisitAll(reset)
+	for _, sub := range cmd.Commands() {
+		resetFlags(sub)
+	}
+}
+
+func TestCLIGolden(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"cat", []string{"cat", "5"}},
+		{"day", []string{"day"}},
+		{"week", []string{"week"}},
+		{"month", []string{"month"}},
+		{"year", []string{"year"}},
+		{"quarter", []string{"q"}},
+		{"sprint", []string{"sprint"}},
+		{"summary_fiscal_year", []string{"summary", "--period", "fiscal-year:apr"}},
+		{"stats", []string{"stats", "--from", "2025-01-06"}},
+		{"forecast", []string{"forecast"}},
+		{"focus_pinned", []string{"focus", "25m"}},
+		{"day_notes", []string{"day", "--notes"}},
+		{"notes", []string{"notes"}},
+		{"search", []string{"search", "(?i)review"}},
+		{"day_where", []string{"day", "--where", "weekday in (tue,fri) and (duration>3h or note~review)"}},
+		{"sql", []string{"sql", "SELECT weekday, count(*) AS days, sum(hours) AS hours, sum(balance) AS balance FROM days GROUP BY weekday ORDER BY hours DESC, weekday"}},
+		{"team", []string{"team", "--dir", filepath.Join("testdata", "team"), "--from", "2025-01-01"}},
+		{"grid_range", []string{"grid", "--from", "2024-12-16", "--to", "2025-01-12", "--color", "never"}},
+		{"grid_month", []string{"grid", "--month", "2025-01", "--color", "never"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"--now", goldenNow}, tt.args...)
+			got := runCLI(t, args...)
+
+			golden := filepath.Join("testdata", "cli", tt.name+".golden")
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+					t.Fatalf("Failed to update %s: %v", golden, err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("Failed to read %s (run go test -update to create it): %v", golden, err)
+			}
+			if got != string(want) {
+				t.Errorf("takt %s differs from %s:\n%s", strings.Join(args, " "), golden, got)
+			}
+		})
+	}
+}
+
+func TestCLINowFromEnvironment(t *testing.T) {
+	t.Setenv("TAKT_NOW", "2025-01-10 14:00")
+	out := runCLI(t, "day", "1")
+	if !strings.Contains(out, "2025-01-10    4h30m") {
+		t.Errorf("Expected the open session to count until TAKT_NOW:\n%s", out)
+	}
+
+	// --now wins over TAKT_NOW
+	out = runCLI(t, "--now", "2025-01-10T16:00:00Z", "day", "1")
+	if !strings.Contains(out, "2025-01-10    6h30m") {
+		t.Errorf("Expected the open session to count until --now:\n%s", out)
+	}
+}
+
+func TestCLIInvalidWhere(t *testing.T) {
+	var out strings.Builder
+	rootCmd.SetOut(&out)
+	rootCmd.SetErr(&out)
+	rootCmd.SetArgs([]string{"day", "--where", "bogus=1"})
+	t.Cleanup(func() {
+		rootCmd.SetOut(nil)
+		rootCmd.SetErr(nil)
+		resetFlags(rootCmd)
+	})
+
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "invalid --where") {
+		t.Fatalf("Expected an invalid --where error, got %v", err)
+	}
+	if strings.Contains(out.String(), "Usage:") {
+		t.Errorf("Expected no usage for an invalid --where:\n%s", out.String())
+	}
+}
@@ -0,0 +1,499 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/remind"
+	"github.com/asdf8601/takt-go/pkg/settings"
+	"github.com/asdf8601/takt-go/pkg/takt"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// Idle sources of takt daemon
+	IdleSourceAuto    = settings.DefaultIdleSource
+	IdleSourceNone    = "none"
+	IdleSourceX11     = "x11"
+	IdleSourceWayland = "wayland"
+	IdleSourceFile    = "file:"
+	IdleSourceCommand = "command:"
+
+	// Answers when coming back from idleness
+	OnReturnAsk     = "ask"
+	OnReturnKeep    = "keep"
+	OnReturnDiscard = "discard"
+	OnReturnNothing = "nothing"
+
+	DefaultIdleInterval = 30 * time.Second
+
+	// IdleNotes are the notes of the check-out inserted at the start of
+	// idleness.
+	IdleNotes = "Idle (takt daemon)"
+)
+
+// IdleSource reports how long the user has been idle.
+type IdleSource interface {
+	Idle() (time.Duration, error)
+}
+
+// fileIdleSource is a heartbeat file touched while the user is active:
+// idleness is the time since its last modification.
+type fileIdleSource struct {
+	path  string
+	clock takt.Clock
+}
+
+func (s fileIdleSource) Idle() (time.Duration, error) {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return 0, fmt.Errorf("heartbeat file: %w", err)
+	}
+	idle := s.clock.Now().Sub(info.ModTime())
+	if idle < 0 {
+		return 0, nil
+	}
+	return idle, nil
+}
+
+// commandIdleSource runs a command printing the idle time in milliseconds,
+// as the last number of its output.
+type commandIdleSource struct {
+	name string
+	args []string
+}
+
+// lastNumber matches the last number of a command's output, so that the
+// uint64 of "(uint64 1234,)" isn't taken for the idle time.
+var lastNumber = regexp.MustCompile(`(\d+)\D*$`)
+
+func (s commandIdleSource) Idle() (time.Duration, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command(s.name, s.args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w: %s", s.name, err, strings.TrimSpace(stderr.String()))
+	}
+	match := lastNumber.FindSubmatch(bytes.TrimSpace(out))
+	if match == nil {
+		return 0, fmt.Errorf("%s: no idle time in %q", s.name, strings.TrimSpace(string(out)))
+	}
+	ms, err := strconv.ParseInt(string(match[1]), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", s.name, err)
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// newIdleSource returns the idle source named by spec: auto, x11 (needs
+// xprintidle), wayland (GNOME's idle monitor over D-Bus), file:PATH,
+// command:COMMAND, or none for no idle detection (a nil source).
+func newIdleSource(spec string) (IdleSource, error) {
+	if spec == IdleSourceNone {
+		return nil, nil
+	}
+	if spec == IdleSourceAuto {
+		switch {
+		case os.Getenv("WAYLAND_DISPLAY") != "":
+			spec = IdleSourceWayland
+		case os.Getenv("DISPLAY") != "":
+			spec = IdleSourceX11
+		default:
+			return nil, errors.New("no X11 or Wayland display found, use --source file:PATH or command:COMMAND")
+		}
+	}
+
+	switch {
+	case spec == IdleSourceX11:
+		return commandIdleSource{name: "xprintidle"}, nil
+	case spec == IdleSourceWayland:
+		return commandIdleSource{name: "gdbus", args: []string{
+			"call", "--session",
+			"--dest", "org.gnome.Mutter.IdleMonitor",
+			"--object-path", "/org/gnome/Mutter/IdleMonitor/Core",
+			"--method", "org.gnome.Mutter.IdleMonitor.GetIdletime",
+		}}, nil
+	case strings.HasPrefix(spec, IdleSourceFile):
+		path, err := settings.AbsPath(strings.TrimPrefix(spec, IdleSourceFile))
+		if err != nil {
+			return nil, err
+		}
+		return fileIdleSource{path: path, clock: clock}, nil
+	case strings.HasPrefix(spec, IdleSourceCommand):
+		fields := strings.Fields(strings.TrimPrefix(spec, IdleSourceCommand))
+		if len(fields) == 0 {
+			return nil, errors.New("empty idle command")
+		}
+		return commandIdleSource{name: fields[0], args: fields[1:]}, nil
+	}
+	return nil, fmt.Errorf("unsupported idle source: %s (must be %s, %s, %s, %sPATH, %sCOMMAND or %s)",
+		spec, IdleSourceAuto, IdleSourceX11, IdleSourceWayland, IdleSourceFile, IdleSourceCommand, IdleSourceNone)
+}
+
+// daemon checks out when the user goes idle while checked in, and sends
+// reminders.
+type daemon struct {
+	fileName  string
+	source    IdleSource // nil for no idle detection
+	threshold time.Duration
+	onReturn  string
+	reminders []remind.Rule
+	sinks     []Sink
+	clock     takt.Clock
+	// answers are the lines typed by the user, closed at the end of input
+	answers <-chan string
+	out     io.Writer
+
+	// away is the check-out inserted at the start of the current idleness
+	away *Record
+	// asked is the return that waits for an answer
+	asked *idleReturn
+	// sent are the keys of the reminders already sent
+	sent map[string]bool
+}
+
+// idleReturn is the user's return after the check-out away.
+type idleReturn struct {
+	away Record
+	at   time.Time
+}
+
+// readAnswers sends the lines of r on the returned channel, which is closed
+// at the end of input, so that waiting for an answer doesn't stop the ticks.
+func readAnswers(r io.Reader) <-chan string {
+	answers := make(chan string)
+	go func() {
+		defer close(answers)
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			answers <- scanner.Text()
+		}
+	}()
+	return answers
+}
+
+// tick runs the daemon's checks once.
+func (d *daemon) tick() error {
+	var errs []error
+	if d.source != nil {
+		errs = append(errs, d.watchIdle())
+	}
+	if d.asked != nil {
+		errs = append(errs, d.answer())
+	}
+	if len(d.reminders) > 0 {
+		errs = append(errs, d.remind())
+	}
+	return errors.Join(errs...)
+}
+
+// remind sends the reminders that are due and weren't sent yet.
+func (d *daemon) remind() error {
+	s := recordStore(d.fileName)
+	s.Clock = d.clock
+	records, err := s.Read(-1)
+	if err != nil {
+		return err
+	}
+	if d.sent == nil {
+		d.sent = map[string]bool{}
+	}
+
+	var errs []error
+	for _, n := range remind.Due(reportConfig(), d.reminders, records, d.clock.Now()) {
+		if d.sent[n.Key] {
+			continue
+		}
+		d.sent[n.Key] = true
+		for _, sink := range d.sinks {
+			if err := sink.Notify(n); err != nil {
+				errs = append(errs, fmt.Errorf("notification failed: %w", err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// watchIdle polls the idle source once: it checks out at the start of
+// idleness over the threshold, and asks what to do with the gap once the
+// user is back.
+func (d *daemon) watchIdle() error {
+	idle, err := d.source.Idle()
+	if err != nil {
+		return err
+	}
+	now := d.clock.Now()
+	if d.away == nil {
+		if idle >= d.threshold {
+			return d.checkOut(now.Add(-idle))
+		}
+		return nil
+	}
+	if idle < d.threshold {
+		return d.back(now.Add(-idle))
+	}
+	return nil
+}
+
+// checkOut inserts a check-out at start, if checked in.
+func (d *daemon) checkOut(start time.Time) error {
+	s := recordStore(d.fileName)
+	s.Clock = d.clock
+	records, err := s.Read(1)
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 || records[0].Kind != "in" {
+		return nil
+	}
+	// idleness from before the check-in doesn't count
+	if start.Before(records[0].Timestamp) {
+		start = records[0].Timestamp
+	}
+
+	record := Record{Timestamp: start.Truncate(time.Second), Kind: "out", Notes: IdleNotes}
+	if err := prependRecord(s, record); err != nil {
+		return err
+	}
+	d.away = &record
+	fmt.Fprintf(d.out, "Idle since %s, checked out\n", record.Timestamp.Format(TimeFormat))
+	return nil
+}
+
+// back handles the user's return at the given time: keeping the gap checks
+// in again, discarding it removes the inserted check-out. Asking leaves the
+// return to the answer, which later ticks pick up.
+func (d *daemon) back(at time.Time) error {
+	away := *d.away
+	d.away = nil
+
+	if latest, err := d.isLatest(away); err != nil || !latest {
+		return err
+	}
+	if d.onReturn != OnReturnAsk {
+		return d.settle(idleReturn{away: away, at: at}, d.onReturn)
+	}
+	gap := at.Sub(away.Timestamp).Round(time.Minute)
+	fmt.Fprintf(d.out, "Back after %s idle since %s. [k]eep the gap and check in, [d]iscard it or do [n]othing? [K/d/n] ",
+		hoursToText(gap.Hours()), away.Timestamp.Format("15:04"))
+	d.asked = &idleReturn{away: away, at: at}
+	return nil
+}
+
+// answer settles the asked return once the user has answered; without
+// input, it does nothing with the gap.
+func (d *daemon) answer() error {
+	var answer string
+	select {
+	case line, ok := <-d.answers:
+		if !ok {
+			answer = "n"
+		} else {
+			answer = line
+		}
+	default:
+		return nil
+	}
+	asked := *d.asked
+	d.asked = nil
+
+	action := OnReturnNothing
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "d", "discard":
+		action = OnReturnDiscard
+	case "", "k", "keep":
+		action = OnReturnKeep
+	}
+	return d.settle(asked, action)
+}
+
+// isLatest reports whether the check-out away is still the latest record,
+// not followed by a check-in or edited by hand meanwhile.
+func (d *daemon) isLatest(away Record) (bool, error) {
+	s := recordStore(d.fileName)
+	s.Clock = d.clock
+	records, err := s.Read(1)
+	if err != nil {
+		return false, err
+	}
+	return len(records) > 0 && records[0].Timestamp.Equal(away.Timestamp) && records[0].Kind == away.Kind, nil
+}
+
+// settle applies action to the return r, unless the records changed since
+// the check-out.
+func (d *daemon) settle(r idleReturn, action string) error {
+	if latest, err := d.isLatest(r.away); err != nil || !latest {
+		return err
+	}
+	s := recordStore(d.fileName)
+	s.Clock = d.clock
+
+	switch action {
+	case OnReturnKeep:
+		record := Record{Timestamp: r.at.Truncate(time.Second), Kind: "in", Notes: "Back (takt daemon)"}
+		if err := prependRecord(s, record); err != nil {
+			return err
+		}
+		fmt.Fprintf(d.out, "Check in at %s\n", record.Timestamp.Format(TimeFormat))
+	case OnReturnDiscard:
+		if err := removeLatest(s, r.away); err != nil {
+			return err
+		}
+		fmt.Fprintf(d.out, "Discarded the check-out at %s\n", r.away.Timestamp.Format(TimeFormat))
+	}
+	return nil
+}
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Check out automatically when idle and send reminders",
+	Long: `Watch the idle time and check out automatically. When idle for longer
+than the threshold while checked in, a check-out is inserted at the start of
+the idleness. On return, choose whether to keep the gap as a break (and
+check in again) or discard it (the session goes on as if never idle).
+
+The daemon also sends reminders, once each time they apply.
+
+IDLE SOURCES:
+  auto            x11 or wayland, from DISPLAY and WAYLAND_DISPLAY (default)
+  x11             Runs xprintidle
+  wayland         Asks GNOME's idle monitor with gdbus
+  file:PATH       Time since PATH was last modified; touch it while active
+  command:CMD     Runs CMD, which prints the idle time in milliseconds
+  none            Reminders only
+
+REMINDERS (comma-separated, or none):
+  checkin-by=09:30  Not checked in by 09:30 on a workday (TAKT_WORKDAYS)
+  target            Today's target hours reached while checked in
+  over=10h          Over 10 hours today while checked in
+  break=1h          Checked out for over an hour before the end of the day
+
+NOTIFICATIONS (--notify, repeatable):
+  stdout                      Print them (default)
+  log:PATH                    Append them to PATH
+  command:TEMPLATE            Run TEMPLATE, replacing {title}, {message} and
+                              {rule} in its arguments; no shell is involved
+  webhook:URL                 POST them as JSON to URL
+
+CONFIGURATION:
+  - TAKT_IDLE_SOURCE: Default idle source
+  - TAKT_IDLE_THRESHOLD: Default threshold, e.g. 10m (default: 15m)
+  - TAKT_REMINDERS: Default reminders (default: ` + remind.Default + `)
+  - TAKT_NOTIFY: Default notifications, separated by semicolons
+  - TAKT_WORKDAYS: Working days, e.g. mon-fri or mon,tue,thu (default: mon-fri)
+
+EXAMPLES:
+  takt daemon
+  takt daemon --threshold 10m --source file:~/.takt-heartbeat
+  takt daemon --on-return keep  # don't ask, e.g. under systemd
+  takt daemon --source none --reminders target,over=9h \
+    --notify 'command:notify-send {title} {message}' \
+    --notify webhook:http://localhost:9000/takt
+
+OUTPUT:
+  Idle since 2025-01-09T12:03:12Z, checked out
+  Back after 1h12m idle since 12:03. [k]eep the gap and check in, [d]iscard it or do [n]othing? [K/d/n]
+  [17:02] Target of 8h00m reached`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if config == nil {
+			fmt.Println("Error: config not initialized")
+			return
+		}
+
+		spec, _ := cmd.Flags().GetString("source")
+		if spec == "" {
+			spec = config.IdleSource
+		}
+		threshold, _ := cmd.Flags().GetDuration("threshold")
+		if threshold <= 0 {
+			threshold = config.IdleThreshold
+		}
+		interval, _ := cmd.Flags().GetDuration("interval")
+		onReturn, _ := cmd.Flags().GetString("on-return")
+		switch onReturn {
+		case OnReturnAsk, OnReturnKeep, OnReturnDiscard, OnReturnNothing:
+		default:
+			fmt.Printf("Error: unsupported --on-return: %s (must be %s, %s, %s or %s)\n",
+				onReturn, OnReturnAsk, OnReturnKeep, OnReturnDiscard, OnReturnNothing)
+			return
+		}
+		if interval <= 0 {
+			fmt.Println("Error: --interval must be positive")
+			return
+		}
+
+		reminders := config.Reminders
+		if cmd.Flags().Changed("reminders") {
+			value, _ := cmd.Flags().GetString("reminders")
+			var err error
+			if reminders, err = remind.Parse(value); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+		}
+		notify, _ := cmd.Flags().GetStringArray("notify")
+		if len(notify) == 0 {
+			notify = config.Notify
+		}
+		var sinks []Sink
+		for _, spec := range notify {
+			sink, err := newSink(spec, os.Stdout)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			sinks = append(sinks, sink)
+		}
+
+		source, err := newIdleSource(spec)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		d := &daemon{
+			fileName:  config.FileName,
+			source:    source,
+			threshold: threshold,
+			onReturn:  onReturn,
+			reminders: reminders,
+			sinks:     sinks,
+			clock:     clock,
+			answers:   readAnswers(os.Stdin),
+			out:       os.Stdout,
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if source != nil {
+			fmt.Printf("Watching idle time (%s source, %s threshold)\n", spec, threshold)
+		}
+		if len(reminders) > 0 {
+			fmt.Printf("Sending %d reminder(s) to %s\n", len(reminders), strings.Join(notify, ", "))
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := d.tick(); err != nil {
+				fmt.Printf("Error: %v\n", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	},
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/remind"
+	"github.com/asdf8601/takt-go/pkg/takt"
+)
+
+const daemonTestCSV = `timestamp,kind,notes
+2025-01-09T09:00:00Z,in,work
+`
+
+// newTestDaemon returns a daemon over a heartbeat file, with a clock set by
+// the returned function, which also touches the heartbeat when active.
+func newTestDaemon(t *testing.T, onReturn, answers string) (*daemon, *strings.Builder, func(at string, active bool)) {
+	t.Helper()
+	fileName := newTestRecordsFile(t, daemonTestCSV)
+	heartbeat := filepath.Join(t.TempDir(), "heartbeat")
+	if err := os.WriteFile(heartbeat, nil, 0o644); err != nil {
+		t.Fatalf("Failed to write heartbeat: %v", err)
+	}
+
+	var now time.Time
+	testClock := takt.ClockFunc(func() time.Time { return now })
+	originalClock := clock
+	clock = testClock
+	t.Cleanup(func() { clock = originalClock })
+	set := func(at string, active bool) {
+		var err error
+		if now, err = time.Parse(time.RFC3339, "2025-01-09T"+at+":00Z"); err != nil {
+			t.Fatalf("Invalid time %q: %v", at, err)
+		}
+		if active {
+			if err := os.Chtimes(heartbeat, now, now); err != nil {
+				t.Fatalf("Failed to touch heartbeat: %v", err)
+			}
+		}
+	}
+
+	var out strings.Builder
+	d := &daemon{
+		fileName:  fileName,
+		source:    fileIdleSource{path: heartbeat, clock: testClock},
+		threshold: 15 * time.Minute,
+		onReturn:  onReturn,
+		clock:     testClock,
+		answers:   testAnswers(answers),
+		out:       &out,
+	}
+	return d, &out, set
+}
+
+// testAnswers returns the lines of answers as typed ahead, closed after
+// them.
+func testAnswers(answers string) <-chan string {
+	lines := strings.SplitAfter(answers, "\n")
+	ch := make(chan string, len(lines))
+	for _, line := range lines {
+		if line != "" {
+			ch <- strings.TrimSuffix(line, "\n")
+		}
+	}
+	close(ch)
+	return ch
+}
+
+func tickAt(t *testing.T, d *daemon, set func(string, bool), at string, active bool) {
+	t.Helper()
+	set(at, active)
+	if err := d.tick(); err != nil {
+		t.Fatalf("tick() at %s failed: %v", at, err)
+	}
+}
+
+func daemonRecords(t *testing.T, d *daemon) string {
+	t.Helper()
+	records, err := readRecordsFromFile(d.fileName, -1)
+	if err != nil {
+		t.Fatalf("readRecordsFromFile() failed: %v", err)
+	}
+	return recordLines(records)
+}
+
+// recordLines formats records (for comparison in tests) one per line as "15:04 kind notes".
+func recordLines(records []Record) string {
+	var lines []string
+	for _, r := range records {
+		lines = append(lines, r.Timestamp.Format("15:04")+" "+r.Kind+" "+r.Notes)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestDaemonIdle(t *testing.T) {
+	tests := []struct {
+		name     string
+		onReturn string
+		answers  string
+		want     string
+	}{
+		{"keep", OnReturnAsk, "k\n", "10:45 in Back (takt daemon)\n10:00 out " + IdleNotes + "\n09:00 in work"},
+		{"keep by default", OnReturnAsk, "\n", "10:45 in Back (takt daemon)\n10:00 out " + IdleNotes + "\n09:00 in work"},
+		{"discard", OnReturnAsk, "d\n", "09:00 in work"},
+		{"nothing", OnReturnAsk, "n\n", "10:00 out " + IdleNotes + "\n09:00 in work"},
+		{"no answer", OnReturnAsk, "", "10:00 out " + IdleNotes + "\n09:00 in work"},
+		{"discard without asking", OnReturnDiscard, "", "09:00 in work"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, out, set := newTestDaemon(t, tt.onReturn, tt.answers)
+
+			tickAt(t, d, set, "10:00", true)
+			tickAt(t, d, set, "10:10", false)
+			if got := daemonRecords(t, d); got != "09:00 in work" {
+				t.Fatalf("Checked out below the threshold:\n%s", got)
+			}
+
+			tickAt(t, d, set, "10:20", false)
+			tickAt(t, d, set, "10:30", false)
+			if got := daemonRecords(t, d); got != "10:00 out "+IdleNotes+"\n09:00 in work" {
+				t.Fatalf("Expected one check-out at the start of idleness:\n%s", got)
+			}
+
+			tickAt(t, d, set, "10:45", true)
+			if got := daemonRecords(t, d); got != tt.want {
+				t.Errorf("Records after return:\n%s\nwant:\n%s", got, tt.want)
+			}
+			if tt.onReturn == OnReturnAsk && !strings.Contains(out.String(), "Back after 0h45m idle since 10:00") {
+				t.Errorf("Expected the question, got:\n%s", out.String())
+			}
+		})
+	}
+}
+
+func TestDaemonIdleEdgeCases(t *testing.T) {
+	t.Run("checked out", func(t *testing.T) {
+		d, _, set := newTestDaemon(t, OnReturnKeep, "")
+		if err := os.WriteFile(d.fileName, []byte(editTestCSV), 0o644); err != nil {
+			t.Fatalf("Failed to write records: %v", err)
+		}
+		tickAt(t, d, set, "17:00", true)
+		tickAt(t, d, set, "18:00", false)
+		if d.away != nil {
+			t.Error("Checked out while not checked in")
+		}
+	})
+
+	t.Run("idle since before the check-in", func(t *testing.T) {
+		d, _, set := newTestDaemon(t, OnReturnKeep, "")
+		tickAt(t, d, set, "08:30", true)
+		tickAt(t, d, set, "09:20", false)
+		if got := daemonRecords(t, d); got != "09:00 out "+IdleNotes+"\n09:00 in work" {
+			t.Errorf("Expected the check-out at the check-in:\n%s", got)
+		}
+	})
+
+	t.Run("checked in by hand meanwhile", func(t *testing.T) {
+		d, _, set := newTestDaemon(t, OnReturnAsk, "k\n")
+		tickAt(t, d, set, "10:00", true)
+		tickAt(t, d, set, "10:20", false)
+		set("10:40", false)
+		if _, err := toggleRecord(d.fileName, "by hand"); err != nil {
+			t.Fatalf("toggleRecord() failed: %v", err)
+		}
+		tickAt(t, d, set, "10:45", true)
+		if got := daemonRecords(t, d); !strings.HasPrefix(got, "10:40 in by hand\n") || strings.Count(got, "\n") != 2 {
+			t.Errorf("Expected the manual check-in to stand:\n%s", got)
+		}
+	})
+}
+
+func TestDaemonAnswerLater(t *testing.T) {
+	d, out, set := newTestDaemon(t, OnReturnAsk, "")
+	answers := make(chan string, 1)
+	d.answers = answers
+	rules, _ := remind.Parse("break=30m")
+	sink := &fakeSink{}
+	d.reminders, d.sinks = rules, []Sink{sink}
+
+	tickAt(t, d, set, "10:00", true)
+	tickAt(t, d, set, "10:20", false)
+	tickAt(t, d, set, "10:25", true)
+	if !strings.Contains(out.String(), "Back after 0h25m idle since 10:00") {
+		t.Fatalf("Expected the question, got:\n%s", out.String())
+	}
+	// the reminders go on while the question waits
+	tickAt(t, d, set, "10:40", true)
+	if got := strings.Join(sink.messages, "\n"); got != "10:40 On a break since 10:00, over 0h30m" {
+		t.Errorf("Expected the break reminder while waiting, got %q", got)
+	}
+
+	answers <- "d"
+	tickAt(t, d, set, "10:41", true)
+	if got := daemonRecords(t, d); got != "09:00 in work" {
+		t.Errorf("Expected the check-out discarded on the answer:\n%s", got)
+	}
+}
+
+func TestCommandIdleSource(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses echo")
+	}
+	for output, want := range map[string]time.Duration{
+		"1234":            1234 * time.Millisecond,
+		"(uint64 90000,)": 90 * time.Second,
+	} {
+		source, err := newIdleSource(IdleSourceCommand + "echo " + output)
+		if err != nil {
+			t.Fatalf("newIdleSource() failed: %v", err)
+		}
+		idle, err := source.Idle()
+		if err != nil || idle != want {
+			t.Errorf("Idle() for %q = %v, %v; want %v", output, idle, err, want)
+		}
+	}
+
+	if _, err := newIdleSource("bogus"); err == nil {
+		t.Error("Expected an error for an unknown source")
+	}
+}
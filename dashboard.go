@@ -0,0 +1,19 @@
+package main
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// dashboardHTML is the single-page dashboard served by 'takt serve'. It is
+// self-contained (no external scripts, styles or fonts) so it works offline.
+//
+//go:embed web/index.html
+var dashboardHTML []byte
+
+// handleDashboard serves the embedded dashboard page.
+func handleDashboard(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", "default-src 'self'; style-src 'unsafe-inline'; script-src 'unsafe-inline'")
+	_, _ = w.Write(dashboardHTML)
+}
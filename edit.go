@@ -0,0 +1,387 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/lock"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// Layouts of the file opened by takt edit
+	EditLayoutCSV      = "csv"
+	EditLayoutSessions = "sessions"
+
+	// Comments are ignored when reading the edited file back
+	editComment     = "#"
+	editErrorPrefix = "# ERROR: "
+)
+
+// sessionsHelp heads the file in the sessions layout.
+var sessionsHelp = []string{
+	"# takt sessions, one per line: DATE START END [NOTES] [|| CHECK-OUT NOTES]",
+	"# END is HH:MM (on the next day when not after START), DATE\"T\"HH:MM for",
+	"# sessions longer than a day, or - while checked in. Delete a line to",
+	"# delete its session. Lines starting with # are ignored.",
+}
+
+// errEditAborted is returned when the user gives up fixing an invalid file.
+var errEditAborted = errors.New("edit aborted, no changes written")
+
+// lineError is a validation error of a line of the edited file (1-based).
+type lineError struct {
+	Line int
+	Err  error
+}
+
+// editRecords lets the user edit the records of fileName on a temporary copy
+// in the given layout. runEditor is called with the copy's path; when the
+// result is invalid, the errors are added as comments and the user is asked
+// on in whether to edit again. The records file is replaced atomically only
+// once the copy is valid; a post-edit hook veto puts the old records back.
+// It reports whether the records file changed.
+func editRecords(fileName, layout string, runEditor func(path string) error, in io.Reader, out io.Writer) (bool, error) {
+	var parse func(text string) ([]Record, []lineError)
+	var content string
+	switch layout {
+	case EditLayoutCSV:
+		parse = parseEditedCSV
+		data, err := os.ReadFile(fileName)
+		if err != nil && !os.IsNotExist(err) {
+			return false, fmt.Errorf("failed to read records: %w", err)
+		}
+		content = string(data)
+		if content == "" {
+			content = strings.Join(Header, ",") + "\n"
+		}
+	case EditLayoutSessions:
+		parse = parseEditedSessions
+		records, err := readRecordsFromFile(fileName, -1)
+		if err != nil {
+			return false, fmt.Errorf("failed to read records: %w", err)
+		}
+		lines := append([]string{}, sessionsHelp...)
+		for _, s := range pairSessions(records) {
+			lines = append(lines, formatSessionLine(s))
+		}
+		content = strings.Join(lines, "\n") + "\n"
+	default:
+		return false, fmt.Errorf("unsupported layout: %s (must be '%s' or '%s')", layout, EditLayoutCSV, EditLayoutSessions)
+	}
+
+	tmpFile, err := os.CreateTemp("", "takt_edit_*.txt")
+	if err != nil {
+		return false, fmt.Errorf("could not create temp file: %w", err)
+	}
+	tmpName := tmpFile.Name()
+	defer func() {
+		_ = os.Remove(tmpName)
+	}()
+	if err := tmpFile.Close(); err != nil {
+		return false, err
+	}
+
+	original := content
+	answers := bufio.NewReader(in)
+	for {
+		if err := os.WriteFile(tmpName, []byte(content), 0o600); err != nil {
+			return false, fmt.Errorf("could not write temp file: %w", err)
+		}
+		if err := runEditor(tmpName); err != nil {
+			return false, fmt.Errorf("editor failed: %w", err)
+		}
+		data, err := os.ReadFile(tmpName)
+		if err != nil {
+			return false, fmt.Errorf("could not read temp file: %w", err)
+		}
+		edited := stripEditErrors(string(data))
+
+		records, errs := parse(edited)
+		if len(errs) == 0 {
+			if edited == original {
+				return false, nil
+			}
+			sortRecords(records)
+			err := checkLocks(fileName, records)
+			var lockErr *lock.Error
+			if errors.As(err, &lockErr) {
+				fmt.Fprintf(out, "%v\n", err)
+				if !editAgain(answers, out, 1) {
+					return false, errEditAborted
+				}
+				content = edited
+				continue
+			} else if err != nil {
+				return false, err
+			}
+
+			if err := backupFile(fileName); err != nil && !os.IsNotExist(err) {
+				return false, fmt.Errorf("could not create backup: %w", err)
+			}
+			err = replaceRecords(fileName, records)
+			var hookErr *hookError
+			if errors.As(err, &hookErr) {
+				fmt.Fprintf(out, "%v\n", err)
+				if !editAgain(answers, out, 1) {
+					return false, errEditAborted
+				}
+				content = edited
+				continue
+			} else if err != nil {
+				return false, err
+			}
+			return true, nil
+		}
+
+		for _, e := range errs {
+			fmt.Fprintf(out, "line %d: %v\n", e.Line, e.Err)
+		}
+		if !editAgain(answers, out, len(errs)) {
+			return false, errEditAborted
+		}
+		content = annotateEditErrors(edited, errs)
+	}
+}
+
+// editAgain asks whether to re-open the editor after errors; anything but
+// "n" means yes, and so does an empty line, but not the end of input.
+func editAgain(answers *bufio.Reader, out io.Writer, count int) bool {
+	fmt.Fprintf(out, "%d error(s) found. Edit again? [Y/n] ", count)
+	answer, err := answers.ReadString('\n')
+	if err != nil && answer == "" {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer != "n" && answer != "no"
+}
+
+// stripEditErrors removes the error comments added by annotateEditErrors.
+func stripEditErrors(text string) string {
+	lines := strings.SplitAfter(text, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if !strings.HasPrefix(line, editErrorPrefix) {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "")
+}
+
+// annotateEditErrors adds an error comment above each invalid line.
+func annotateEditErrors(text string, errs []lineError) string {
+	byLine := map[int][]string{}
+	for _, e := range errs {
+		byLine[e.Line] = append(byLine[e.Line], e.Err.Error())
+	}
+
+	var sb strings.Builder
+	for i, line := range strings.SplitAfter(text, "\n") {
+		for _, msg := range byLine[i+1] {
+			sb.WriteString(editErrorPrefix + msg + "\n")
+		}
+		sb.WriteString(line)
+	}
+	return sb.String()
+}
+
+// editLines returns the lines of text that aren't blank or comments, with
+// their 1-based line numbers.
+func editLines(text string) ([]string, []int) {
+	var lines []string
+	var numbers []int
+	for i, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, editComment) {
+			continue
+		}
+		lines = append(lines, line)
+		numbers = append(numbers, i+1)
+	}
+	return lines, numbers
+}
+
+// parseEditedCSV parses and validates records in the CSV layout. The header
+// line is optional.
+func parseEditedCSV(text string) ([]Record, []lineError) {
+	var records []Record
+	var numbers []int
+	var errs []lineError
+
+	lines, lineNumbers := editLines(text)
+	for i, line := range lines {
+		fail := func(err error) {
+			errs = append(errs, lineError{lineNumbers[i], err})
+		}
+		fields, err := csv.NewReader(strings.NewReader(line)).Read()
+		if err != nil {
+			fail(fmt.Errorf("invalid CSV: %w", err))
+			continue
+		}
+		if i == 0 && strings.Join(fields, ",") == strings.Join(Header, ",") {
+			continue
+		}
+		if len(fields) != len(Header) {
+			fail(fmt.Errorf("expected %d fields (%s), got %d", len(Header), strings.Join(Header, ","), len(fields)))
+			continue
+		}
+		timestamp, err := time.Parse(TimeFormat, fields[0])
+		if err != nil {
+			fail(fmt.Errorf("invalid timestamp %q: expected %s", fields[0], TimeFormat))
+			continue
+		}
+		record := Record{Timestamp: timestamp, Kind: fields[1], Notes: fields[2]}
+		if err := validateRecord(record); err != nil {
+			fail(err)
+			continue
+		}
+		records = append(records, record)
+		numbers = append(numbers, lineNumbers[i])
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	// check alternation and overlaps in time order, whatever the file order
+	order := make([]int, len(records))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return records[order[i]].Timestamp.After(records[order[j]].Timestamp)
+	})
+	sorted := make([]Record, len(records))
+	for i, k := range order {
+		sorted[i] = records[k]
+	}
+	sessions, first := pairSessionsIndexed(sorted)
+	for _, e := range checkSessions(sessions) {
+		errs = append(errs, lineError{numbers[order[first[e.Index]]], e.Err})
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return records, nil
+}
+
+// parseEditedSessions parses and validates sessions in the sessions layout.
+func parseEditedSessions(text string) ([]Record, []lineError) {
+	var sessions []Session
+	var numbers []int
+	var errs []lineError
+
+	lines, lineNumbers := editLines(text)
+	for i, line := range lines {
+		s, err := parseSessionLine(line)
+		if err != nil {
+			errs = append(errs, lineError{lineNumbers[i], err})
+			continue
+		}
+		sessions = append(sessions, s)
+		numbers = append(numbers, lineNumbers[i])
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	order := make([]int, len(sessions))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return sessionTime(sessions[order[i]]).After(sessionTime(sessions[order[j]]))
+	})
+	sorted := make([]Session, len(sessions))
+	for i, k := range order {
+		sorted[i] = sessions[k]
+	}
+	for _, e := range checkSessions(sorted) {
+		errs = append(errs, lineError{numbers[order[e.Index]], e.Err})
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return sessionRecords(sorted), nil
+}
+
+var editCmd = &cobra.Command{
+	Use:     "edit",
+	Aliases: []string{"e"},
+	Short:   "Edit the records file",
+	Long: `Edit the records in your configured editor. Set TAKT_EDITOR environment
+variable to specify your preferred editor.
+
+The editor opens a temporary copy. When it exits, the copy is validated:
+timestamps and kinds, check-ins and check-outs alternating, and no overlapping
+sessions. Errors are added as "# ERROR:" comments above the offending lines
+and the editor is opened again until the copy is valid or you answer "n".
+Only a valid copy replaces the records file, atomically; the previous file is
+kept as a .bak backup. The post-edit hook (TAKT_HOOK_POST_EDIT) then gets the
+new records on stdin and can reject them with a non-zero exit, which puts the
+old records back.
+
+EXAMPLES:
+  takt edit                     # Edit the CSV file
+  takt edit --sessions          # Edit one session per line
+  takt e                        # Using alias
+
+SETUP:
+  export TAKT_EDITOR=vim        # Use vim
+  export TAKT_EDITOR=code       # Use VS Code (with --wait)
+  export TAKT_EDITOR=nano       # Use nano
+
+FILE FORMAT:
+  timestamp,kind,notes
+  2025-01-09T17:45:00Z,out,End of day
+  2025-01-09T14:30:00Z,in,Meeting prep
+
+SESSIONS FORMAT:
+  2025-01-09 14:30 17:45 Meeting prep || End of day
+  2025-01-10 09:00 -     Still checked in
+
+OUTPUT:
+  line 3: session overlaps with the one starting at 09:00
+  1 error(s) found. Edit again? [Y/n]`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if config == nil {
+			fmt.Println("Error: config not initialized")
+			return
+		}
+
+		if config.Editor == "" {
+			fmt.Println("Error: TAKT_EDITOR environment variable not set")
+			return
+		}
+
+		layout := EditLayoutCSV
+		if sessions, _ := cmd.Flags().GetBool("sessions"); sessions {
+			layout = EditLayoutSessions
+		}
+		runEditor := func(path string) error {
+			editor := exec.Command(config.Editor, path)
+			editor.Stdin = os.Stdin
+			editor.Stdout = os.Stdout
+			editor.Stderr = os.Stderr
+			return editor.Run()
+		}
+
+		changed, err := editRecords(config.FileName, layout, runEditor, os.Stdin, os.Stdout)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		if changed {
+			fmt.Printf("Saved %s\n", config.FileName)
+		} else {
+			fmt.Println("No changes")
+		}
+	},
+}
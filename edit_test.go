@@ -0,0 +1,171 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/asdf8601/takt-go/pkg/settings"
+)
+
+// newTestRecordsFile creates a temporary records file for the global config.
+func newTestRecordsFile(t *testing.T, csvContent string) string {
+	t.Helper()
+
+	tempFile, err := os.CreateTemp("", "takt_edit_test_*.csv")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = os.Remove(tempFile.Name())
+		_ = os.Remove(tempFile.Name() + ".bak")
+		_ = os.Remove(tempFile.Name() + ".locks")
+	})
+	if _, err := tempFile.WriteString(csvContent); err != nil {
+		t.Fatalf("Failed to write test data: %v", err)
+	}
+	if err := tempFile.Close(); err != nil {
+		t.Fatalf("Failed to close temp file: %v", err)
+	}
+
+	cfg, err := settings.Load()
+	if err != nil {
+		t.Fatalf("settings.Load() failed: %v", err)
+	}
+	originalConfig := config
+	config = cfg
+	config.FileName = tempFile.Name()
+	t.Cleanup(func() { config = originalConfig })
+	return tempFile.Name()
+}
+
+// scriptedEditor returns an editor that replaces the file with each of the
+// contents in turn, saving what it was given in seen.
+func scriptedEditor(t *testing.T, seen *[]string, contents ...string) func(string) error {
+	return func(path string) error {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		*seen = append(*seen, string(data))
+		if len(*seen) > len(contents) {
+			t.Fatalf("Editor opened %d times, expected %d", len(*seen), len(contents))
+		}
+		return os.WriteFile(path, []byte(contents[len(*seen)-1]), 0o600)
+	}
+}
+
+const editTestCSV = `timestamp,kind,notes
+2025-01-09T17:00:00Z,out,done
+2025-01-09T09:00:00Z,in,work
+`
+
+func TestEditRecordsCSV(t *testing.T) {
+	fileName := newTestRecordsFile(t, editTestCSV)
+
+	invalid := `timestamp,kind,notes
+2025-01-10T17:00:00Z,out,done
+2025-01-10T09:00:00Z,in,work
+2025-01-09T17:00:00Z,out,done
+2025-01-09T09:00:00Z,in,work
+2025-01-09T18:00:00Z,out,orphan
+bad line
+`
+	fixed := `timestamp,kind,notes
+2025-01-09T09:00:00Z,in,"work, and more"
+2025-01-10T09:00:00Z,in,work
+2025-01-09T17:00:00Z,out,done
+2025-01-10T17:00:00Z,out,done
+`
+	var seen []string
+	var out strings.Builder
+	changed, err := editRecords(fileName, EditLayoutCSV, scriptedEditor(t, &seen, invalid, fixed), strings.NewReader("\n"), &out)
+	if err != nil || !changed {
+		t.Fatalf("editRecords() = %v, %v", changed, err)
+	}
+
+	if seen[0] != editTestCSV {
+		t.Errorf("Expected the editor to open a copy of the file, got %q", seen[0])
+	}
+	for _, want := range []string{
+		"# ERROR: expected 3 fields (timestamp,kind,notes), got 1\nbad line",
+	} {
+		if !strings.Contains(seen[1], want) {
+			t.Errorf("Expected the re-opened file to contain %q:\n%s", want, seen[1])
+		}
+	}
+	if !strings.Contains(out.String(), "line 7:") || !strings.Contains(out.String(), "Edit again?") {
+		t.Errorf("Unexpected output: %q", out.String())
+	}
+
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		t.Fatalf("Failed to read records: %v", err)
+	}
+	expected := `timestamp,kind,notes
+2025-01-10T17:00:00Z,out,done
+2025-01-10T09:00:00Z,in,work
+2025-01-09T17:00:00Z,out,done
+2025-01-09T09:00:00Z,in,"work, and more"
+`
+	if string(data) != expected {
+		t.Errorf("Expected records sorted newest first:\n%s\ngot:\n%s", expected, data)
+	}
+}
+
+func TestEditRecordsAbort(t *testing.T) {
+	fileName := newTestRecordsFile(t, editTestCSV)
+
+	var seen []string
+	var out strings.Builder
+	overlap := editTestCSV + "2025-01-09T12:00:00Z,out,lunch\n2025-01-09T08:00:00Z,in,early\n"
+	_, err := editRecords(fileName, EditLayoutCSV, scriptedEditor(t, &seen, overlap), strings.NewReader("n\n"), &out)
+	if !errors.Is(err, errEditAborted) {
+		t.Fatalf("Expected the edit to be aborted, got %v", err)
+	}
+	if data, _ := os.ReadFile(fileName); string(data) != editTestCSV {
+		t.Errorf("Expected the records file to be unchanged, got:\n%s", data)
+	}
+}
+
+func TestEditRecordsSessions(t *testing.T) {
+	fileName := newTestRecordsFile(t, editTestCSV)
+
+	// opening and closing the editor changes nothing
+	var seen []string
+	unchanged := func(path string) error {
+		data, err := os.ReadFile(path)
+		seen = append(seen, string(data))
+		return err
+	}
+	changed, err := editRecords(fileName, EditLayoutSessions, unchanged, strings.NewReader(""), &strings.Builder{})
+	if err != nil || changed {
+		t.Fatalf("Expected no changes, got %v, %v", changed, err)
+	}
+	if !strings.HasPrefix(seen[0], sessionsHelp[0]) || !strings.HasSuffix(seen[0], " work || done\n") {
+		t.Errorf("Unexpected sessions layout:\n%s", seen[0])
+	}
+
+	current := seen[0]
+	seen = nil
+	overlapping := current + "2025-01-10 09:00 12:00 a\n2025-01-10 11:00 13:00 b\n"
+	fixed := current + "2025-01-10 09:00 12:00 a\n2025-01-10 12:00 13:00 b\n"
+	var out strings.Builder
+	changed, err = editRecords(fileName, EditLayoutSessions, scriptedEditor(t, &seen, overlapping, fixed), strings.NewReader("y\n"), &out)
+	if err != nil || !changed {
+		t.Fatalf("editRecords() = %v, %v", changed, err)
+	}
+	want := editErrorPrefix + "session overlaps with the one starting at 11:00\n2025-01-10 09:00 12:00 a"
+	if !strings.Contains(seen[1], want) {
+		t.Errorf("Expected the re-opened file to contain %q:\n%s", want, seen[1])
+	}
+
+	records, err := readRecordsFromFile(fileName, -1)
+	if err != nil {
+		t.Fatalf("Failed to read records: %v", err)
+	}
+	if len(records) != 6 || records[0].Notes != "" || records[1].Notes != "b" || records[5].Notes != "work" {
+		t.Errorf("Unexpected records: %+v", records)
+	}
+}
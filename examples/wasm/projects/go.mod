@@ -0,0 +1,3 @@
+module github.com/asdf8601/takt-go/examples/wasm/projects
+
+go 1.24
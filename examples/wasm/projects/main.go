@@ -0,0 +1,122 @@
+//go:build wasip1
+
+// Command projects is a takt WASM plugin that tags new records with a
+// +project taken from keywords in their notes and adds a Projects column to
+// summaries. Build it with Go 1.24 or later:
+//
+//	GOOS=wasip1 GOARCH=wasm go build -buildmode=c-shared -o projects.wasm .
+package main
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"unsafe"
+)
+
+// keywords maps words in notes to the project they belong to.
+var keywords = map[string]string{
+	"acme":    "acme",
+	"standup": "acme",
+	"invoice": "admin",
+}
+
+type record struct {
+	Timestamp string `json:"timestamp"`
+	Kind      string `json:"kind"`
+	Notes     string `json:"notes"`
+}
+
+type row struct {
+	Group   string   `json:"group"`
+	Records []record `json:"records"`
+}
+
+type column struct {
+	Name   string            `json:"name"`
+	Values map[string]string `json:"values"`
+}
+
+// buffers keeps memory handed to the host alive until the next call.
+var buffers = map[uintptr][]byte{}
+
+//go:wasmexport takt_alloc
+func alloc(size uint32) uint32 {
+	buf := make([]byte, size)
+	ptr := uintptr(unsafe.Pointer(unsafe.SliceData(buf)))
+	buffers[ptr] = buf
+	return uint32(ptr)
+}
+
+// input returns the input at ptr and releases its buffer.
+func input(ptr, size uint32) []byte {
+	buf := buffers[uintptr(ptr)][:size]
+	delete(buffers, uintptr(ptr))
+	return buf
+}
+
+// output keeps data alive and packs its pointer and length.
+func output(data []byte) uint64 {
+	if len(data) == 0 {
+		return 0
+	}
+	ptr := uintptr(unsafe.Pointer(unsafe.SliceData(data)))
+	buffers[ptr] = data
+	return uint64(ptr)<<32 | uint64(len(data))
+}
+
+// projects returns the +project tokens of notes.
+func projects(notes string) []string {
+	var out []string
+	for _, word := range strings.Fields(notes) {
+		if len(word) > 1 && word[0] == '+' {
+			out = append(out, word[1:])
+		}
+	}
+	return out
+}
+
+//go:wasmexport takt_transform
+func transform(ptr, size uint32) uint64 {
+	var r record
+	if err := json.Unmarshal(input(ptr, size), &r); err != nil || len(projects(r.Notes)) > 0 {
+		return 0
+	}
+	for _, word := range strings.Fields(strings.ToLower(r.Notes)) {
+		if project, ok := keywords[word]; ok {
+			r.Notes = strings.TrimSpace(r.Notes + " +" + project)
+			data, _ := json.Marshal(r)
+			return output(data)
+		}
+	}
+	return 0
+}
+
+//go:wasmexport takt_columns
+func columns(ptr, size uint32) uint64 {
+	var in struct {
+		Rows []row `json:"rows"`
+	}
+	if err := json.Unmarshal(input(ptr, size), &in); err != nil {
+		return 0
+	}
+	col := column{Name: "Projects", Values: map[string]string{}}
+	for _, r := range in.Rows {
+		seen := map[string]bool{}
+		for _, rec := range r.Records {
+			for _, p := range projects(rec.Notes) {
+				seen[p] = true
+			}
+		}
+		var names []string
+		for p := range seen {
+			names = append(names, p)
+		}
+		sort.Strings(names)
+		col.Values[r.Group] = strings.Join(names, ",")
+	}
+	data, _ := json.Marshal(map[string][]column{"columns": {col}})
+	return output(data)
+}
+
+func main() {}
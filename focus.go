@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/report"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+const (
+	// FocusTag tags the check-in of a focus session and the check-out of a
+	// completed one.
+	FocusTag = "#focus"
+
+	// Notes of the check-out ending a focus session
+	FocusDoneNotes        = "Focus done " + FocusTag
+	FocusInterruptedNotes = "Focus interrupted"
+
+	DefaultFocus = 25 * time.Minute
+)
+
+// hasTag reports whether notes contain the #tag as a word.
+func hasTag(notes, tag string) bool {
+	for _, word := range strings.Fields(notes) {
+		if strings.EqualFold(word, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// focusTimer runs focus sessions: check in, count down, check out.
+type focusTimer struct {
+	fileName string
+	out      io.Writer
+	// live redraws the countdown every second on a terminal
+	live bool
+	// sleep waits for d, or returns the context's error when it is done
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// sleepContext waits for d unless ctx is done first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// countdown waits until end, redrawing the remaining time after label. It
+// returns the context's error when interrupted.
+func (f *focusTimer) countdown(ctx context.Context, end time.Time, label string) error {
+	for {
+		remaining := end.Sub(clock.Now())
+		if remaining <= 0 {
+			if f.live {
+				fmt.Fprint(f.out, "\r\033[K")
+			}
+			return nil
+		}
+		if f.live {
+			secs := int(remaining.Round(time.Second).Seconds())
+			fmt.Fprintf(f.out, "\r\033[K%02d:%02d %s", secs/60, secs%60, label)
+		}
+		step := remaining % time.Second
+		if step == 0 {
+			step = time.Second
+		}
+		if err := f.sleep(ctx, step); err != nil {
+			if f.live {
+				fmt.Fprintln(f.out)
+			}
+			return err
+		}
+	}
+}
+
+// run checks in with the focus tag, counts down d and checks out; when
+// interrupted, it checks out at once without the tag, so the session
+// doesn't count as a completed focus. With a break, it then counts the
+// break down.
+func (f *focusTimer) run(ctx context.Context, d, breakTime time.Duration, notes string) error {
+	records, err := readRecordsFromFile(f.fileName, 1)
+	if err != nil {
+		return err
+	}
+	if len(records) > 0 && records[0].Kind == "in" {
+		record, err := toggleRecord(f.fileName, "")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(f.out, "Check out at %s\n", record.Timestamp.Format(TimeFormat))
+	}
+
+	notes = strings.TrimSpace(notes + " " + FocusTag)
+	start, err := toggleRecord(f.fileName, notes)
+	if err != nil {
+		return err
+	}
+	end := start.Timestamp.Add(d)
+	fmt.Fprintf(f.out, "Check in at %s, focus until %s\n", start.Timestamp.Format(TimeFormat), end.Format("15:04"))
+
+	if err := f.countdown(ctx, end, start.Notes); err != nil {
+		record, err := toggleRecord(f.fileName, FocusInterruptedNotes)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(f.out, "Check out at %s, focus interrupted\n", record.Timestamp.Format(TimeFormat))
+		return nil
+	}
+	record, err := toggleRecord(f.fileName, FocusDoneNotes)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(f.out, "\aCheck out at %s, focus done\n", record.Timestamp.Format(TimeFormat))
+
+	if breakTime <= 0 {
+		return nil
+	}
+	breakEnd := record.Timestamp.Add(breakTime)
+	fmt.Fprintf(f.out, "Break until %s\n", breakEnd.Format("15:04"))
+	if err := f.countdown(ctx, breakEnd, "break"); err != nil {
+		return nil
+	}
+	fmt.Fprintln(f.out, "\aBreak over")
+	return nil
+}
+
+// focusColumns returns a Focus column with the completed focus sessions of
+// each row, or none without focus sessions.
+func focusColumns(period string, records []Record) ([]report.Column, error) {
+	label, err := report.Labeler(period)
+	if err != nil {
+		return nil, err
+	}
+	counts := map[string]int{}
+	for _, s := range pairSessions(records) {
+		if !s.IsOpen() && hasTag(s.OutNotes, FocusTag) {
+			counts[label(s.Start)]++
+		}
+	}
+	if len(counts) == 0 {
+		return nil, nil
+	}
+	values := map[string]string{}
+	for group, count := range counts {
+		values[group] = strconv.Itoa(count)
+	}
+	return []report.Column{{Name: "Focus", Values: values}}, nil
+}
+
+var focusCmd = &cobra.Command{
+	Use:   "focus [DURATION] [NOTE]",
+	Short: "Check in for a focus session with a countdown",
+	Long: `Start a focus session (pomodoro): check in with the note tagged #focus,
+count down in the terminal and check out when the time is up. If you're
+checked in already, that session is checked out first.
+
+Completed focus sessions end with a check-out tagged #focus; the day, week,
+month and year summaries count them in a Focus column. Interrupting with
+Ctrl-C checks out at once, and the session counts as work but not as a
+completed focus.
+
+DURATION defaults to 25m. With --break, a break countdown follows. The
+countdown follows the real time, so takt focus refuses a pinned --now.
+
+EXAMPLES:
+  takt focus                        # 25 minutes
+  takt focus 25m "Write the report"
+  takt focus 50m "Deep work" --break 10m
+
+OUTPUT:
+  Check in at 2025-01-09T14:30:00Z, focus until 14:55
+  24:59 Write the report #focus
+  Check out at 2025-01-09T14:55:00Z, focus done`,
+	Args: cobra.MaximumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if config == nil {
+			fmt.Println("Error: config not initialized")
+			return
+		}
+		// the countdown waits for the clock, which a pinned one never reaches
+		if !config.Now.IsZero() {
+			fmt.Println("Error: takt focus needs the real time: unset --now and TAKT_NOW")
+			return
+		}
+
+		d := DefaultFocus
+		var notes string
+		if len(args) > 0 {
+			parsed, err := time.ParseDuration(args[0])
+			switch {
+			case err == nil && parsed > 0:
+				d = parsed
+				args = args[1:]
+			case err == nil || len(args) == 2:
+				fmt.Printf("Error: invalid duration %q: expected e.g. 25m\n", args[0])
+				return
+			}
+		}
+		if len(args) > 0 {
+			notes = args[0]
+		}
+		breakTime, _ := cmd.Flags().GetDuration("break")
+
+		if _, err := resolveStaleSession(config.FileName, os.Stdin, os.Stdout, false); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		f := &focusTimer{
+			fileName: config.FileName,
+			out:      os.Stdout,
+			live:     term.IsTerminal(int(os.Stdout.Fd())),
+			sleep:    sleepContext,
+		}
+		if err := f.run(ctx, d, breakTime, notes); err != nil {
+			fmt.Printf("Error: %v\n", err)
+		}
+	},
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/takt"
+)
+
+// newTestFocus returns a focus timer whose sleep advances a test clock,
+// canceling after interruptAfter when it is set.
+func newTestFocus(t *testing.T, csv string, interruptAfter time.Duration) (*focusTimer, *strings.Builder, context.Context) {
+	t.Helper()
+	fileName := newTestRecordsFile(t, csv)
+	now := time.Date(2025, 1, 9, 14, 30, 0, 0, time.UTC)
+	originalClock := clock
+	clock = takt.ClockFunc(func() time.Time { return now })
+	t.Cleanup(func() { clock = originalClock })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	slept := time.Duration(0)
+	var out strings.Builder
+	f := &focusTimer{
+		fileName: fileName,
+		out:      &out,
+		sleep: func(ctx context.Context, d time.Duration) error {
+			if interruptAfter > 0 && slept+d > interruptAfter {
+				now = now.Add(interruptAfter - slept)
+				cancel()
+				return ctx.Err()
+			}
+			slept += d
+			now = now.Add(d)
+			return nil
+		},
+	}
+	return f, &out, ctx
+}
+
+func TestFocus(t *testing.T) {
+	f, out, ctx := newTestFocus(t, editTestCSV, 0)
+	if err := f.run(ctx, 25*time.Minute, 5*time.Minute, "Write the report"); err != nil {
+		t.Fatalf("run() failed: %v", err)
+	}
+
+	records, err := readRecordsFromFile(f.fileName, 2)
+	if err != nil {
+		t.Fatalf("readRecordsFromFile() failed: %v", err)
+	}
+	if records[0].Notes != FocusDoneNotes || records[0].Timestamp.Format("15:04") != "14:55" {
+		t.Errorf("Expected a focus check-out at 14:55, got %+v", records[0])
+	}
+	if records[1].Notes != "Write the report #focus" || records[1].Timestamp.Format("15:04") != "14:30" {
+		t.Errorf("Expected a focus check-in at 14:30, got %+v", records[1])
+	}
+	for _, want := range []string{"focus until 14:55", "focus done", "Break until 15:00", "Break over"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Output missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestFocusInterrupted(t *testing.T) {
+	f, out, ctx := newTestFocus(t, daemonTestCSV, 10*time.Minute)
+	if err := f.run(ctx, 25*time.Minute, 0, ""); err != nil {
+		t.Fatalf("run() failed: %v", err)
+	}
+
+	records, err := readRecordsFromFile(f.fileName, -1)
+	if err != nil {
+		t.Fatalf("readRecordsFromFile() failed: %v", err)
+	}
+	got := recordLines(records)
+	want := "14:40 out Focus interrupted\n14:30 in #focus\n14:30 out \n09:00 in work"
+	if got != want {
+		t.Errorf("Records:\n%s\nwant:\n%s", got, want)
+	}
+	if !strings.Contains(out.String(), "focus interrupted") {
+		t.Errorf("Expected the interruption in the output:\n%s", out.String())
+	}
+
+	columns, err := focusColumns("day", records)
+	if err != nil || columns != nil {
+		t.Errorf("focusColumns() = %v, %v; an interrupted focus doesn't count", columns, err)
+	}
+}
+
+func TestFocusColumns(t *testing.T) {
+	at := func(hhmm string) time.Time {
+		t, _ := time.Parse(time.RFC3339, "2025-01-09T"+hhmm+":00Z")
+		return t
+	}
+	records := []Record{
+		{Timestamp: at("10:25"), Kind: "out", Notes: FocusDoneNotes},
+		{Timestamp: at("10:00"), Kind: "in", Notes: "b #focus"},
+		{Timestamp: at("09:55"), Kind: "out", Notes: FocusDoneNotes},
+		{Timestamp: at("09:30"), Kind: "in", Notes: "a #focus"},
+		{Timestamp: at("09:20"), Kind: "out"},
+		{Timestamp: at("09:00"), Kind: "in"},
+	}
+	columns, err := focusColumns("day", records)
+	if err != nil {
+		t.Fatalf("focusColumns() failed: %v", err)
+	}
+	if len(columns) != 1 || columns[0].Name != "Focus" || columns[0].Values["2025-01-09"] != "2" {
+		t.Errorf("focusColumns() = %+v", columns)
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/report"
+	"github.com/spf13/cobra"
+)
+
+// printForecast writes the remaining hours and leave times of a forecast.
+func printForecast(w io.Writer, f report.Forecast, period string) {
+	name := "week"
+	if period == report.PeriodMonth {
+		name = "month"
+	}
+	// leave formats when to leave, with the days later when not today
+	leave := func(hours float64) string {
+		at := f.LeaveAt(hours)
+		day := func(t time.Time) time.Time {
+			return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		}
+		if days := int(day(at).Sub(day(f.Now)).Hours() / 24); days > 0 {
+			return fmt.Sprintf("%s (+%d)", at.Format("15:04"), days)
+		}
+		return at.Format("15:04")
+	}
+
+	fmt.Fprintf(w, "%s %s (%s to %s)\n", strings.ToUpper(name[:1])+name[1:], f.Period, f.From.Format(DateFormat), f.To.Format(DateFormat))
+	fmt.Fprintf(w, "  Worked %s of %s, %s to go\n", hoursToText(f.Worked), hoursToText(f.Target), hoursToText(f.Remaining()))
+	fmt.Fprintf(w, "  Balance before today: %s\n", formatOvertime(f.Balance))
+
+	fmt.Fprintf(w, "Today, %s\n", f.Now.Format("Mon 2006-01-02"))
+	status := "checked out"
+	if f.CheckedIn() {
+		status = "checked in since " + f.Since.Format("15:04")
+	}
+	fmt.Fprintf(w, "  Worked %s of %s, %s\n", hoursToText(f.TodayWorked), hoursToText(f.TodayTarget), status)
+	switch {
+	case f.TodayTarget == 0:
+		fmt.Fprintln(w, "  Day off")
+	case f.TodayRemaining() == 0:
+		fmt.Fprintf(w, "  Today's target met (%s)\n", formatOvertime(f.TodayWorked-f.TodayTarget))
+	case f.CheckedIn():
+		fmt.Fprintf(w, "  Leave at %s to meet today's target (%s to go)\n", leave(f.TodayRemaining()), hoursToText(f.TodayRemaining()))
+	default:
+		fmt.Fprintf(w, "  Work %s more to meet today's target\n", hoursToText(f.TodayRemaining()))
+	}
+	switch {
+	case f.Remaining() == 0:
+		fmt.Fprintf(w, "  The %s's target is met (%s)\n", name, formatOvertime(f.Worked-f.Target))
+	case f.CheckedIn() && f.Remaining() > f.TodayRemaining() && f.Remaining() < 24:
+		fmt.Fprintf(w, "  Leave at %s to meet the %s's target today (%s to go)\n", leave(f.Remaining()), name, hoursToText(f.Remaining()))
+	}
+
+	if len(f.Rest) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "Rest of the %s, once today's target is met\n", name)
+	for _, day := range f.Rest {
+		fmt.Fprintf(w, "  %s %8s\n", day.Date.Format("Mon 2006-01-02"), hoursToText(day.Hours))
+	}
+}
+
+var forecastCmd = &cobra.Command{
+	Use:   "forecast [week|month]",
+	Short: "Forecast the hours left and when to leave to meet the target",
+	Long: `Forecast the hours left to meet the target of the week (default) or
+the month, counting the open session until now. The target is TARGET_HOURS
+for each working day (TAKT_WORKDAYS) of the period.
+
+Shows the balance of the days before today, when to leave today to meet the
+daily target or the whole period's target, and how the rest is shared over
+the working days left once today's target is met.
+
+EXAMPLES:
+  takt forecast                 # this week
+  takt forecast month
+
+OUTPUT:
+  Week 2025-W02 (2025-01-06 to 2025-01-12)
+    Worked 1d04h00m of 1d16h00m, 12h00m to go
+    Balance before today: 00h00m
+  Today, Thu 2025-01-09
+    Worked 4h00m of 8h00m, checked in since 13:00
+    Leave at 18:00 to meet today's target (4h00m to go)
+    Leave at 02:00 (+1) to meet the week's target today (12h00m to go)
+  Rest of the week, once today's target is met
+    Fri 2025-01-10    8h00m`,
+	Args:      cobra.MatchAll(cobra.MaximumNArgs(1), cobra.OnlyValidArgs),
+	ValidArgs: []string{report.PeriodWeek, report.PeriodMonth},
+	Run: func(cmd *cobra.Command, args []string) {
+		if config == nil {
+			fmt.Println("Error: config not initialized")
+			return
+		}
+		period := report.PeriodWeek
+		if len(args) > 0 {
+			period = args[0]
+		}
+		records, err := readReportRecords(-1)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		f, err := reportConfig().Forecast(records, period)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		printForecast(os.Stdout, f, period)
+	},
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/ansi"
+	"github.com/asdf8601/takt-go/pkg/grid"
+	"github.com/spf13/cobra"
+)
+
+var gridCmd = &cobra.Command{
+	Short: "Visual grid showing daily activity with colors",
+	Use:   "grid [YEAR] [LEGEND]",
+	Long: `Display a visual grid showing daily activity for the specified year,
+for any date range, or several years stacked.
+Each day is represented by a colored symbol indicating hours worked.
+Features month separators, color coding, and activity statistics.
+Default shows current year without legend.
+
+EXAMPLES:
+  takt grid                     # Show current year
+  takt grid 2024                # Show 2024
+  takt grid 2025 true           # Show 2025 with legend
+  takt grid --from 2024-10-19   # Rolling range up to today
+  takt grid --from 2025-04-01 --to 2026-03-31   # Fiscal year
+  takt grid 2025 --years 3      # 2023, 2024 and 2025 stacked
+  takt grid --month 2026-10     # Month calendar with each day's hours
+  takt grid 2025 --svg out.svg  # Export as SVG (GitHub-style layout)
+  takt grid --png out.png --layout vertical --balance
+  takt grid --symbols ascii --color never
+
+EXPORT:
+  --svg FILE, --png FILE        Write an image with month labels and a legend
+  --layout horizontal|vertical  Weeks as columns (default) or as rows
+  --balance                     Color by balance vs TARGET_HOURS instead of hours
+
+GRID SYMBOLS:
+  nerd-font  unicode  ascii
+  󰋣          ·        .      = 0-1 hours (minimal work) - Gray
+  ▪          ░        -      = 1-4 hours (light work) - Yellow
+  ▮          ▒        +      = 4-8 hours (normal work) - Green
+  󰈸          ▓        *      = 8-12 hours (heavy work) - Orange
+  󰯆          █        #      = 12+ hours (very heavy work) - Red
+
+  Thresholds scale with TAKT_TARGET_HOURS (shown for 8h): with a 6h target
+  "normal" ends at 6h. Set TAKT_GRID_THRESHOLDS=1,4,8,12 to fix them.
+  Choose symbols with --symbols or TAKT_GRID_SYMBOLS. Colors follow --color
+  (auto: only on a terminal and when NO_COLOR is unset).
+
+FEATURES:
+  • Color-coded activity levels
+  • Month separators for better readability
+  • Activity statistics (total days, active days, activity rate)
+  • Improved legend with descriptions
+  • Better visual alignment and formatting
+
+OUTPUT FORMAT:
+      Date       W  M  T  W  T  F  S  S
+      ══════════════════════════════════
+      2025-01-01 01       󰋣 󰈸 󰈸 󰋣 󰋣
+      2025-01-06 02 󰈸 󰈸 󰋣 󰈸 󰈸 󰋣 󰋣
+      ──────────────────────────────────
+      📊 Summary:
+      ├─ Total tracked days: 192
+      ├─ Active work days: 116
+      └─ Activity rate: 60.4%`,
+	Run: func(cmd *cobra.Command, args []string) {
+		lenArgs := len(args)
+		legend := false
+		year := ""
+
+		if lenArgs < 1 {
+			year = clock.Now().Format("2006")
+		} else {
+			year = args[0]
+		}
+
+		if lenArgs > 1 {
+			legend = args[1] == "true"
+		}
+
+		from, _ := cmd.Flags().GetString("from")
+		to, _ := cmd.Flags().GetString("to")
+		years, _ := cmd.Flags().GetInt("years")
+		ranges, err := grid.Ranges(year, years, from, to, clock.Now())
+		if err != nil {
+			log.Fatalf("Failed to print grid: %v", err)
+		}
+
+		svgFile, _ := cmd.Flags().GetString("svg")
+		pngFile, _ := cmd.Flags().GetString("png")
+		if svgFile != "" || pngFile != "" {
+			r := grid.Range{From: ranges[0].From, To: ranges[len(ranges)-1].To}
+			if err := exportGrid(r, svgFile, pngFile, cmd); err != nil {
+				log.Fatalf("Failed to export grid: %v", err)
+			}
+			return
+		}
+
+		symbols, _ := cmd.Flags().GetString("symbols")
+		if symbols == "" {
+			symbols = config.GridSymbols
+		}
+		colorMode, _ := cmd.Flags().GetString("color")
+		style, err := grid.NewStyle(symbols, colorMode)
+		if err != nil {
+			log.Fatalf("Failed to print grid: %v", err)
+		}
+
+		month, _ := cmd.Flags().GetString("month")
+		if month != "" {
+			t, err := time.ParseInLocation("2006-01", month, time.Local)
+			if err != nil {
+				log.Fatalf("Invalid month %q: expected YYYY-MM", month)
+			}
+			records, err := readReportRecords(-1)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if err := grid.WriteMonth(os.Stdout, reportConfig(), records, t, style, config.GridThresholdsOrDefault()); err != nil {
+				log.Fatalf("Failed to print calendar: %v", err)
+			}
+			return
+		}
+
+		for i, r := range ranges {
+			if len(ranges) > 1 {
+				if i > 0 {
+					fmt.Println()
+				}
+				fmt.Printf("    %s\n", style.Paint(ansi.Bold, r.From.Format("2006")))
+			}
+			if err := printGrid(r, legend && i == len(ranges)-1, style); err != nil {
+				log.Fatalf("Failed to print grid: %v", err)
+			}
+		}
+	},
+}
+
+// printGrid prints the grid of the records within the range.
+func printGrid(r grid.Range, legend bool, style grid.Style) error {
+	records, err := readReportRecords(-1)
+	if err != nil {
+		return fmt.Errorf("failed to read all records: %w", err)
+	}
+
+	if len(records) == 0 {
+		return errors.New("no records found")
+	}
+
+	days, err := grid.Days(reportConfig(), records, r)
+	if err != nil {
+		return err
+	}
+
+	th := config.GridThresholdsOrDefault()
+	grid.Write(os.Stdout, grid.Build(days, style, th), legend, style, th)
+	return nil
+}
+
+// exportGrid writes the grid of the range to the SVG and/or PNG files using
+// the layout and coloring flags of cmd.
+func exportGrid(r grid.Range, svgFile, pngFile string, cmd *cobra.Command) error {
+	layout, _ := cmd.Flags().GetString("layout")
+	balance, _ := cmd.Flags().GetBool("balance")
+
+	records, err := readReportRecords(-1)
+	if err != nil {
+		return fmt.Errorf("failed to read all records: %w", err)
+	}
+	days, err := grid.Days(reportConfig(), records, r)
+	if err != nil {
+		return err
+	}
+
+	for _, out := range []struct {
+		fileName string
+		asPNG    bool
+	}{{svgFile, false}, {pngFile, true}} {
+		if out.fileName == "" {
+			continue
+		}
+		img, err := grid.NewImage(days, layout, balance, config.TargetHours, config.GridThresholdsOrDefault())
+		if err != nil {
+			return err
+		}
+		if err := img.WriteFile(out.fileName, out.asPNG); err != nil {
+			return err
+		}
+		fmt.Printf("Grid written to %s\n", out.fileName)
+	}
+	return nil
+}
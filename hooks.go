@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/asdf8601/takt-go/pkg/settings"
+)
+
+const (
+	// Hook events
+	HookPreCheck  = settings.HookPreCheck
+	HookPostCheck = settings.HookPostCheck
+	HookPostEdit  = settings.HookPostEdit
+	HookPostSync  = settings.HookPostSync
+)
+
+// hookEvent is the JSON written to a hook's stdin: the new record for
+// check and sync hooks, all records for edit hooks.
+type hookEvent struct {
+	Event   string       `json:"event"`
+	File    string       `json:"file"`
+	Record  *recordJSON  `json:"record,omitempty"`
+	Records []recordJSON `json:"records,omitempty"`
+}
+
+// hookError is returned when a hook vetoes an action with a non-zero exit.
+type hookError struct {
+	Event  string
+	Err    error
+	Stderr string
+}
+
+func (e *hookError) Error() string {
+	msg := fmt.Sprintf("%s hook vetoed the action: %v", e.Event, e.Err)
+	if e.Stderr != "" {
+		msg += ": " + e.Stderr
+	}
+	return msg
+}
+
+func (e *hookError) Unwrap() error {
+	return e.Err
+}
+
+// runHook runs the command configured for event, if any, with the record
+// (or records) as JSON on stdin and the configuration in the environment.
+// A non-zero exit is returned as a *hookError.
+func runHook(event string, record *Record, records []Record) error {
+	if config == nil || config.Hooks[event] == "" {
+		return nil
+	}
+
+	payload := hookEvent{Event: event, File: config.FileName}
+	if record != nil {
+		r := toRecordJSON(*record)
+		payload.Record = &r
+	}
+	for _, r := range records {
+		payload.Records = append(payload.Records, toRecordJSON(r))
+	}
+	input, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s hook input: %w", event, err)
+	}
+
+	var stderr bytes.Buffer
+	cmd := shellCommand(config.Hooks[event])
+	cmd.Stdin = bytes.NewReader(append(input, '\n'))
+	cmd.Stdout = os.Stderr // keep stdout for takt's own output
+	cmd.Stderr = &stderr
+	cmd.Env = append(taktEnv(), "TAKT_HOOK="+event)
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return fmt.Errorf("failed to run %s hook: %w", event, err)
+		}
+		return &hookError{Event: event, Err: err, Stderr: strings.TrimSpace(stderr.String())}
+	}
+	_, _ = os.Stderr.Write(stderr.Bytes())
+	return nil
+}
+
+// shellCommand runs a command line with the platform's shell.
+func shellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command)
+	}
+	return exec.Command("sh", "-c", command)
+}
+
+// taktEnv returns the environment for hooks and plugins: the current one plus
+// the resolved configuration, so they see the same file and settings.
+func taktEnv() []string {
+	env := os.Environ()
+	if config == nil {
+		return env
+	}
+	env = append(env,
+		"TAKT_FILE="+config.FileName,
+		"TAKT_TARGET_HOURS="+strconv.FormatFloat(config.TargetHours, 'f', -1, 64),
+		"TAKT_GRID_SYMBOLS="+config.GridSymbols,
+		"TAKT_VERSION="+Version,
+	)
+	if !config.Now.IsZero() {
+		env = append(env, "TAKT_NOW="+config.Now.Format(TimeFormat))
+	}
+	return env
+}
+
+// syncHook runs the post-sync hook with the latest record.
+func syncHook() error {
+	records, err := readRecords(1)
+	if err != nil {
+		return err
+	}
+	var latest *Record
+	if len(records) > 0 {
+		latest = &records[0]
+	}
+	return runHook(HookPostSync, latest, nil)
+}
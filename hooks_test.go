@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestToggleRecordHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook commands use sh")
+	}
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.json")
+
+	tests := []struct {
+		name    string
+		hooks   map[string]string
+		wantErr bool
+		records int
+	}{
+		{"no hooks", nil, false, 3},
+		{"pre-check receives the record", map[string]string{HookPreCheck: "cat > " + input}, false, 3},
+		{"pre-check veto", map[string]string{HookPreCheck: "echo busy >&2; exit 1"}, true, 2},
+		{"post-check veto rolls back", map[string]string{HookPostCheck: "exit 2"}, true, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fileName := newTestRecordsFile(t, editTestCSV)
+			config.Hooks = tt.hooks
+
+			record, err := toggleRecord(fileName, "hooked")
+			var hookErr *hookError
+			if tt.wantErr != errors.As(err, &hookErr) {
+				t.Fatalf("toggleRecord() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && record.Kind != "in" {
+				t.Errorf("Expected a check-in, got %q", record.Kind)
+			}
+
+			records, err := readRecordsFromFile(fileName, -1)
+			if err != nil {
+				t.Fatalf("readRecordsFromFile() failed: %v", err)
+			}
+			if len(records) != tt.records {
+				t.Errorf("Expected %d records, got %d", tt.records, len(records))
+			}
+		})
+	}
+
+	data, err := os.ReadFile(input)
+	if err != nil {
+		t.Fatalf("Hook input not written: %v", err)
+	}
+	for _, want := range []string{`"event":"pre-check"`, `"kind":"in"`, `"notes":"hooked"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Hook input %s missing %s", data, want)
+		}
+	}
+}
+
+func TestHookEnvironment(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook commands use sh")
+	}
+	fileName := newTestRecordsFile(t, editTestCSV)
+	output := filepath.Join(t.TempDir(), "env")
+	config.Hooks = map[string]string{HookPostSync: `echo "$TAKT_HOOK $TAKT_FILE" > ` + output}
+
+	if err := runHook(HookPostSync, nil, nil); err != nil {
+		t.Fatalf("runHook() failed: %v", err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("Hook output not written: %v", err)
+	}
+	if got, want := strings.TrimSpace(string(data)), HookPostSync+" "+fileName; got != want {
+		t.Errorf("Hook environment = %q, want %q", got, want)
+	}
+}
+
+func TestEditRecordsPostEditVeto(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook commands use sh")
+	}
+	fileName := newTestRecordsFile(t, editTestCSV)
+	config.Hooks = map[string]string{HookPostEdit: "echo locked >&2; exit 1"}
+
+	edited := strings.Replace(editTestCSV, "work", "changed", 1)
+	var seen []string
+	var out strings.Builder
+	changed, err := editRecords(fileName, EditLayoutCSV, scriptedEditor(t, &seen, edited), strings.NewReader("n\n"), &out)
+	if !errors.Is(err, errEditAborted) || changed {
+		t.Fatalf("editRecords() = %v, %v; want aborted", changed, err)
+	}
+	if !strings.Contains(out.String(), "post-edit hook vetoed the action") || !strings.Contains(out.String(), "locked") {
+		t.Errorf("Expected the hook error in the output, got %q", out.String())
+	}
+	data, _ := os.ReadFile(fileName)
+	if string(data) != editTestCSV {
+		t.Errorf("Records file changed despite the veto:\n%s", data)
+	}
+}
+
+func TestEditRecordsPostEditSeesNewRecords(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook commands use sh")
+	}
+	fileName := newTestRecordsFile(t, editTestCSV)
+	config.Hooks = map[string]string{HookPostEdit: `grep -q changed "$TAKT_FILE"`}
+
+	edited := strings.Replace(editTestCSV, "work", "changed", 1)
+	var seen []string
+	changed, err := editRecords(fileName, EditLayoutCSV, scriptedEditor(t, &seen, edited), strings.NewReader(""), io.Discard)
+	if err != nil || !changed {
+		t.Fatalf("editRecords() = %v, %v; expected the hook to see the written records", changed, err)
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/billing"
+	"github.com/asdf8601/takt-go/pkg/report"
+	"github.com/asdf8601/takt-go/pkg/settings"
+	"github.com/asdf8601/takt-go/pkg/takt"
+	"github.com/spf13/cobra"
+)
+
+// DefaultCurrency is the currency of invoices unless TAKT_CURRENCY is set.
+const DefaultCurrency = settings.DefaultCurrency
+
+// billingEntries returns the closed sessions of records that start in the
+// month and name the project (any project when empty), and the number of
+// open sessions left out.
+func billingEntries(records []Record, month time.Time, project string) ([]billing.Entry, int) {
+	from := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.Local)
+	to := from.AddDate(0, 1, 0)
+	project = strings.ToLower(strings.TrimPrefix(project, takt.ProjectPrefix))
+
+	var entries []billing.Entry
+	open := 0
+	for _, s := range pairSessions(records) {
+		if s.Start.IsZero() || s.Start.Before(from) || !s.Start.Before(to) {
+			continue
+		}
+		if project != "" && !slices.Contains(takt.Projects(s.Notes), project) {
+			continue
+		}
+		if s.IsOpen() {
+			open++
+			continue
+		}
+		entries = append(entries, billing.Entry{Start: s.Start.In(time.Local), End: s.End.In(time.Local), Notes: s.Notes})
+	}
+	return entries, open
+}
+
+var invoiceCmd = &cobra.Command{
+	Use:   "invoice",
+	Short: "Generate an itemized invoice from the sessions of a month",
+	Long: `Generate an itemized invoice from the sessions of a month, priced with a
+rate card, as Markdown, HTML or CSV.
+
+The rate card (--rates, default $TAKT_RATES or ~/.takt_rates.csv) is a CSV
+of scope,name,rate,from lines. The scope is default, project (+acme in the
+notes) or tag (#urgent); a name may keep the prefix of its own scope only.
+From is the optional date the rate takes effect.
+A session is billed at the rate of one of its tags, else of one of its
+projects, else the default, each the latest effective on the session's day:
+
+  scope,name,rate,from
+  default,,80,
+  project,acme,100,
+  project,acme,120,2026-09-01
+  tag,urgent,150,
+
+Each session is rounded with --rounding (default $TAKT_BILLING_ROUNDING or
+none), e.g. up:15m bills in 15-minute increments per session. Tax comes
+from --tax or $TAKT_TAX, the currency from --currency or $TAKT_CURRENCY
+(default EUR). Open sessions are left out with a warning.
+
+EXAMPLES:
+  takt invoice --project acme --month 2026-09
+  takt invoice --project acme --month 2026-09 --format html --output acme-2026-09.html
+  takt invoice --month 2026-09 --rounding up:15m --tax 21 --number 2026-007
+
+OUTPUT:
+  # Invoice 2026-007
+
+  - Project: acme
+  - Period: 2026-09
+  - Rounding: up:15m
+
+  | Date | Time | Description | Hours | Billed | Rate | Amount |
+  |------|------|-------------|------:|-------:|-----:|-------:|
+  | 2026-09-01 | 09:00–17:00 | +acme review | 8.00 | 8.00 | €100.00 | €800.00 |
+  ...
+  **Total:** €1,149.50`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if config == nil {
+			fmt.Println("Error: config not initialized")
+			return
+		}
+		if err := runInvoice(cmd, os.Stdout); err != nil {
+			fmt.Printf("Error: %v\n", err)
+		}
+	},
+}
+
+// runInvoice writes the invoice that the flags of cmd describe.
+func runInvoice(cmd *cobra.Command, stdout io.Writer) error {
+	project, _ := cmd.Flags().GetString("project")
+	format, _ := cmd.Flags().GetString("format")
+	output, _ := cmd.Flags().GetString("output")
+	number, _ := cmd.Flags().GetString("number")
+
+	month := clock.Now().In(time.Local)
+	if value, _ := cmd.Flags().GetString("month"); value != "" {
+		var err error
+		if month, err = time.ParseInLocation("2006-01", value, time.Local); err != nil {
+			return fmt.Errorf("invalid month %q: expected YYYY-MM", value)
+		}
+	}
+
+	inv := billing.Invoice{
+		Number:     number,
+		Project:    strings.TrimPrefix(project, takt.ProjectPrefix),
+		Period:     month.Format("2006-01"),
+		Currency:   config.Currency,
+		Rounding:   config.BillingRounding,
+		TaxPercent: config.TaxPercent,
+	}
+	if cmd.Flags().Changed("currency") {
+		inv.Currency, _ = cmd.Flags().GetString("currency")
+	}
+	if cmd.Flags().Changed("tax") {
+		inv.TaxPercent, _ = cmd.Flags().GetFloat64("tax")
+	}
+	if value, _ := cmd.Flags().GetString("rounding"); value != "" {
+		rounding, err := report.ParseRounding(value)
+		if err != nil {
+			return err
+		}
+		inv.Rounding = rounding
+	}
+
+	rates := config.Rates
+	if value, _ := cmd.Flags().GetString("rates"); value != "" {
+		var err error
+		if rates, err = settings.AbsPath(value); err != nil {
+			return err
+		}
+	}
+	card, err := billing.LoadRateCard(rates)
+	if err != nil {
+		return fmt.Errorf("failed to read the rate card: %w", err)
+	}
+
+	records, err := readReportRecords(-1)
+	if err != nil {
+		return err
+	}
+	entries, open := billingEntries(records, month, project)
+	if open > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: left out %d open session(s); check out to bill them\n", open)
+	}
+	if err := inv.AddEntries(entries, card); err != nil {
+		return err
+	}
+
+	if output == "" {
+		return inv.Write(stdout, format)
+	}
+	f, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", output, err)
+	}
+	if err := inv.Write(f, format); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Invoice written to %s (%s)\n", output, billing.FormatAmount(inv.Total(), inv.Currency))
+	return nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/takt"
+)
+
+const invoiceTestCSV = `timestamp,kind,notes
+2026-10-01T09:00:00Z,in,+acme next month
+2026-09-30T12:00:00Z,in,+acme review
+2026-09-16T10:05:00Z,out,
+2026-09-16T09:00:00Z,in,+acme deploy
+2026-09-02T17:00:00Z,out,
+2026-09-02T09:00:00Z,in,+other
+2026-09-01T17:00:00Z,out,
+2026-09-01T09:00:00Z,in,+ACME review
+2026-08-31T17:00:00Z,out,
+2026-08-31T09:00:00Z,in,+acme last month
+`
+
+// pinInvoiceTime pins the clock after the records of invoiceTestCSV, in UTC.
+func pinInvoiceTime(t *testing.T) {
+	originalClock, originalLocal := clock, time.Local
+	clock, time.Local = takt.FixedClock(time.Date(2026, 10, 2, 12, 0, 0, 0, time.UTC)), time.UTC
+	t.Cleanup(func() { clock, time.Local = originalClock, originalLocal })
+}
+
+func TestBillingEntries(t *testing.T) {
+	pinInvoiceTime(t)
+	records, err := readRecordsFromFile(newTestRecordsFile(t, invoiceTestCSV), -1)
+	if err != nil {
+		t.Fatalf("readRecordsFromFile() failed: %v", err)
+	}
+	month := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+
+	entries, open := billingEntries(records, month, "+acme")
+	if open != 1 {
+		t.Errorf("open = %d, want the session of 2026-09-30", open)
+	}
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Start.Format("01-02 15:04")+" "+e.Notes)
+	}
+	want := "09-16 09:00 +acme deploy, 09-01 09:00 +ACME review"
+	if strings.Join(got, ", ") != want {
+		t.Errorf("billingEntries() = %q, want %q", strings.Join(got, ", "), want)
+	}
+
+	if entries, _ := billingEntries(records, month, ""); len(entries) != 3 {
+		t.Errorf("billingEntries() of any project = %d entries, want 3", len(entries))
+	}
+}
+
+func TestRunInvoice(t *testing.T) {
+	pinInvoiceTime(t)
+	newTestRecordsFile(t, invoiceTestCSV)
+	dir := t.TempDir()
+	config.Rates = filepath.Join(dir, "rates.csv")
+	if err := os.WriteFile(config.Rates, []byte("default,,100\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write rates: %v", err)
+	}
+	output := filepath.Join(dir, "invoice.csv")
+
+	flags := map[string]string{"project": "acme", "month": "2026-09", "format": "csv", "output": output, "rounding": "up:15m", "tax": "10"}
+	for name, value := range flags {
+		if err := invoiceCmd.Flags().Set(name, value); err != nil {
+			t.Fatalf("Failed to set --%s: %v", name, err)
+		}
+	}
+	t.Cleanup(func() {
+		for name := range flags {
+			flag := invoiceCmd.Flags().Lookup(name)
+			_ = flag.Value.Set(flag.DefValue)
+			flag.Changed = false
+		}
+	})
+
+	var out strings.Builder
+	if err := runInvoice(invoiceCmd, &out); err != nil {
+		t.Fatalf("runInvoice() failed: %v", err)
+	}
+	// 8h plus 1h05m billed as 1h15m, at 100 plus 10% tax
+	if out.String() != "Invoice written to "+output+" (€1,017.50)\n" {
+		t.Errorf("Unexpected output: %q", out.String())
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("Failed to read the invoice: %v", err)
+	}
+	if !strings.Contains(string(data), "2026-09-16,09:00,10:05,+acme deploy,1.08,1.25,100.00,125.00,EUR\n") {
+		t.Errorf("Unexpected invoice:\n%s", data)
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/lock"
+	"github.com/asdf8601/takt-go/pkg/takt"
+	"github.com/spf13/cobra"
+)
+
+// lockFile returns the locks file of a records file.
+func lockFile(fileName string) string {
+	return fileName + lock.Suffix
+}
+
+// lockGuard returns the store guard refusing changes inside the locked
+// periods of fileName, and any change while the records of a locked period
+// no longer match its hash.
+func lockGuard(fileName string) func(old, new []Record) error {
+	return func(old, new []Record) error {
+		locks, err := lock.Read(lockFile(fileName), time.Local)
+		if err != nil {
+			return err
+		}
+		if err := lock.Check(locks, old, new); err != nil {
+			var lockErr *lock.Error
+			var modifiedErr *lock.ModifiedError
+			switch {
+			case errors.As(err, &lockErr):
+				return fmt.Errorf("%w: unlock it first with 'takt unlock %s'", err, lockErr.Lock.Period)
+			case errors.As(err, &modifiedErr):
+				p := modifiedErr.Lock.Period
+				return fmt.Errorf("%w: restore them, or accept them with 'takt unlock %s' and 'takt lock %s'", err, p, p)
+			}
+			return err
+		}
+		return nil
+	}
+}
+
+// checkLocks returns the error of the guard when the records of fileName
+// would change to records.
+func checkLocks(fileName string, records []Record) error {
+	old, err := readRecordsFromFile(fileName, -1)
+	if err != nil {
+		return err
+	}
+	return lockGuard(fileName)(old, records)
+}
+
+// lockPeriod locks period of the records file: it hashes the records of
+// the period and adds the lock, unless it overlaps one or isn't over yet.
+func lockPeriod(w io.Writer, fileName, period string) error {
+	l, err := lock.ParsePeriod(period, time.Local)
+	if err != nil {
+		return err
+	}
+	now := clock.Now()
+	if now.Before(l.To.AddDate(0, 0, 1)) {
+		return fmt.Errorf("%s isn't over until %s", l.Period, l.To.Format(takt.DateFormat))
+	}
+	locks, err := lock.Read(lockFile(fileName), time.Local)
+	if err != nil {
+		return err
+	}
+	for _, other := range locks {
+		if l.Overlaps(other) {
+			return fmt.Errorf("%s overlaps %s, locked already", l.Period, other.Period)
+		}
+	}
+
+	records, err := readRecordsFromFile(fileName, -1)
+	if err != nil {
+		return err
+	}
+	l.Hash, l.LockedAt = l.Sum(records), now.Truncate(time.Second)
+	if err := lock.Write(lockFile(fileName), append(locks, l)); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Locked %s (%s to %s): %d records, sha256 %s\n",
+		l.Period, l.From.Format(takt.DateFormat), l.To.Format(takt.DateFormat), l.Count(records), l.Hash[:16])
+	return nil
+}
+
+// unlockPeriod removes the lock of period.
+func unlockPeriod(w io.Writer, fileName, period string) error {
+	locks, err := lock.Read(lockFile(fileName), time.Local)
+	if err != nil {
+		return err
+	}
+	for i, l := range locks {
+		if l.Period == period {
+			if err := lock.Write(lockFile(fileName), append(locks[:i], locks[i+1:]...)); err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "Unlocked %s\n", period)
+			return nil
+		}
+	}
+	return fmt.Errorf("%s isn't locked", period)
+}
+
+// printLocks lists the locks of the records file and whether their records
+// are still those locked. It returns an error when any aren't.
+func printLocks(w io.Writer, fileName string) error {
+	locks, err := lock.Read(lockFile(fileName), time.Local)
+	if err != nil {
+		return err
+	}
+	if len(locks) == 0 {
+		fmt.Fprintln(w, "No locked periods")
+		return nil
+	}
+	records, err := readRecordsFromFile(fileName, -1)
+	if err != nil {
+		return err
+	}
+	var modified []string
+	fmt.Fprintf(w, "%-10s  %-10s  %-10s  %-20s  %s\n", "Period", "From", "To", "Locked at", "Records")
+	for _, l := range locks {
+		status := "ok"
+		if !l.Verify(records) {
+			status = "MODIFIED"
+			modified = append(modified, l.Period)
+		}
+		fmt.Fprintf(w, "%-10s  %-10s  %-10s  %-20s  %s\n", l.Period, l.From.Format(takt.DateFormat),
+			l.To.Format(takt.DateFormat), l.LockedAt.Format(TimeFormat), status)
+	}
+	if len(modified) > 0 {
+		return fmt.Errorf("records changed after locking: %s", strings.Join(modified, ", "))
+	}
+	return nil
+}
+
+var lockCmd = &cobra.Command{
+	Use:   "lock [PERIOD]",
+	Short: "Close a period so that its records can't change",
+	Long: `Lock a period that is over, such as a month submitted to payroll: a day
+(2026-09-15), an ISO week (2026-W37), a month (2026-09), a quarter (2026-Q3)
+or a year (2026). Every command that writes the records file (check, edit,
+focus, the daemon, the TUI, the HTTP API and the cleanup of invalid lines)
+then refuses changes inside it until 'takt unlock PERIOD'. A record belongs
+to the period of its own date, as written in the file with its UTC offset,
+whatever the local time zone.
+
+Locks are kept next to the records file, in TAKT_FILE.locks, with the
+SHA-256 hash of the records of the period. Without a period, takt lock lists
+the locks and verifies the hashes, so changes made by hand are detected;
+until they are restored or relocked, every write is refused.
+
+EXAMPLES:
+  takt lock 2026-09
+  takt lock               # list and verify
+  takt unlock 2026-09
+
+OUTPUT:
+  Locked 2026-09 (2026-09-01 to 2026-09-30): 42 records, sha256 3f1c0e5a9b7d2e44
+
+  Period      From        To          Locked at             Records
+  2026-09     2026-09-01  2026-09-30  2026-10-02T09:15:00Z  ok`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if config == nil {
+			fmt.Println("Error: config not initialized")
+			return
+		}
+		var err error
+		if len(args) == 0 {
+			err = printLocks(os.Stdout, config.FileName)
+		} else {
+			err = lockPeriod(os.Stdout, config.FileName, args[0])
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+		}
+	},
+}
+
+var unlockCmd = &cobra.Command{
+	Use:   "unlock PERIOD",
+	Short: "Reopen a locked period",
+	Long: `Remove the lock of a period, as listed by takt lock, so that its records
+can change again.
+
+EXAMPLES:
+  takt unlock 2026-09
+
+OUTPUT:
+  Unlocked 2026-09`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if config == nil {
+			fmt.Println("Error: config not initialized")
+			return
+		}
+		if err := unlockPeriod(os.Stdout, config.FileName, args[0]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+		}
+	},
+}
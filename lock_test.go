@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/takt"
+)
+
+func TestLockPeriod(t *testing.T) {
+	fileName := newTestRecordsFile(t, editTestCSV)
+	originalClock, originalLocal := clock, time.Local
+	clock, time.Local = takt.FixedClock(time.Date(2025, 2, 10, 9, 0, 0, 0, time.UTC)), time.UTC
+	t.Cleanup(func() { clock, time.Local = originalClock, originalLocal })
+
+	var out strings.Builder
+	if err := lockPeriod(&out, fileName, "2025-01"); err != nil {
+		t.Fatalf("lockPeriod() failed: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "Locked 2025-01 (2025-01-01 to 2025-01-31): 2 records, sha256 ") {
+		t.Errorf("Unexpected output: %q", out.String())
+	}
+	for period, expected := range map[string]string{
+		"2025-01-09": "2025-01-09 overlaps 2025-01, locked already",
+		"2025-02":    "2025-02 isn't over until 2025-02-28",
+		"January":    `invalid period "January"`,
+	} {
+		if err := lockPeriod(&out, fileName, period); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("lockPeriod(%s) = %v, expected %q", period, err, expected)
+		}
+	}
+
+	// checks after the period go on
+	if _, err := toggleRecord(fileName, "February"); err != nil {
+		t.Errorf("Expected a check-in after the lock, got %v", err)
+	}
+	records, err := readRecords(-1)
+	if err != nil {
+		t.Fatalf("readRecords() failed: %v", err)
+	}
+	records[len(records)-1].Notes = "rewritten"
+	if err := writeRecordsAtomic(fileName, records); err == nil ||
+		err.Error() != "2025-01 is locked (2025-01-01 to 2025-01-31): unlock it first with 'takt unlock 2025-01'" {
+		t.Errorf("Expected the change to be refused, got %v", err)
+	}
+
+	var seen []string
+	edited := "timestamp,kind,notes\n2025-01-09T17:00:00Z,out,done\n2025-01-09T08:00:00Z,in,work\n"
+	out.Reset()
+	if _, err := editRecords(fileName, EditLayoutCSV, scriptedEditor(t, &seen, edited), strings.NewReader("n\n"), &out); !errors.Is(err, errEditAborted) {
+		t.Errorf("Expected the edit to be aborted, got %v", err)
+	}
+	if !strings.Contains(out.String(), "2025-01 is locked") {
+		t.Errorf("Expected the lock in the output, got %q", out.String())
+	}
+
+	out.Reset()
+	if err := printLocks(&out, fileName); err != nil || !strings.Contains(out.String(), "2025-01     2025-01-01  2025-01-31  2025-02-10T09:00:00Z  ok") {
+		t.Errorf("printLocks() = %v:\n%s", err, out.String())
+	}
+	data, _ := os.ReadFile(fileName)
+	if err := os.WriteFile(fileName, []byte(strings.Replace(string(data), ",work", ",play", 1)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	if err := printLocks(&out, fileName); err == nil || !strings.Contains(out.String(), "MODIFIED") {
+		t.Errorf("Expected the change by hand to be detected, got %v:\n%s", err, out.String())
+	}
+	if _, err := toggleRecord(fileName, ""); err == nil || !strings.Contains(err.Error(), "records of 2025-01 changed after locking") {
+		t.Errorf("Expected writes to be refused after the change by hand, got %v", err)
+	}
+
+	if err := unlockPeriod(&out, fileName, "2025-01"); err != nil {
+		t.Fatalf("unlockPeriod() failed: %v", err)
+	}
+	if err := writeRecordsAtomic(fileName, records); err != nil {
+		t.Errorf("Expected the change to be allowed once unlocked, got %v", err)
+	}
+	if err := unlockPeriod(&out, fileName, "2025-01"); err == nil {
+		t.Error("Expected an error unlocking twice")
+	}
+}
@@ -2,987 +2,36 @@ package main
 
 // Final test of simplified CI/CD workflow with path filters
 import (
-	"bufio"
-	"encoding/csv"
-	"errors"
 	"fmt"
-	"io"
 	"log"
-	"math"
 	"os"
-	"os/exec"
-	"path/filepath"
 	"runtime"
-	"sort"
 	"strconv"
-	"strings"
-	"time"
 
+	"github.com/asdf8601/takt-go/pkg/ansi"
+	"github.com/asdf8601/takt-go/pkg/billing"
+	"github.com/asdf8601/takt-go/pkg/gitsync"
+	"github.com/asdf8601/takt-go/pkg/grid"
+	"github.com/asdf8601/takt-go/pkg/query"
+	"github.com/asdf8601/takt-go/pkg/remind"
+	"github.com/asdf8601/takt-go/pkg/report"
+	"github.com/asdf8601/takt-go/pkg/settings"
+	"github.com/asdf8601/takt-go/pkg/takt"
 	"github.com/spf13/cobra"
 )
 
 const (
 	Version            = "2025-01-09"
 	DefaultHead        = 10
-	DefaultTargetHours = 8.0
-
-	// Grid constants
-	DaysPerYear = 365
-	GridColumns = 9
-	GridRows    = 54
+	DefaultTargetHours = takt.DefaultTargetHours
 
 	// Time format constants
-	TimeFormat = time.RFC3339
-	DateFormat = "2006-01-02"
-
-	// Hour thresholds for grid display
-	LowHours      = 1.0
-	MediumHours   = 4.0
-	HighHours     = 8.0
-	VeryHighHours = 12.0
-
-	// Grid symbols
-	SymbolMinimal   = "󰋣 " // 0-1 hours
-	SymbolLight     = "▪ " // 1-4 hours
-	SymbolNormal    = "▮ " // 4-8 hours
-	SymbolHeavy     = "󰈸 " // 8-12 hours
-	SymbolVeryHeavy = "󰯆 " // 12+ hours
+	TimeFormat = takt.TimeFormat
+	DateFormat = takt.DateFormat
 )
 
-// Config holds application configuration
-type Config struct {
-	Editor      string
-	FileName    string
-	TargetHours float64
-}
-
-// LoadConfig initializes configuration from environment variables
-func LoadConfig() (*Config, error) {
-	fileName, err := getFileName("TAKT_FILE", "~/takt.csv")
-	if err != nil {
-		return nil, fmt.Errorf("failed to get file name: %w", err)
-	}
-
-	targetHours, err := getTargetHours("TAKT_TARGET_HOURS", 8.0)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get target hours: %w", err)
-	}
-
-	return &Config{
-		Editor:      os.Getenv("TAKT_EDITOR"),
-		FileName:    fileName,
-		TargetHours: targetHours,
-	}, nil
-}
-
 // Global configuration
-var config *Config
-
-// CSV Header
-var Header = []string{"timestamp", "kind", "notes"}
-
-type Record struct {
-	Timestamp time.Time
-	Kind      string
-	Notes     string
-}
-
-type AggregatedRecord struct {
-	Group        string
-	TotalHours   float64
-	Dates        []string
-	Notes        []string
-	AverageHours float64
-}
-
-// printGrid prints the grid of the records.
-func printGrid(year string, legend bool) error {
-	records, err := readRecords(1)
-	if err != nil {
-		return fmt.Errorf("failed to read latest record: %w", err)
-	}
-
-	if len(records) == 0 {
-		return errors.New("no records found")
-	}
-
-	lastDay := records[0].Timestamp.Format(DateFormat)
-
-	records, err = readRecords(-1)
-	if err != nil {
-		return fmt.Errorf("failed to read all records: %w", err)
-	}
-
-	agg, err := calculateDuration(records, "day")
-	if err != nil {
-		return fmt.Errorf("error calculating duration: %w", err)
-	}
-
-	daysAgg := make(map[string]AggregatedRecord)
-	for _, a := range agg {
-		daysAgg[a.Group] = a
-	}
-
-	grid := make([][GridColumns]string, GridRows)
-	for i := range grid {
-		for j := range grid[i] {
-			grid[i][j] = "  "
-		}
-	}
-
-	startDate := year + "-01-01"
-	t, err := time.Parse(DateFormat, startDate)
-	if err != nil {
-		return fmt.Errorf("invalid year format: %w", err)
-	}
-
-	value := ""
-	lastIdx := -1
-
-	for i := 0; i < DaysPerYear; i++ {
-		day := t.Format(DateFormat)
-		currentYear, week := t.ISOWeek()
-		if day > lastDay {
-			lastIdx = week
-			break
-		}
-		if t.Year() != currentYear {
-			continue
-		}
-		dayOfWeek := t.Weekday()
-
-		item := daysAgg[day]
-		hours := item.TotalHours
-		if hours < LowHours {
-			value = SymbolMinimal
-		} else if hours < MediumHours {
-			value = SymbolLight
-		} else if hours < HighHours {
-			value = SymbolNormal
-		} else if hours < VeryHighHours {
-			value = SymbolHeavy
-		} else {
-			value = SymbolVeryHeavy
-		}
-
-		grid[week][0] = day
-		grid[week][1] = fmt.Sprintf("%02d", week)
-		grid[week][dayOfWeek+2] = value
-		t = t.Add(time.Hour * 24)
-	}
-
-	if lastIdx > 0 {
-		grid = grid[:lastIdx+1]
-	}
-
-	printGridOutput(grid, lastIdx, legend)
-	return nil
-}
-
-// printGridOutput prints the formatted grid with improved formatting
-func printGridOutput(grid [][GridColumns]string, lastIdx int, legend bool) {
-	pad := "    "
-
-	// ANSI color codes
-	const (
-		colorReset  = "\033[0m"
-		colorRed    = "\033[31m"
-		colorYellow = "\033[33m"
-		colorGreen  = "\033[32m"
-		colorBlue   = "\033[34m"
-		colorOrange = "\033[38;5;208m"
-		colorPurple = "\033[35m"
-		colorCyan   = "\033[36m"
-		colorGray   = "\033[37m"
-		colorBold   = "\033[1m"
-	)
-
-	// Print header with better formatting
-	fmt.Printf("%s%s%s%-10s W  M  T  W  T  F  S  S%s\n", pad, colorBold, colorBlue, "Date", colorReset)
-	fmt.Printf("%s%s══════════════════════════════════%s", pad, colorBlue, colorReset)
-
-	var stats struct {
-		totalDays     int
-		activeDays    int
-		totalHours    float64
-		lightDays     int
-		normalDays    int
-		heavyDays     int
-		veryHeavyDays int
-	}
-
-	var currentMonth string
-
-	for idx, week := range grid {
-		if week[0] == "" {
-			continue
-		}
-
-		// Extract month from date for separators
-		if len(week[0]) >= 7 {
-			weekMonth := week[0][:7] // "2025-01"
-			if currentMonth != "" && currentMonth != weekMonth {
-				fmt.Printf("%s%s──────────────────────────────────%s\n", pad, colorBlue, colorReset)
-			}
-			currentMonth = weekMonth
-		}
-
-		// Color code the symbols and collect stats
-		coloredWeek := make([]string, len(week))
-		copy(coloredWeek, week[:])
-
-		for i := 2; i < len(week); i++ {
-			symbol := week[i]
-			if symbol != "  " {
-				stats.totalDays++
-				if symbol != SymbolMinimal {
-					stats.activeDays++
-				}
-
-				switch symbol {
-				case SymbolMinimal:
-					coloredWeek[i] = colorGray + symbol + colorReset
-					stats.lightDays++
-				case SymbolLight:
-					coloredWeek[i] = colorYellow + symbol + colorReset
-					stats.normalDays++
-				case SymbolNormal:
-					coloredWeek[i] = colorGreen + symbol + colorReset
-					stats.normalDays++
-				case SymbolHeavy:
-					coloredWeek[i] = colorOrange + symbol + colorReset
-					stats.heavyDays++
-				case SymbolVeryHeavy:
-					coloredWeek[i] = colorRed + symbol + colorReset
-					stats.veryHeavyDays++
-				}
-			}
-		}
-		// Print the week with better alignment
-		fmt.Printf("%s%s%s%s %s %s %s %s %s %s %s %s\n",
-			pad, colorBold, coloredWeek[0], colorReset,
-			coloredWeek[1], coloredWeek[3], coloredWeek[4],
-			coloredWeek[5], coloredWeek[6], coloredWeek[7],
-			coloredWeek[8], coloredWeek[2])
-
-		if (lastIdx > 0) && (idx == lastIdx) {
-			break
-		}
-	}
-
-	// Print summary statistics
-	if stats.totalDays > 0 {
-		fmt.Printf("\n%s%s📊 Summary:%s\n", pad, colorBold, colorReset)
-		fmt.Printf("%s%s├─ Total tracked days: %d%s\n", pad, colorBlue, stats.totalDays, colorReset)
-		fmt.Printf("%s%s├─ Active work days: %d%s\n", pad, colorBlue, stats.activeDays, colorReset)
-		if stats.activeDays > 0 {
-			activePercent := float64(stats.activeDays) / float64(stats.totalDays) * 100
-			fmt.Printf("%s%s└─ Activity rate: %.1f%%%s\n", pad, colorBlue, activePercent, colorReset)
-		}
-	}
-
-	if legend {
-		fmt.Printf("\n%s%s🎨 Legend:%s\n", pad, colorBold, colorReset)
-		fmt.Printf("%s%s├─ %s%s%s 0h00m - 1h00m   (Minimal work)%s\n", pad, colorGray, colorGray, SymbolMinimal, colorReset, colorReset)
-		fmt.Printf("%s%s├─ %s%s%s 1h00m - 4h00m   (Light work)%s\n", pad, colorYellow, colorYellow, SymbolLight, colorReset, colorReset)
-		fmt.Printf("%s%s├─ %s%s%s 4h00m - 8h00m   (Normal work)%s\n", pad, colorGreen, colorGreen, SymbolNormal, colorReset, colorReset)
-		fmt.Printf("%s%s├─ %s%s%s 8h00m - 12h00m  (Heavy work)%s\n", pad, colorBlue, colorBlue, SymbolHeavy, colorReset, colorReset)
-		fmt.Printf("%s%s└─ %s%s%s 12h00m or more  (Very heavy work)%s\n", pad, colorPurple, colorPurple, SymbolVeryHeavy, colorReset, colorReset)
-	}
-}
-
-// findGitRoot finds the git root directory starting from the config file directory.
-func findGitRoot() (string, error) {
-	if config == nil {
-		return "", errors.New("config not initialized")
-	}
-
-	dir := filepath.Dir(config.FileName)
-	dir, err := filepath.Abs(dir)
-	if err != nil {
-		return "", fmt.Errorf("couldn't get absolute path: %w", err)
-	}
-
-	for {
-		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
-			return dir, nil
-		}
-		if dir == "/" {
-			return "", errors.New("not in a git repository")
-		}
-		dir = filepath.Join(dir, "..")
-	}
-}
-
-// gitPush pushes the file to the git repository.
-func gitPush() error {
-	gitRoot, _ := findGitRoot()
-	gitCmd := exec.Command("git", "-C", gitRoot, "push")
-	err := execBashCmd(gitCmd)
-	return err
-}
-
-// gitCommit commits the file to the git repository.
-func gitCommit() error {
-	gitRoot, _ := findGitRoot()
-	gitCmd := exec.Command("git", "-C", gitRoot, "commit", "-m", "Automatic commit from Takt")
-	err := execBashCmd(gitCmd)
-	return err
-}
-
-// gitAdd adds the file to the git repository.
-func gitAdd() error {
-	if config == nil {
-		return errors.New("config not initialized")
-	}
-
-	gitRoot, err := findGitRoot()
-	if err != nil {
-		return err
-	}
-
-	dir := filepath.Dir(config.FileName)
-	dir, err = filepath.Abs(dir)
-	if err != nil {
-		return fmt.Errorf("couldn't get absolute path: %w", err)
-	}
-
-	fileDirRel, err := filepath.Rel(gitRoot, dir)
-	if err != nil {
-		return fmt.Errorf("couldn't get relative path: %w", err)
-	}
-
-	fileNameAbs := filepath.Join(fileDirRel, filepath.Base(config.FileName))
-	gitCmd := exec.Command("git", "-C", gitRoot, "add", fileNameAbs)
-	return execBashCmd(gitCmd)
-}
-
-// execBashCmd executes a bash command.
-func execBashCmd(cmd *exec.Cmd) error {
-
-	stderr, _ := cmd.StderrPipe()
-
-	if err := cmd.Start(); err != nil {
-		fmt.Print("error= " + err.Error())
-	}
-
-	slurp, _ := io.ReadAll(stderr)
-	if slurp != nil {
-		fmt.Printf("%s\n", slurp)
-	}
-
-	if err := cmd.Wait(); err != nil {
-		if e, ok := err.(interface{ ExitCode() int }); ok {
-			if e.ExitCode() != 1 {
-				// exit code is neither zero (as we have an error) or one
-				fmt.Print("error= " + err.Error())
-				return err
-			}
-		} else {
-			return err
-		}
-	}
-
-	return nil
-}
-
-// absPath returns the absolute path by expanding the tilde (~) to the user's home directory.
-func absPath(path string) (string, error) {
-	if path[:2] == "~/" {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			fmt.Println("Error: could not get user home directory")
-			return "", err
-		}
-		return filepath.Join(home, path[2:]), nil
-	}
-	return path, nil
-}
-
-// getTargetHours returns the target hours from the environment variable or the default value.
-// Supports both float format (e.g., "7.5") and time format (e.g., "7:30").
-func getTargetHours(key string, dflt float64) (float64, error) {
-	value := os.Getenv(key)
-	if value == "" {
-		return dflt, nil
-	}
-
-	// Check if the value contains a colon (HH:MM format)
-	if strings.Contains(value, ":") {
-		parts := strings.Split(value, ":")
-		if len(parts) != 2 {
-			return dflt, nil
-		}
-
-		hours, err := strconv.Atoi(parts[0])
-		if err != nil || hours < 0 {
-			return dflt, nil
-		}
-
-		minutes, err := strconv.Atoi(parts[1])
-		if err != nil || minutes < 0 || minutes >= 60 {
-			return dflt, nil
-		}
-
-		// Convert to decimal hours
-		return float64(hours) + float64(minutes)/60.0, nil
-	}
-
-	// Try to parse as float
-	hours, err := strconv.ParseFloat(value, 64)
-	if err != nil {
-		return dflt, nil
-	}
-
-	return hours, nil
-}
-
-// getFileName returns the file name from the environment variable or the default value.
-func getFileName(key, dflt string) (string, error) {
-	path := os.Getenv(key)
-
-	if path == "" {
-		return absPath(dflt)
-	}
-
-	return absPath(path)
-}
-
-// sortedKeys returns the keys of a map sorted in descending order.
-func sortedKeys(m map[string]AggregatedRecord) []string {
-	// Crear un slice para las claves
-	keys := make([]string, 0, len(m))
-
-	// Agregar las claves al slice
-	for k := range m {
-		keys = append(keys, k)
-	}
-
-	// Ordenar las claves
-	sort.Strings(keys)
-
-	// invert the order
-	out := make([]string, 0, len(keys))
-	for i := len(keys) - 1; i >= 0; i-- {
-		out = append(out, keys[i])
-	}
-
-	return out
-}
-
-// hoursToText converts hours to a human-readable format.
-func hoursToText(totalHours float64) string {
-	if totalHours <= 0 {
-		return "00h00m"
-	} else if totalHours <= 24 {
-		hours := int(totalHours)
-		minutes := int(math.Round((totalHours - float64(hours)) * 60))
-		return fmt.Sprintf("%dh%02dm", hours, minutes)
-	} else {
-		days := int(totalHours / 24)
-		hours := int(totalHours) % 24
-		minutes := int(math.Round((totalHours - float64(days*24+hours)) * 60))
-		return fmt.Sprintf("%dd%02dh%02dm", days, hours, minutes)
-	}
-}
-
-// formatOvertime formats the overtime/undertime difference with a sign.
-// formatOvertime formats the overtime/undertime difference using TARGET_HOUR as the day unit
-func formatOvertime(difference float64) string {
-	if difference == 0 {
-		return "00h00m"
-	}
-
-	sign := ""
-	if difference > 0 {
-		sign = "+"
-	} else {
-		sign = "-"
-	}
-
-	// Use absolute value for formatting
-	absDiff := math.Abs(difference)
-
-	// Calculate days based on TARGET_HOUR
-	targetHour := config.TargetHours
-	if targetHour == 0 {
-		targetHour = DefaultTargetHours // fallback to default if config is not set
-	}
-
-	if absDiff >= targetHour {
-		days := int(absDiff / targetHour)
-		remainingHours := absDiff - (float64(days) * targetHour)
-		hours := int(remainingHours)
-		minutes := int(math.Round((remainingHours - float64(hours)) * 60))
-
-		// Handle case where minutes round to 60
-		if minutes >= 60 {
-			hours += minutes / 60
-			minutes = minutes % 60
-		}
-
-		if hours == 0 && minutes == 0 {
-			return fmt.Sprintf("%s%dd", sign, days)
-		} else if minutes == 0 {
-			return fmt.Sprintf("%s%dd%dh", sign, days, hours)
-		} else {
-			return fmt.Sprintf("%s%dd%dh%02dm", sign, days, hours, minutes)
-		}
-	} else {
-		hours := int(absDiff)
-		minutes := int(math.Round((absDiff - float64(hours)) * 60))
-
-		// Handle case where minutes round to 60
-		if minutes >= 60 {
-			hours += minutes / 60
-			minutes = minutes % 60
-		}
-
-		return fmt.Sprintf("%s%dh%02dm", sign, hours, minutes)
-	}
-}
-
-// summary prints a summary of the records.
-func summary(offset string, head int) {
-	records, err := readRecords(-1)
-	if err != nil {
-		log.Fatal(err)
-	}
-	agg, err := calculateDuration(records, offset)
-	if err != nil {
-		log.Fatalf("error calculating duration: %v", err)
-	}
-
-	if head < 1 || head > len(agg) {
-		head = len(agg)
-	}
-
-	var outFmt string
-	if offset == "day" {
-		outFmt = "%-12s %6s\t%4s\t%6s\t%8s\n"
-		fmt.Printf(outFmt, "Date", "Total", "Days", "Avg", "Balance")
-	} else {
-		// wider total hours column for week, month, year
-		outFmt = "%-8s %10s\t%4s\t%6s\t%8s\n"
-		fmt.Printf(outFmt, "Date", "Total", "Days", "Avg", "Balance")
-	}
-
-	for i := 0; i < head; i++ {
-		a := agg[i]
-		hhmm := hoursToText(a.TotalHours)
-		ndays := strconv.Itoa(len(a.Dates))
-		avg := hoursToText(a.AverageHours)
-
-		// For all periods, calculate expected hours = target hours * working days
-		expectedHours := config.TargetHours * float64(len(a.Dates))
-		diff := a.TotalHours - expectedHours
-		overtime := formatOvertime(diff)
-		fmt.Printf(outFmt, a.Group, hhmm, ndays, avg, overtime)
-	}
-}
-
-// contains returns true if the item is in the slice.
-func contains(items []string, item string) bool {
-	for _, it := range items {
-		if it == item {
-			return true
-		}
-	}
-	return false
-}
-
-// unique returns a slice with unique items.
-func unique(items []string) []string {
-
-	out := []string{}
-	for _, it := range items {
-		if !contains(out, it) {
-			out = append(out, it)
-		}
-	}
-	return out
-}
-
-// calculateDuration calculates the duration of the records.
-func calculateDuration(records []Record, period string) ([]AggregatedRecord, error) {
-	if len(records) == 0 {
-		return nil, errors.New("no records to process")
-	}
-
-	inferLastOut(&records)
-
-	var aggregations map[string]AggregatedRecord
-	var labeler func(time.Time) string
-
-	switch period {
-	case "day":
-		labeler = func(t time.Time) string {
-			return t.Format("2006-01-02")
-		}
-	case "week":
-		labeler = func(t time.Time) string {
-			year, week := t.ISOWeek()
-			return fmt.Sprintf("%d-W%02d", year, week)
-		}
-	case "month":
-		labeler = func(t time.Time) string {
-			return t.Format("2006-01")
-		}
-	case "year":
-		labeler = func(t time.Time) string {
-			return t.Format("2006")
-		}
-	default:
-		return nil, fmt.Errorf("unsupported period: %s", period)
-	}
-
-	aggregations = aggregateBy(records, labeler)
-	var out []AggregatedRecord
-	keys := sortedKeys(aggregations)
-	for _, k := range keys {
-		v := aggregations[k]
-		v.Dates = unique(v.Dates)
-		v.AverageHours = v.TotalHours / float64(len(v.Dates))
-		out = append(out, v)
-	}
-	return out, nil
-}
-
-// aggregateBy aggregates the records by the groupFunc.
-func aggregateBy(records []Record, groupFunc func(time.Time) string) map[string]AggregatedRecord {
-	aggregations := make(map[string]AggregatedRecord)
-
-	var lastOutTime time.Time
-	for _, record := range records {
-		if record.Kind == "out" {
-			lastOutTime = record.Timestamp
-		} else if record.Kind == "in" && !lastOutTime.IsZero() {
-			groupKey := groupFunc(record.Timestamp)
-			duration := lastOutTime.Sub(record.Timestamp).Hours()
-
-			if agg, exists := aggregations[groupKey]; exists {
-				agg.TotalHours += duration
-				agg.Dates = append(agg.Dates, record.Timestamp.Format(DateFormat))
-				agg.Notes = append(agg.Notes, record.Notes)
-				aggregations[groupKey] = agg
-			} else {
-				aggregations[groupKey] = AggregatedRecord{
-					Group:      groupKey,
-					TotalHours: duration,
-					Dates:      []string{record.Timestamp.Format(DateFormat)},
-					Notes:      []string{record.Notes},
-				}
-			}
-			lastOutTime = time.Time{} // reset
-		}
-	}
-
-	return aggregations
-}
-
-// inferLastOut adds an "out" record at the beginning of the records if the last record is "in".
-func inferLastOut(records *[]Record) int {
-	if len(*records) > 0 && (*records)[0].Kind == "in" {
-		record := []Record{
-			{
-				Timestamp: time.Now(),
-				Kind:      "out",
-				Notes:     "Inferred by takt.",
-			},
-		}
-		*records = append(record, *records...)
-		return 1
-	}
-	return 0
-}
-
-// printRecords prints the records.
-func printRecords(records []Record) {
-	fmt.Printf("%-25s %-5s %s\n", Header[0], Header[1], Header[2])
-	for _, record := range records {
-		fmt.Printf("%-25s %-5s %s\n", record.Timestamp.Format(TimeFormat), record.Kind, record.Notes)
-	}
-}
-
-// createFile creates a new file with the header using the configured filename.
-func createFile() error {
-	if config == nil {
-		return errors.New("config not initialized")
-	}
-
-	file, err := os.Create(config.FileName)
-	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
-	}
-	defer func() {
-		if err := file.Close(); err != nil {
-			fmt.Printf("Error closing file: %v\n", err)
-		}
-	}()
-
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
-	if err := writer.Write(Header); err != nil {
-		return fmt.Errorf("failed to write header: %w", err)
-	}
-	return nil
-}
-
-// readRecords reads nrows records from the configured file
-func readRecords(head int) ([]Record, error) {
-	if config == nil {
-		return nil, errors.New("config not initialized")
-	}
-	return readRecordsFromFile(config.FileName, head)
-}
-
-// readRecordsFromFile reads nrows records from the file fileName and returns them.
-func readRecordsFromFile(fileName string, head int) ([]Record, error) {
-	if _, err := os.Stat(fileName); os.IsNotExist(err) {
-		if err := createFile(); err != nil {
-			return nil, fmt.Errorf("failed to create file: %w", err)
-		}
-	}
-
-	// Create backup before reading
-	if err := backupFile(fileName); err != nil {
-		return nil, fmt.Errorf("could not create backup: %w", err)
-	}
-
-	file, err := os.Open(fileName)
-	if err != nil {
-		return nil, fmt.Errorf("could not open file: %w", err)
-	}
-	defer func() {
-		if err := file.Close(); err != nil {
-			fmt.Printf("Error closing file: %v\n", err)
-		}
-	}()
-
-	reader := csv.NewReader(file)
-	lines, err := reader.ReadAll()
-	if err != nil {
-		// Try to recover from backup
-		if records, err := recoverFromBackup(fileName); err == nil {
-			return records, nil
-		}
-		return nil, fmt.Errorf("could not read CSV: %w", err)
-	}
-
-	var records []Record
-	var validRecords []Record
-	var invalidLines []int
-
-	if head == 0 || len(lines) < 2 {
-		return records, nil
-	}
-
-	// Process all lines except header
-	for i, line := range lines[1:] {
-		if len(line) != 3 {
-			invalidLines = append(invalidLines, i+1)
-			continue
-		}
-
-		timestamp, err := time.Parse(TimeFormat, line[0])
-		if err != nil {
-			invalidLines = append(invalidLines, i+1)
-			continue
-		}
-
-		record := Record{timestamp, line[1], line[2]}
-		if err := validateRecord(record); err != nil {
-			invalidLines = append(invalidLines, i+1)
-			continue
-		}
-
-		validRecords = append(validRecords, record)
-	}
-
-	if len(invalidLines) > 0 {
-		// Log warning about invalid lines
-		log.Printf("Warning: found %d invalid records at lines: %v", len(invalidLines), invalidLines)
-
-		// Write only valid records back to file
-		if err := writeValidRecords(fileName, validRecords); err != nil {
-			log.Printf("Error: could not clean up invalid records: %v", err)
-		}
-	}
-
-	if head > 0 && len(validRecords) > head {
-		return validRecords[:head], nil
-	}
-	return validRecords, nil
-}
-
-// checkAction checks in or out.
-func checkAction(filename, notes string) error {
-	records, err := readRecordsFromFile(filename, 1)
-	if err != nil {
-		return fmt.Errorf("failed to read records: %w", err)
-	}
-
-	var kind string
-	if len(records) == 0 || records[0].Kind == "out" {
-		kind = "in"
-	} else {
-		kind = "out"
-	}
-
-	timestamp := time.Now().Format(TimeFormat)
-	line := fmt.Sprintf("%s,%s,%s", timestamp, kind, notes)
-	if err := writeRecords(filename, line); err != nil {
-		return fmt.Errorf("failed to write records: %w", err)
-	}
-
-	fmt.Printf("Check %s at %s\n", kind, timestamp)
-	return nil
-}
-
-// validateRecord checks if a record is valid and returns an error if not
-func validateRecord(record Record) error {
-	if record.Timestamp.IsZero() {
-		return fmt.Errorf("invalid timestamp")
-	}
-	if record.Kind != "in" && record.Kind != "out" {
-		return fmt.Errorf("invalid kind: %s (must be 'in' or 'out')", record.Kind)
-	}
-	if record.Timestamp.After(time.Now()) {
-		return fmt.Errorf("timestamp in future: %v", record.Timestamp)
-	}
-	return nil
-}
-
-// backupFile creates a backup of the file
-func backupFile(fileName string) error {
-	backupName := fileName + ".bak"
-	source, err := os.Open(fileName)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		if err := source.Close(); err != nil {
-			fmt.Printf("Error closing source file: %v\n", err)
-		}
-	}()
-
-	destination, err := os.Create(backupName)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		if err := destination.Close(); err != nil {
-			fmt.Printf("Error closing destination file: %v\n", err)
-		}
-	}()
-
-	_, err = io.Copy(destination, source)
-	return err
-}
-
-// recoverFromBackup attempts to recover records from the backup file
-func recoverFromBackup(fileName string) ([]Record, error) {
-	backupName := fileName + ".bak"
-	records, err := readRecordsFromFile(backupName, -1)
-	if err != nil {
-		return nil, fmt.Errorf("could not recover from backup: %w", err)
-	}
-	return records, nil
-}
-
-// writeValidRecords writes only valid records back to the file
-func writeValidRecords(fileName string, records []Record) error {
-	file, err := os.Create(fileName)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		if err := file.Close(); err != nil {
-			fmt.Printf("Error closing file: %v\n", err)
-		}
-	}()
-
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
-
-	// Write header
-	if err := writer.Write(Header); err != nil {
-		return err
-	}
-
-	// Write records
-	for _, record := range records {
-		line := []string{
-			record.Timestamp.Format(TimeFormat),
-			record.Kind,
-			record.Notes,
-		}
-		if err := writer.Write(line); err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
-
-// writeRecords writes a new line to the file.
-func writeRecords(fileName, newLine string) error {
-	prevFile, err := os.Open(fileName)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		if err := prevFile.Close(); err != nil {
-			fmt.Printf("Error closing previous file: %v\n", err)
-		}
-	}()
-
-	newFile, err := os.CreateTemp("", "takt_tempfile.csv")
-	if err != nil {
-		fmt.Printf("Error: could not create temp file")
-		return err
-	}
-	defer func() {
-		if err := newFile.Close(); err != nil {
-			fmt.Printf("Error closing new file: %v\n", err)
-		}
-	}()
-
-	newWriter := bufio.NewWriter(newFile)
-	defer func() {
-		if err := newWriter.Flush(); err != nil {
-			fmt.Printf("Error flushing writer: %v\n", err)
-		}
-	}()
-	_, err = fmt.Fprintf(newWriter, "%s,%s,%s\n", Header[0], Header[1], Header[2])
-	if err != nil {
-		fmt.Printf("Error: could not write to temp file")
-		return err
-	}
-	_, err = newWriter.WriteString(newLine + "\n")
-	if err != nil {
-		fmt.Printf("Error: could not write to temp file")
-		return err
-	}
-
-	prevReader := bufio.NewReader(prevFile)
-
-	// drop the header
-	_, _, err = prevReader.ReadLine()
-	if err != nil {
-		return err
-	}
-	_, err = io.Copy(newWriter, prevReader)
-	if err != nil {
-		return err
-	}
-
-	if err := os.Rename(newFile.Name(), fileName); err != nil {
-		return err
-	}
-
-	return nil
-}
+var config *settings.Config
 
 var rootCmd = &cobra.Command{
 	Use:   "takt [COMMAND] [ARGS]",
@@ -994,6 +43,56 @@ CONFIGURATION:
   - TAKT_FILE: Path to CSV file (default: ~/takt.csv)
   - TAKT_TARGET_HOURS: Target hours per day (default: 8.0)
   - TAKT_EDITOR: Editor for 'takt edit' command
+  - TAKT_TOKEN: Token required by 'takt serve' requests
+  - TAKT_GRID_SYMBOLS: Grid symbol set: nerd-font, unicode or ascii
+  - TAKT_GRID_THRESHOLDS: Grid hour thresholds, e.g. 1,4,8,12 (default: scaled to target)
+  - TAKT_HOOK_PRE_CHECK, TAKT_HOOK_POST_CHECK, TAKT_HOOK_POST_EDIT,
+    TAKT_HOOK_POST_SYNC: Hook commands, see HOOKS below
+  - TAKT_WASM_PLUGINS: WASM plugin files or directories, separated like PATH
+  - TAKT_DAY_END: End of the working day, HH:MM (default: 18:00)
+  - TAKT_OPEN_SESSIONS: How long a session without check-out counts: now,
+    cap:DURATION, end-of-day or exclude (default: now)
+  - TAKT_IDLE_SOURCE: Idle source of 'takt daemon' (default: auto)
+  - TAKT_IDLE_THRESHOLD: Idle time that checks out, e.g. 10m (default: 15m)
+  - TAKT_WORKDAYS: Working days, e.g. mon-fri or sun-thu (default: mon-fri)
+  - TAKT_ROUNDING: Round report durations, e.g. nearest:15m or up:6m
+    (default: none), per session or day as TAKT_ROUNDING_PER says
+  - TAKT_MIN_SESSION: Drop or flag shorter sessions, e.g. drop:1m or flag:2m
+  - TAKT_FISCAL_YEAR_START: First month of fiscal years, e.g. 4 or apr
+  - TAKT_SPRINT: Sprints as ANCHOR/LENGTH, e.g. 2025-01-06/2w
+  - TAKT_RATES, TAKT_BILLING_ROUNDING, TAKT_TAX, TAKT_CURRENCY: Rate card,
+    rounding, tax and currency of 'takt invoice'
+  - TAKT_REMINDERS, TAKT_NOTIFY: Reminders of 'takt daemon' and where to
+    send them, see 'takt daemon --help'
+  - NO_COLOR: Disable colors unless --color=always
+
+FILTERS:
+  Reports and exports take --where to keep only the matching sessions, e.g.
+  --where 'project=acme and weekday in (mon,tue) and duration>2h'. Fields:
+  project, tag, note (~ for a regular expression), weekday, date, month,
+  start, end and duration; combine them with and, or, not and parentheses.
+
+LOCKS:
+  'takt lock 2026-09' closes a period that is over: commands that write the
+  records file refuse changes inside it until 'takt unlock 2026-09'. Locks
+  and the hashes of their records are kept in TAKT_FILE.locks.
+
+PLUGINS:
+  'takt NAME [ARGS]' runs the executable takt-NAME from the PATH when NAME
+  isn't a built-in command, with TAKT_FILE and the rest of the configuration
+  in its environment. 'takt plugins' lists them.
+
+  WASM plugins (TAKT_WASM_PLUGINS) run sandboxed, with no filesystem access:
+  they read the records, add columns to day/week/month/year summaries and
+  change the notes of new records before 'takt check' writes them.
+
+HOOKS:
+  Hook commands run with sh (cmd on Windows) and get the event as JSON on
+  stdin: the new record for check and sync hooks, all records for post-edit.
+  Pre-check runs before the write and the post hooks after it. A non-zero
+  exit vetoes the action: pre-check stops the check, post-check removes the
+  new record again and post-edit puts the old records back. Post-sync runs
+  once the records are pushed, so its failure is only reported.
 
 EXAMPLES:
   # Check in/out (toggles automatically)
@@ -1030,6 +129,17 @@ OUTPUT FORMAT:
   - Days: Number of working days in period
   - Avg: Average hours per working day
   - Balance: Overtime/undertime vs target (±days/hours)`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// the arguments are valid by now, so errors from here on aren't
+		// about usage
+		cmd.SilenceUsage = true
+		now, _ := cmd.Flags().GetString("now")
+		if err := setClock(now); err != nil {
+			return err
+		}
+		value, _ := cmd.Flags().GetString("where")
+		return setWhere(value)
+	},
 }
 
 var checkCmd = &cobra.Command{
@@ -1040,6 +150,12 @@ var checkCmd = &cobra.Command{
 If you're currently checked out, it will check you in.
 If you're currently checked in, it will check you out.
 
+When the open session looks forgotten (still open on a later day than it
+started and past the end of its working day, see TAKT_DAY_END, or past the cap
+of TAKT_OPEN_SESSIONS=cap:DURATION), takt first asks when to check out of it:
+at that end (Enter), at another HH:MM, or now. After such a check-out it asks
+whether to check in again; it does not unless you answer y.
+
 EXAMPLES:
   takt check                    # Simple check in/out
   takt check "Meeting prep"     # Check in/out with note
@@ -1047,7 +163,12 @@ EXAMPLES:
 
 OUTPUT:
   Check in at 2025-01-09T14:30:00Z
-  Check out at 2025-01-09T17:45:00Z`,
+  Check out at 2025-01-09T17:45:00Z
+
+  Open session since Fri 2025-01-03 09:00 (3d00h02m). Check out at 18:00, at HH:MM or [n]ow? [18:00/HH:MM/n]
+  Check out at 2025-01-03T18:00:00+01:00
+  Check in now? [y/N] y
+  Check in at 2025-01-06T09:02:11+01:00`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if config == nil {
 			fmt.Println("Error: config not initialized")
@@ -1059,6 +180,14 @@ OUTPUT:
 			notes = args[0]
 		}
 
+		toggle, err := resolveStaleSession(config.FileName, os.Stdin, os.Stdout, true)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		if !toggle {
+			return
+		}
 		if err := checkAction(config.FileName, notes); err != nil {
 			fmt.Printf("Error: %v\n", err)
 		}
@@ -1090,7 +219,7 @@ OUTPUT FORMAT:
 				log.Fatal(err)
 			}
 		}
-		records, err := readRecords(head)
+		records, err := readReportRecords(head)
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -1120,17 +249,7 @@ BALANCE EXPLANATION:
   - +1d = 1 full working day of overtime (based on TARGET_HOURS)
   - +0h30m = 30 minutes overtime
   - -2h00m = 2 hours undertime`,
-	Run: func(cmd *cobra.Command, args []string) {
-		head := DefaultHead
-		var err error
-		if len(args) > 0 {
-			head, err = strconv.Atoi(args[0])
-			if err != nil {
-				log.Fatal(err)
-			}
-		}
-		summary("day", head)
-	},
+	Run: runSummary(report.PeriodDay),
 }
 
 var weekCmd = &cobra.Command{
@@ -1150,17 +269,7 @@ OUTPUT FORMAT:
   Date      Total     Days  Avg     Balance
   2025-W02  40h15m    5     8h03m   +0h15m
   2025-W01  37h30m    5     7h30m   -2h30m`,
-	Run: func(cmd *cobra.Command, args []string) {
-		head := DefaultHead
-		var err error
-		if len(args) > 0 {
-			head, err = strconv.Atoi(args[0])
-			if err != nil {
-				log.Fatal(err)
-			}
-		}
-		summary("week", head)
-	},
+	Run: runSummary(report.PeriodWeek),
 }
 
 var monthCmd = &cobra.Command{
@@ -1181,17 +290,7 @@ OUTPUT FORMAT:
   Date     Total     Days  Avg     Balance
   2025-01  168h30m   21    8h02m   +0h30m
   2024-12  159h45m   20    7h59m   -0h15m`,
-	Run: func(cmd *cobra.Command, args []string) {
-		head := DefaultHead
-		var err error
-		if len(args) > 0 {
-			head, err = strconv.Atoi(args[0])
-			if err != nil {
-				log.Fatal(err)
-			}
-		}
-		summary("month", head)
-	},
+	Run: runSummary(report.PeriodMonth),
 }
 
 var yearCmd = &cobra.Command{
@@ -1210,143 +309,47 @@ OUTPUT FORMAT:
   Date  Total      Days  Avg     Balance
   2025  2080h30m   260   8h00m   +0h30m
   2024  2076h15m   259   8h01m   +4h15m`,
-	Run: func(cmd *cobra.Command, args []string) {
-		head := DefaultHead
-		var err error
-		if len(args) > 0 {
-			head, err = strconv.Atoi(args[0])
-			if err != nil {
-				log.Fatal(err)
-			}
-		}
-		summary("year", head)
-	},
+	Run: runSummary(report.PeriodYear),
 }
 
-var gridCmd = &cobra.Command{
-	Short: "Visual grid showing daily activity with colors",
-	Use:   "grid [YEAR] [LEGEND]",
-	Long: `Display a visual grid showing daily activity for the specified year.
-Each day is represented by a colored symbol indicating hours worked.
-Features month separators, color coding, and activity statistics.
-Default shows current year without legend.
-
-EXAMPLES:
-  takt grid                     # Show current year
-  takt grid 2024                # Show 2024
-  takt grid 2025 true           # Show 2025 with legend
-
-GRID SYMBOLS:
-  󰋣  = 0-1 hours (minimal work) - Gray
-  ▪  = 1-4 hours (light work) - Yellow
-  ▮  = 4-8 hours (normal work) - Green
-  󰈸 = 8-12 hours (heavy work) - Blue
-  █  = 12+ hours (very heavy work) - Purple
-
-FEATURES:
-  • Color-coded activity levels
-  • Month separators for better readability
-  • Activity statistics (total days, active days, activity rate)
-  • Improved legend with descriptions
-  • Better visual alignment and formatting
-
-OUTPUT FORMAT:
-      Date         W  L  M  X  J  V  S  D
-      ═══════════════════════════════════
-      2025-01-01 01 󰋣       󰈸
-      2025-01-06 02     󰈸 󰈸   󰋣
-      ───────────────────────────────
-      📊 Summary:
-      ├─ Total tracked days: 192
-      ├─ Active work days: 116
-      └─ Activity rate: 60.4%`,
-	Run: func(cmd *cobra.Command, args []string) {
-		lenArgs := len(args)
-		legend := false
-		year := ""
-
-		if lenArgs < 1 {
-			year = time.Now().Format("2006")
-		} else {
-			year = args[0]
-		}
-
-		if lenArgs > 1 {
-			legend = args[1] == "true"
-		}
-
-		if err := printGrid(year, legend); err != nil {
-			log.Fatalf("Failed to print grid: %v", err)
-		}
-	},
-}
-
-var editCmd = &cobra.Command{
-	Use:     "edit",
-	Aliases: []string{"e"},
-	Short:   "Edit the records file",
-	Long: `Open the time tracking CSV file in your configured editor.
-Set TAKT_EDITOR environment variable to specify your preferred editor.
-
-EXAMPLES:
-  takt edit                     # Open in configured editor
-  takt e                        # Using alias
-
-SETUP:
-  export TAKT_EDITOR=vim        # Use vim
-  export TAKT_EDITOR=code       # Use VS Code
-  export TAKT_EDITOR=nano       # Use nano
+var commitCmd = &cobra.Command{
+	Use:     "commit",
+	Aliases: []string{"cm"},
+	Short:   "Commit the records file",
+	Long: `Add, commit and push the records file in its git repository.
 
-FILE FORMAT:
-  timestamp,kind,notes
-  2025-01-09T14:30:00Z,in,Meeting prep
-  2025-01-09T17:45:00Z,out,End of day`,
+The post-sync hook (TAKT_HOOK_POST_SYNC) runs after the push with the
+latest record on stdin; a non-zero exit is reported as an error.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if config == nil {
 			fmt.Println("Error: config not initialized")
 			return
 		}
 
-		if config.Editor == "" {
-			fmt.Println("Error: TAKT_EDITOR environment variable not set")
-			return
-		}
-
-		editCmd := exec.Command(config.Editor, config.FileName)
-		editCmd.Stdin = os.Stdin
-		editCmd.Stdout = os.Stdout
-		err := editCmd.Run()
-		if err != nil {
-			log.Fatal(err)
-		}
-	},
-}
-
-var commitCmd = &cobra.Command{
-	Use:     "commit",
-	Aliases: []string{"cm"},
-	Short:   "Commit the records file",
-	Run: func(cmd *cobra.Command, args []string) {
-		err := gitAdd()
+		err := gitsync.Add(config.FileName)
 		if err != nil {
 			fmt.Println("Error: git add failed")
 			return
 		}
 		fmt.Println("Records added")
 
-		err = gitCommit()
+		err = gitsync.Commit(config.FileName)
 		if err != nil {
 			fmt.Println("Error: git commit failed")
 			return
 		}
 		fmt.Println("Records committed")
 
-		err = gitPush()
+		err = gitsync.Push(config.FileName)
 		if err != nil {
 			fmt.Println("Error: git push failed")
 			return
 		}
 		fmt.Println("Records pushed")
+
+		if err := syncHook(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+		}
 	},
 }
 
@@ -1362,28 +365,106 @@ var versionCmd = &cobra.Command{
 }
 
 func init() {
+	gridCmd.Flags().String("svg", "", "write the grid as SVG to this file")
+	gridCmd.Flags().String("png", "", "write the grid as PNG to this file")
+	gridCmd.Flags().String("layout", grid.LayoutHorizontal, "image layout: horizontal or vertical")
+	gridCmd.Flags().Bool("balance", false, "color days by balance against the target hours")
+	gridCmd.Flags().String("from", "", "first day of the grid (YYYY-MM-DD)")
+	gridCmd.Flags().String("to", "", "last day of the grid (YYYY-MM-DD, default today)")
+	gridCmd.Flags().Int("years", 1, "number of calendar years to stack, ending at YEAR")
+	gridCmd.Flags().String("month", "", "show a calendar of the month (YYYY-MM) with each day's hours")
+	gridCmd.Flags().String("symbols", "", "symbol set: nerd-font, unicode or ascii (default $TAKT_GRID_SYMBOLS or nerd-font)")
+	editCmd.Flags().Bool("sessions", false, "edit one session per line instead of the CSV records")
+	rootCmd.PersistentFlags().String("color", ansi.ColorAuto, "colorize output: auto, always or never")
+	rootCmd.PersistentFlags().String("now", "", "pretend the current time is this (RFC3339, YYYY-MM-DD HH:MM or YYYY-MM-DD)")
+	_ = rootCmd.PersistentFlags().MarkHidden("now")
+
 	rootCmd.AddCommand(checkCmd)
 	rootCmd.AddCommand(catCmd)
 	rootCmd.AddCommand(dayCmd)
 	rootCmd.AddCommand(weekCmd)
 	rootCmd.AddCommand(monthCmd)
 	rootCmd.AddCommand(yearCmd)
+	rootCmd.AddCommand(quarterCmd)
+	rootCmd.AddCommand(fiscalYearCmd)
+	rootCmd.AddCommand(sprintCmd)
+	rootCmd.AddCommand(summaryCmd)
+	for _, cmd := range []*cobra.Command{dayCmd, weekCmd, monthCmd, yearCmd, quarterCmd, fiscalYearCmd, sprintCmd, summaryCmd} {
+		cmd.Flags().Bool("notes", false, "print the notes of each row under it")
+	}
+	summaryCmd.Flags().String("period", report.PeriodDay, "period expression: day, week, month, quarter, year, fiscal-year[:MONTH] or sprint[:ANCHOR/LENGTH]")
 	rootCmd.AddCommand(editCmd)
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(commitCmd)
 	rootCmd.AddCommand(gridCmd)
+	rootCmd.AddCommand(tuiCmd)
+	rootCmd.AddCommand(pluginsCmd)
+	rootCmd.AddCommand(daemonCmd)
+	rootCmd.AddCommand(focusCmd)
+	focusCmd.Flags().Duration("break", 0, "break to count down after the focus session")
+	daemonCmd.Flags().String("source", "", "idle source: auto, x11, wayland, file:PATH, command:CMD or none (default $TAKT_IDLE_SOURCE or auto)")
+	daemonCmd.Flags().Duration("threshold", 0, "idle time that checks out (default $TAKT_IDLE_THRESHOLD or 15m)")
+	daemonCmd.Flags().Duration("interval", DefaultIdleInterval, "time between idle checks")
+	daemonCmd.Flags().String("on-return", OnReturnAsk, "on return from idleness: ask, keep, discard or nothing")
+	daemonCmd.Flags().String("reminders", "", "comma-separated reminders, or none (default $TAKT_REMINDERS or "+remind.Default+")")
+	rootCmd.AddCommand(forecastCmd)
+	rootCmd.AddCommand(searchCmd)
+	rootCmd.AddCommand(notesCmd)
+	notesCmd.Flags().Bool("day", false, "break today down")
+	notesCmd.Flags().Bool("week", false, "break this week down (default)")
+	notesCmd.Flags().Bool("month", false, "break this month down")
+	notesCmd.MarkFlagsMutuallyExclusive("day", "week", "month")
+	rootCmd.AddCommand(statsCmd)
+	statsCmd.Flags().String("from", "", "first day to analyze (YYYY-MM-DD, default the first day with sessions)")
+	statsCmd.Flags().String("to", "", "last day to analyze (YYYY-MM-DD, default today)")
+	statsCmd.Flags().Bool("json", false, "print the stats as JSON")
+	rootCmd.AddCommand(invoiceCmd)
+	invoiceCmd.Flags().String("project", "", "bill the sessions of this project (+PROJECT in the notes)")
+	invoiceCmd.Flags().String("month", "", "month to bill, YYYY-MM (default the current month)")
+	invoiceCmd.Flags().String("format", billing.FormatMarkdown, "invoice format: markdown, html or csv")
+	invoiceCmd.Flags().String("output", "", "write the invoice to this file instead of stdout")
+	invoiceCmd.Flags().String("rates", "", "rate card CSV (default $TAKT_RATES or ~/.takt_rates.csv)")
+	invoiceCmd.Flags().String("rounding", "", "per-session rounding, e.g. up:15m or nearest:6m (default $TAKT_BILLING_ROUNDING or none)")
+	invoiceCmd.Flags().Float64("tax", 0, "tax percentage (default $TAKT_TAX or 0)")
+	invoiceCmd.Flags().String("currency", "", "currency code, e.g. EUR or USD (default $TAKT_CURRENCY or EUR)")
+	invoiceCmd.Flags().String("number", "", "invoice number")
+	daemonCmd.Flags().StringArray("notify", nil, "notification sink: stdout, log:PATH, command:TEMPLATE or webhook:URL (default $TAKT_NOTIFY or stdout)")
+	rootCmd.AddCommand(sqlCmd)
+	sqlCmd.Flags().String("format", query.FormatTable, "output format: table, csv or json")
+	rootCmd.AddCommand(teamCmd)
+	rootCmd.AddCommand(lockCmd)
+	rootCmd.AddCommand(unlockCmd)
+	teamCmd.Flags().String("dir", "", "directory with one records file (*.csv) per person")
+	teamCmd.Flags().String("period", report.PeriodWeek, "break the report down by week or month")
+	teamCmd.Flags().String("from", "", "first day of the report (YYYY-MM-DD, default the start of the period of --to)")
+	teamCmd.Flags().String("to", "", "last day of the report (YYYY-MM-DD, default today)")
+	teamCmd.Flags().Bool("json", false, "print the report as JSON")
+	for _, cmd := range []*cobra.Command{catCmd, dayCmd, weekCmd, monthCmd, yearCmd, quarterCmd, fiscalYearCmd, sprintCmd, summaryCmd,
+		gridCmd, forecastCmd, searchCmd, notesCmd, statsCmd, invoiceCmd, teamCmd} {
+		cmd.Flags().String("where", "", `report only the sessions matching this filter, e.g. 'project=acme and duration>2h'`)
+	}
 }
 
 func Execute() {
+	if name, args, ok := pluginArgs(os.Args[1:]); ok {
+		if path, err := findPlugin(name); err == nil {
+			code, err := runPlugin(path, args, os.Stdin, os.Stdout, os.Stderr)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+			}
+			os.Exit(code)
+		}
+	}
+
+	// cobra prints the error
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
 		os.Exit(1)
 	}
 }
 
 func main() {
 	var err error
-	config, err = LoadConfig()
+	config, err = settings.Load()
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
@@ -7,12 +7,15 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/asdf8601/takt-go/pkg/settings"
+	"github.com/asdf8601/takt-go/pkg/takt"
 )
 
 func TestCalculateDuration(t *testing.T) {
 	records := []Record{
-		{time.Now().Add(-4 * time.Hour), "in", ""},
-		{time.Now().Add(-2 * time.Hour), "out", ""},
+		{Timestamp: time.Now().Add(-4 * time.Hour), Kind: "in", Notes: ""},
+		{Timestamp: time.Now().Add(-2 * time.Hour), Kind: "out", Notes: ""},
 	}
 
 	tests := []struct {
@@ -55,8 +58,8 @@ func TestAggregateBy(t *testing.T) {
 	// This matches how the application typically stores records
 	now := time.Now()
 	records := []Record{
-		{now.Add(-2 * time.Hour), "out", ""}, // 2 hours ago - finished working (newest)
-		{now.Add(-4 * time.Hour), "in", ""},  // 4 hours ago - started working (worked for 2 hours)
+		{Timestamp: now.Add(-2 * time.Hour), Kind: "out", Notes: ""}, // 2 hours ago - finished working (newest)
+		{Timestamp: now.Add(-4 * time.Hour), Kind: "in", Notes: ""},  // 4 hours ago - started working (worked for 2 hours)
 	}
 
 	groupFunc := func(t time.Time) string {
@@ -78,7 +81,7 @@ func TestAggregateBy(t *testing.T) {
 
 func TestInferLastOut(t *testing.T) {
 	records := []Record{
-		{time.Now().Add(-2 * time.Hour), "in", ""},
+		{Timestamp: time.Now().Add(-2 * time.Hour), Kind: "in", Notes: ""},
 	}
 
 	inferLastOut(&records)
@@ -136,22 +139,22 @@ func TestValidateRecord(t *testing.T) {
 	}{
 		{
 			"valid_record",
-			Record{time.Now().Add(-1 * time.Hour), "in", "test"},
+			Record{Timestamp: time.Now().Add(-1 * time.Hour), Kind: "in", Notes: "test"},
 			false,
 		},
 		{
 			"zero_timestamp",
-			Record{time.Time{}, "in", "test"},
+			Record{Timestamp: time.Time{}, Kind: "in", Notes: "test"},
 			true,
 		},
 		{
 			"invalid_kind",
-			Record{time.Now().Add(-1 * time.Hour), "invalid", "test"},
+			Record{Timestamp: time.Now().Add(-1 * time.Hour), Kind: "invalid", Notes: "test"},
 			true,
 		},
 		{
 			"future_timestamp",
-			Record{time.Now().Add(1 * time.Hour), "in", "test"},
+			Record{Timestamp: time.Now().Add(1 * time.Hour), Kind: "in", Notes: "test"},
 			true,
 		},
 	}
@@ -230,8 +233,8 @@ func TestWriteValidRecords(t *testing.T) {
 
 	// Create test records
 	records := []Record{
-		{time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC), "in", "test1"},
-		{time.Date(2023, 1, 1, 18, 0, 0, 0, time.UTC), "out", "test2"},
+		{Timestamp: time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC), Kind: "in", Notes: "test1"},
+		{Timestamp: time.Date(2023, 1, 1, 18, 0, 0, 0, time.UTC), Kind: "out", Notes: "test2"},
 	}
 
 	// Write records
@@ -301,66 +304,11 @@ func TestCheckAction(t *testing.T) {
 	}
 }
 
-func TestGetTargetHours(t *testing.T) {
-	tests := []struct {
-		name     string
-		envValue string
-		dflt     float64
-		expected float64
-	}{
-		{"default", "", 8.0, 8.0},
-		{"float_format", "7.5", 8.0, 7.5},
-		{"time_format", "7:30", 8.0, 7.5},
-		{"invalid_format", "invalid", 8.0, 8.0},
-		{"invalid_time", "7:99", 8.0, 8.0},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			// Set environment variable
-			key := "TEST_TARGET_HOURS"
-			if tt.envValue != "" {
-				if err := os.Setenv(key, tt.envValue); err != nil {
-					t.Fatalf("Failed to set environment variable: %v", err)
-				}
-			} else {
-				if err := os.Unsetenv(key); err != nil {
-					t.Fatalf("Failed to unset environment variable: %v", err)
-				}
-			}
-			defer func() {
-				if err := os.Unsetenv(key); err != nil {
-					t.Logf("Failed to unset environment variable: %v", err)
-				}
-			}()
-
-			result, err := getTargetHours(key, tt.dflt)
-			if err != nil {
-				t.Errorf("getTargetHours() returned error: %v", err)
-			}
-			if result != tt.expected {
-				t.Errorf("getTargetHours() = %v, want %v", result, tt.expected)
-			}
-		})
-	}
-}
-
-func TestConfig(t *testing.T) {
-	// Test config loading
-	cfg, err := LoadConfig()
-	if err != nil {
-		t.Errorf("LoadConfig() failed: %v", err)
-	}
-	if cfg == nil {
-		t.Error("LoadConfig() returned nil config")
-	}
-}
-
 func TestFormatOvertime(t *testing.T) {
 	// Initialize config for testing
-	cfg, err := LoadConfig()
+	cfg, err := settings.Load()
 	if err != nil {
-		t.Fatalf("LoadConfig() failed: %v", err)
+		t.Fatalf("settings.Load() failed: %v", err)
 	}
 	originalConfig := config
 	config = cfg
@@ -405,9 +353,9 @@ func TestFormatOvertime(t *testing.T) {
 
 func TestFormatOvertimeWithDifferentTargetHours(t *testing.T) {
 	// Initialize config for testing
-	cfg, err := LoadConfig()
+	cfg, err := settings.Load()
 	if err != nil {
-		t.Fatalf("LoadConfig() failed: %v", err)
+		t.Fatalf("settings.Load() failed: %v", err)
 	}
 	originalConfig := config
 	config = cfg
@@ -452,9 +400,9 @@ func TestFormatOvertimeWithDifferentTargetHours(t *testing.T) {
 
 func TestBalanceCalculationAggregatedRecords(t *testing.T) {
 	// Initialize config for testing
-	cfg, err := LoadConfig()
+	cfg, err := settings.Load()
 	if err != nil {
-		t.Fatalf("LoadConfig() failed: %v", err)
+		t.Fatalf("settings.Load() failed: %v", err)
 	}
 	originalConfig := config
 	config = cfg
@@ -463,10 +411,10 @@ func TestBalanceCalculationAggregatedRecords(t *testing.T) {
 	// Test balance calculation in aggregated records
 	now := time.Now()
 	records := []Record{
-		{now.Add(-18 * time.Hour), "out", ""}, // 1 day ago, 2pm (newest)
-		{now.Add(-24 * time.Hour), "in", ""},  // 1 day ago, 8am (6 hours)
-		{now.Add(-39 * time.Hour), "out", ""}, // 2 days ago, 5pm
-		{now.Add(-48 * time.Hour), "in", ""},  // 2 days ago, 8am (9 hours)
+		{Timestamp: now.Add(-18 * time.Hour), Kind: "out", Notes: ""}, // 1 day ago, 2pm (newest)
+		{Timestamp: now.Add(-24 * time.Hour), Kind: "in", Notes: ""},  // 1 day ago, 8am (6 hours)
+		{Timestamp: now.Add(-39 * time.Hour), Kind: "out", Notes: ""}, // 2 days ago, 5pm
+		{Timestamp: now.Add(-48 * time.Hour), Kind: "in", Notes: ""},  // 2 days ago, 8am (9 hours)
 	}
 
 	// Set target hours to 8 for testing
@@ -526,9 +474,9 @@ func TestBalanceCalculationAggregatedRecords(t *testing.T) {
 
 func TestBalanceCalculationWithDifferentTargetHours(t *testing.T) {
 	// Initialize config for testing
-	cfg, err := LoadConfig()
+	cfg, err := settings.Load()
 	if err != nil {
-		t.Fatalf("LoadConfig() failed: %v", err)
+		t.Fatalf("settings.Load() failed: %v", err)
 	}
 	originalConfig := config
 	config = cfg
@@ -537,8 +485,8 @@ func TestBalanceCalculationWithDifferentTargetHours(t *testing.T) {
 	// Test with 7.5 hour target
 	now := time.Now()
 	records := []Record{
-		{now.Add(-16 * time.Hour), "out", ""}, // 1 day ago, end (newest)
-		{now.Add(-24 * time.Hour), "in", ""},  // 1 day ago, start (8 hours worked)
+		{Timestamp: now.Add(-16 * time.Hour), Kind: "out", Notes: ""}, // 1 day ago, end (newest)
+		{Timestamp: now.Add(-24 * time.Hour), Kind: "in", Notes: ""},  // 1 day ago, start (8 hours worked)
 	}
 
 	// Set target hours to 7.5 for testing
@@ -569,9 +517,9 @@ func TestBalanceCalculationWithDifferentTargetHours(t *testing.T) {
 
 func TestBalanceCalculationMultipleDays(t *testing.T) {
 	// Initialize config for testing
-	cfg, err := LoadConfig()
+	cfg, err := settings.Load()
 	if err != nil {
-		t.Fatalf("LoadConfig() failed: %v", err)
+		t.Fatalf("settings.Load() failed: %v", err)
 	}
 	originalConfig := config
 	config = cfg
@@ -581,14 +529,14 @@ func TestBalanceCalculationMultipleDays(t *testing.T) {
 	now := time.Now()
 	records := []Record{
 		// Day 3: 8 hours (most recent)
-		{now.Add(-16 * time.Hour), "out", ""}, // 1 day ago
-		{now.Add(-24 * time.Hour), "in", ""},  // 1 day ago
+		{Timestamp: now.Add(-16 * time.Hour), Kind: "out", Notes: ""}, // 1 day ago
+		{Timestamp: now.Add(-24 * time.Hour), Kind: "in", Notes: ""},  // 1 day ago
 		// Day 2: 6 hours
-		{now.Add(-42 * time.Hour), "out", ""}, // 2 days ago
-		{now.Add(-48 * time.Hour), "in", ""},  // 2 days ago
+		{Timestamp: now.Add(-42 * time.Hour), Kind: "out", Notes: ""}, // 2 days ago
+		{Timestamp: now.Add(-48 * time.Hour), Kind: "in", Notes: ""},  // 2 days ago
 		// Day 1: 10 hours (oldest)
-		{now.Add(-62 * time.Hour), "out", ""}, // 3 days ago
-		{now.Add(-72 * time.Hour), "in", ""},  // 3 days ago
+		{Timestamp: now.Add(-62 * time.Hour), Kind: "out", Notes: ""}, // 3 days ago
+		{Timestamp: now.Add(-72 * time.Hour), Kind: "in", Notes: ""},  // 3 days ago
 	}
 
 	// Set target hours to 8 for testing
@@ -658,9 +606,9 @@ func TestCLIIntegrationWithBalance(t *testing.T) {
 	}
 
 	// Initialize config
-	cfg, err := LoadConfig()
+	cfg, err := settings.Load()
 	if err != nil {
-		t.Fatalf("LoadConfig() failed: %v", err)
+		t.Fatalf("settings.Load() failed: %v", err)
 	}
 	originalConfig := config
 	config = cfg
@@ -738,3 +686,63 @@ func TestCLIIntegrationWithBalance(t *testing.T) {
 		t.Errorf("Weekly expected balance = %v, want -1.0", expectedWeeklyBalance)
 	}
 }
+
+func TestResolveStaleSession(t *testing.T) {
+	const openCSV = `timestamp,kind,notes
+2025-01-03T09:00:00Z,in,friday
+`
+	tests := []struct {
+		name       string
+		now        string
+		answers    string
+		want       string
+		wantToggle bool
+	}{
+		{"end of day", "2025-01-06T09:00:00Z", "\n\n", "2025-01-03T18:00:00Z", false},
+		{"given time", "2025-01-06T09:00:00Z", "25:00\n16:30\n", "2025-01-03T16:30:00Z", false},
+		{"check in again", "2025-01-06T09:00:00Z", "\ny\n", "2025-01-03T18:00:00Z", true},
+		{"now", "2025-01-06T09:00:00Z", "n\n", "", true},
+		{"no answer", "2025-01-06T09:00:00Z", "", "", true},
+		{"not stale", "2025-01-03T12:00:00Z", "", "", true},
+		{"same day", "2025-01-03T18:30:00Z", "\n", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fileName := newTestRecordsFile(t, openCSV)
+			now, _ := time.Parse(time.RFC3339, tt.now)
+			originalClock, originalLocal := clock, time.Local
+			clock, time.Local = takt.FixedClock(now), time.UTC
+			t.Cleanup(func() { clock, time.Local = originalClock, originalLocal })
+
+			var out strings.Builder
+			toggle, err := resolveStaleSession(fileName, strings.NewReader(tt.answers), &out, true)
+			if err != nil {
+				t.Fatalf("resolveStaleSession() failed: %v", err)
+			}
+			if toggle != tt.wantToggle {
+				t.Errorf("resolveStaleSession() toggle = %v, want %v", toggle, tt.wantToggle)
+			}
+			records, err := readRecordsFromFile(fileName, -1)
+			if err != nil {
+				t.Fatalf("readRecordsFromFile() failed: %v", err)
+			}
+
+			if tt.want == "" {
+				if len(records) != 1 {
+					t.Errorf("Expected the session to stay open, got %v", records)
+				}
+				if tt.name == "same day" && out.Len() > 0 {
+					t.Errorf("Expected no question, got:\n%s", out.String())
+				}
+				return
+			}
+			if len(records) != 2 || records[0].Kind != "out" || records[0].Timestamp.Format(TimeFormat) != tt.want || records[0].Notes != ForgottenNotes {
+				t.Errorf("Expected a check-out at %s, got %v", tt.want, records)
+			}
+			if !strings.Contains(out.String(), "Open session since Fri 2025-01-03 09:00 (3d00h00m)") {
+				t.Errorf("Unexpected question:\n%s", out.String())
+			}
+		})
+	}
+}
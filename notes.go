@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/report"
+	"github.com/spf13/cobra"
+)
+
+// NoNotes labels the time of sessions without notes.
+const NoNotes = "(no notes)"
+
+// searchSessions returns the sessions (newest first) whose notes or
+// check-out notes match re.
+func searchSessions(records []Record, re *regexp.Regexp) []Session {
+	var found []Session
+	for _, s := range pairSessions(records) {
+		if !s.Start.IsZero() && (re.MatchString(s.Notes) || re.MatchString(s.OutNotes)) {
+			found = append(found, s)
+		}
+	}
+	return found
+}
+
+// printSessions writes sessions with their durations, open ones counted
+// until now, and their total.
+func printSessions(w io.Writer, sessions []Session, now time.Time) {
+	total := 0.0
+	for _, s := range sessions {
+		hours := s.Hours(now)
+		total += hours
+		fmt.Fprintf(w, "%8s  %s\n", hoursToText(hours), formatSessionLine(s))
+	}
+	noun := "sessions"
+	if len(sessions) == 1 {
+		noun = "session"
+	}
+	fmt.Fprintf(w, "%8s  %d %s\n", hoursToText(total), len(sessions), noun)
+}
+
+// normalizeNotes returns notes lowercased, with single spaces and without
+// trailing punctuation, so that "Fix bug." and "fix  bug" group together.
+func normalizeNotes(notes string) string {
+	notes = strings.Join(strings.Fields(strings.ToLower(notes)), " ")
+	notes = strings.TrimRight(notes, ".,;:!? ")
+	if notes == "" {
+		return NoNotes
+	}
+	return notes
+}
+
+// noteTotal is the time spent on a normalized note.
+type noteTotal struct {
+	Notes    string
+	Hours    float64
+	Sessions int
+}
+
+// noteTotals groups the time of the sessions starting between from and to
+// (inclusive days) by normalized notes, most time first.
+func noteTotals(records []Record, from, to time.Time, now time.Time) []noteTotal {
+	end := to.AddDate(0, 0, 1)
+	byNotes := map[string]*noteTotal{}
+	for _, s := range pairSessions(records) {
+		if s.Start.IsZero() || s.Start.Before(from) || !s.Start.Before(end) {
+			continue
+		}
+		key := normalizeNotes(s.Notes)
+		t, ok := byNotes[key]
+		if !ok {
+			t = &noteTotal{Notes: key}
+			byNotes[key] = t
+		}
+		t.Hours += s.Hours(now)
+		t.Sessions++
+	}
+
+	var totals []noteTotal
+	for _, t := range byNotes {
+		totals = append(totals, *t)
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].Hours != totals[j].Hours {
+			return totals[i].Hours > totals[j].Hours
+		}
+		return totals[i].Notes < totals[j].Notes
+	})
+	return totals
+}
+
+// printNoteTotals writes note totals with their share of the time.
+func printNoteTotals(w io.Writer, totals []noteTotal) {
+	sum := 0.0
+	for _, t := range totals {
+		sum += t.Hours
+	}
+	fmt.Fprintf(w, "%8s  %5s  %8s  %s\n", "Total", "Share", "Sessions", "Notes")
+	for _, t := range totals {
+		share := 0.0
+		if sum > 0 {
+			share = t.Hours / sum * 100
+		}
+		fmt.Fprintf(w, "%8s  %4.0f%%  %8d  %s\n", hoursToText(t.Hours), share, t.Sessions, t.Notes)
+	}
+	fmt.Fprintf(w, "%8s\n", hoursToText(sum))
+}
+
+var searchCmd = &cobra.Command{
+	Use:   "search REGEX",
+	Short: "List the sessions whose notes match a regular expression",
+	Long: `List the sessions whose check-in or check-out notes match a regular
+expression (Go syntax), newest first, with their durations and total. Open
+sessions count until now. Use (?i) to ignore case.
+
+EXAMPLES:
+  takt search "feature x"
+  takt search "(?i)^review"
+  takt search "\+acme"
+
+OUTPUT:
+     8h00m  2025-01-09 09:00 17:00 Feature X design
+     2h30m  2025-01-08 14:00 16:30 Feature X review
+    10h30m  2 sessions`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		re, err := regexp.Compile(args[0])
+		if err != nil {
+			fmt.Printf("Error: invalid regular expression: %v\n", err)
+			return
+		}
+		records, err := readReportRecords(-1)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		printSessions(os.Stdout, searchSessions(records, re), clock.Now())
+	},
+}
+
+var notesCmd = &cobra.Command{
+	Use:   "notes",
+	Short: "Break the time of this week down by notes",
+	Long: `Break the time of this week (or of today, or this month) down by notes.
+Notes are normalized to group alike ones: lowercased, with single spaces
+and without trailing punctuation. Open sessions count until now.
+
+EXAMPLES:
+  takt notes                    # this week
+  takt notes --day
+  takt notes --month
+
+OUTPUT:
+  Notes of 2025-W02 (2025-01-06 to 2025-01-12)
+     Total  Share  Sessions  Notes
+    6h00m    55%         2  feature x
+    3h00m    27%         1  standup
+    2h00m    18%         1  (no notes)
+   11h00m`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		period := report.PeriodWeek
+		for _, p := range []string{report.PeriodDay, report.PeriodMonth} {
+			if on, _ := cmd.Flags().GetBool(p); on {
+				period = p
+			}
+		}
+		now := clock.Now().In(time.Local)
+		from, to, label, err := report.PeriodRange(period, now)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		records, err := readReportRecords(-1)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		fmt.Printf("Notes of %s", label)
+		if period != report.PeriodDay {
+			fmt.Printf(" (%s to %s)", from.Format(DateFormat), to.Format(DateFormat))
+		}
+		fmt.Println()
+		printNoteTotals(os.Stdout, noteTotals(records, from, to, now))
+	},
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNormalizeNotes(t *testing.T) {
+	tests := map[string]string{
+		"Fix bug.":        "fix bug",
+		"  fix   BUG  ":   "fix bug",
+		"Feature X: done": "feature x: done",
+		"":                NoNotes,
+		"...":             NoNotes,
+	}
+	for notes, want := range tests {
+		if got := normalizeNotes(notes); got != want {
+			t.Errorf("normalizeNotes(%q) = %q, want %q", notes, got, want)
+		}
+	}
+}
+
+const notesTestCSV = `timestamp,kind,notes
+2025-01-09T17:00:00Z,out,Feature X shipped
+2025-01-09T13:00:00Z,in,Review
+2025-01-09T12:00:00Z,out,
+2025-01-09T09:00:00Z,in,Feature X.
+2025-01-08T12:00:00Z,out,
+2025-01-08T10:00:00Z,in,feature  x
+2025-01-08T09:00:00Z,out,
+2025-01-08T08:00:00Z,in,
+`
+
+func TestNoteTotals(t *testing.T) {
+	originalLocal := time.Local
+	time.Local = time.UTC
+	t.Cleanup(func() { time.Local = originalLocal })
+
+	records, err := readRecordsFromFile(newTestRecordsFile(t, notesTestCSV), -1)
+	if err != nil {
+		t.Fatalf("readRecordsFromFile() failed: %v", err)
+	}
+	from := time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 1, 12, 0, 0, 0, 0, time.UTC)
+	totals := noteTotals(records, from, to, to)
+
+	var sb strings.Builder
+	printNoteTotals(&sb, totals)
+	expected := "   Total  Share  Sessions  Notes\n" +
+		"   5h00m    50%         2  feature x\n" +
+		"   4h00m    40%         1  review\n" +
+		"   1h00m    10%         1  (no notes)\n" +
+		"  10h00m\n"
+	if sb.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, sb.String())
+	}
+
+	if got := noteTotals(records, to, to, to); len(got) != 0 {
+		t.Errorf("noteTotals() of a day without sessions = %+v", got)
+	}
+
+	sb.Reset()
+	printSessions(&sb, searchSessions(records, regexp.MustCompile("(?i)feature +x")), to)
+	expected = "   4h00m  2025-01-09 13:00 17:00 Review || Feature X shipped\n" +
+		"   3h00m  2025-01-09 09:00 12:00 Feature X.\n" +
+		"   2h00m  2025-01-08 10:00 12:00 feature  x\n" +
+		"   9h00m  3 sessions\n"
+	if sb.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, sb.String())
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"log"
+	"strconv"
+
+	"github.com/asdf8601/takt-go/pkg/report"
+	"github.com/spf13/cobra"
+)
+
+// runSummary returns the Run of a summary command of period, whose only
+// argument is HEAD and whose --notes flag prints the notes of each row.
+func runSummary(period string) func(cmd *cobra.Command, args []string) {
+	return func(cmd *cobra.Command, args []string) {
+		notes, _ := cmd.Flags().GetBool("notes")
+		head := DefaultHead
+		var err error
+		if len(args) > 0 {
+			head, err = strconv.Atoi(args[0])
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+		if err := summary(period, head, notes); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+var quarterCmd = &cobra.Command{
+	Aliases: []string{"q"},
+	Use:     "quarter [HEAD]",
+	Short:   "Quarterly summary with balance calculation",
+	Long: `Show quarterly time tracking summary with balance calculation.
+Quarters are calendar quarters: Q1 is January to March.
+Balance shows overtime/undertime based on TARGET_HOURS × working days.
+
+EXAMPLES:
+  takt quarter                  # Show last 10 quarters
+  takt q 4                      # Show last 4 quarters
+
+OUTPUT FORMAT:
+  Date      Total       Days Avg     Balance
+  2025-Q1   20d20h30m   62   8h04m   +4h30m
+  2024-Q4   20d16h00m   62   8h00m   00h00m`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runSummary(report.PeriodQuarter),
+}
+
+var fiscalYearCmd = &cobra.Command{
+	Aliases: []string{"fy"},
+	Use:     "fiscal-year [HEAD]",
+	Short:   "Fiscal year summary with balance calculation",
+	Long: `Show fiscal year summary with balance calculation.
+Fiscal years start in the month of TAKT_FISCAL_YEAR_START (default January)
+and are labeled by their first year: FY2025-26 runs from April 2025 to March
+2026 when they start in April.
+
+EXAMPLES:
+  takt fiscal-year              # Show last 10 fiscal years
+  TAKT_FISCAL_YEAR_START=apr takt fy 2
+
+OUTPUT FORMAT:
+  Date              Total  Days     Avg   Balance
+  FY2025-26    43d08h00m    130   8h00m    00h00m
+  FY2024-25    86d06h00m    259   7h59m   -2h00m`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runSummary(report.PeriodFiscalYear),
+}
+
+var sprintCmd = &cobra.Command{
+	Use:   "sprint [HEAD]",
+	Short: "Sprint summary with balance calculation",
+	Long: `Show sprint summary with balance calculation. Sprints are labeled by
+their first day.
+
+TAKT_SPRINT sets them as ANCHOR/LENGTH: the first day of any sprint and
+their length in days or weeks, such as 2025-01-06/2w (default: two weeks
+from Monday 2024-01-01).
+
+EXAMPLES:
+  takt sprint                   # Show last 10 sprints
+  TAKT_SPRINT=2025-01-08/3w takt sprint 4
+
+OUTPUT FORMAT:
+  Date              Total  Days     Avg   Balance
+  2025-01-13    1d16h00m      5   8h00m    00h00m
+  2024-12-30    3d00h30m      9   8h03m   +0h30m`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runSummary(report.PeriodSprint),
+}
+
+var summaryCmd = &cobra.Command{
+	Use:   "summary [HEAD]",
+	Short: "Summary of any period with balance calculation",
+	Long: `Show a summary with balance calculation of the period that --period
+describes:
+
+  day, week, month, quarter, year
+  fiscal-year[:MONTH]           fiscal years starting in MONTH (4 or apr)
+  sprint[:ANCHOR/LENGTH]        sprints of LENGTH days or weeks (10d, 2w)
+                                of which one starts on ANCHOR (YYYY-MM-DD)
+
+Without an argument, fiscal-year and sprint follow TAKT_FISCAL_YEAR_START
+and TAKT_SPRINT.
+
+EXAMPLES:
+  takt summary --period quarter
+  takt summary --period fiscal-year:apr 3
+  takt summary --period sprint:2025-01-06/3w
+
+OUTPUT FORMAT:
+  Date              Total  Days     Avg   Balance
+  2025-01-27    5d00h00m     15   8h00m    00h00m`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		period, _ := cmd.Flags().GetString("period")
+		runSummary(period)(cmd, args)
+	},
+}
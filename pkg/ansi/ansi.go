@@ -0,0 +1,61 @@
+// Package ansi colors terminal output with ANSI escape codes, when the
+// color mode and the output allow it.
+package ansi
+
+import (
+	"fmt"
+	"os"
+)
+
+const (
+	// Color modes
+	ColorAuto   = "auto"
+	ColorAlways = "always"
+	ColorNever  = "never"
+
+	// ANSI codes
+	Reset  = "\033[0m"
+	Red    = "\033[31m"
+	Yellow = "\033[33m"
+	Green  = "\033[32m"
+	Blue   = "\033[34m"
+	Orange = "\033[38;5;208m"
+	Gray   = "\033[37m"
+	Bold   = "\033[1m"
+)
+
+// UseColor decides whether to emit ANSI colors: "always" and "never" are
+// explicit; "auto" colors only when out is a terminal and NO_COLOR is unset.
+func UseColor(mode string, out *os.File) (bool, error) {
+	switch mode {
+	case ColorAlways:
+		return true, nil
+	case ColorNever:
+		return false, nil
+	case ColorAuto, "":
+		if os.Getenv("NO_COLOR") != "" {
+			return false, nil
+		}
+		return IsTerminal(out), nil
+	default:
+		return false, fmt.Errorf("invalid color mode: %s (must be '%s', '%s' or '%s')",
+			mode, ColorAuto, ColorAlways, ColorNever)
+	}
+}
+
+// IsTerminal reports whether f is a character device such as a TTY.
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Paint wraps text in code when color is on.
+func Paint(color bool, code, text string) string {
+	if !color || code == "" {
+		return text
+	}
+	return code + text + Reset
+}
@@ -0,0 +1,44 @@
+package ansi
+
+import (
+	"os"
+	"testing"
+)
+
+func TestUseColor(t *testing.T) {
+	pipeReader, pipeWriter, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	defer func() {
+		_ = pipeReader.Close()
+		_ = pipeWriter.Close()
+	}()
+
+	tests := []struct {
+		name     string
+		mode     string
+		noColor  string
+		expected bool
+		wantErr  bool
+	}{
+		{"always", ColorAlways, "1", true, false},
+		{"never", ColorNever, "", false, false},
+		{"auto_pipe", ColorAuto, "", false, false},
+		{"auto_no_color", ColorAuto, "1", false, false},
+		{"invalid", "sometimes", "", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("NO_COLOR", tt.noColor)
+			got, err := UseColor(tt.mode, pipeWriter)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UseColor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.expected {
+				t.Errorf("UseColor(%q) = %v, want %v", tt.mode, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,293 @@
+// Package billing prices takt sessions with rate cards and writes itemized
+// invoices as Markdown, HTML or CSV.
+package billing
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/report"
+	"github.com/asdf8601/takt-go/pkg/takt"
+)
+
+// Rate scopes, from the lowest precedence to the highest
+const (
+	ScopeDefault = "default"
+	ScopeProject = "project"
+	ScopeTag     = "tag"
+)
+
+// RateHeader is the header line of a rate card file.
+var RateHeader = []string{"scope", "name", "rate", "from"}
+
+// Rate is an hourly rate, in cents, effective from a date on.
+type Rate struct {
+	Scope string
+	Name  string // project or tag, without the prefix; empty for the default
+	Cents int64
+	From  time.Time // zero means always
+}
+
+// RateCard is the rates of a rate card file.
+type RateCard []Rate
+
+// ReadRateCard reads a rate card CSV: scope,name,rate,from, where scope is
+// default, project or tag, rate is an hourly amount such as 95.50 and from
+// is an optional YYYY-MM-DD.
+func ReadRateCard(r io.Reader) (RateCard, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	lines, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var card RateCard
+	for i, line := range lines {
+		if i == 0 && len(line) > 0 && line[0] == RateHeader[0] {
+			continue
+		}
+		if len(line) < 3 || len(line) > 4 {
+			return nil, fmt.Errorf("line %d: expected %s", i+1, strings.Join(RateHeader, ","))
+		}
+		rate, err := parseRate(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		card = append(card, rate)
+	}
+	return card, nil
+}
+
+// LoadRateCard reads the rate card file at path.
+func LoadRateCard(path string) (RateCard, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	card, err := ReadRateCard(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return card, nil
+}
+
+// parseRate parses a line of a rate card.
+func parseRate(line []string) (Rate, error) {
+	rate := Rate{Scope: strings.TrimSpace(line[0]), Name: strings.ToLower(strings.TrimSpace(line[1]))}
+	switch rate.Scope {
+	case ScopeDefault:
+		if rate.Name != "" {
+			return Rate{}, errors.New("the default rate takes no name")
+		}
+	case ScopeProject, ScopeTag:
+		// a project may be written +acme and a tag #urgent, but not the
+		// other way around
+		prefix, other := takt.ProjectPrefix, takt.TagPrefix
+		if rate.Scope == ScopeTag {
+			prefix, other = other, prefix
+		}
+		if strings.HasPrefix(rate.Name, other) {
+			return Rate{}, fmt.Errorf("invalid %s name %q: expected NAME or %sNAME", rate.Scope, rate.Name, prefix)
+		}
+		rate.Name = strings.TrimPrefix(rate.Name, prefix)
+		if rate.Name == "" {
+			return Rate{}, fmt.Errorf("a %s rate needs a name", rate.Scope)
+		}
+	default:
+		return Rate{}, fmt.Errorf("unsupported scope: %s (must be %s, %s or %s)", rate.Scope, ScopeDefault, ScopeProject, ScopeTag)
+	}
+
+	cents, err := ParseAmount(line[2])
+	if err != nil {
+		return Rate{}, err
+	}
+	rate.Cents = cents
+
+	if len(line) == 4 && strings.TrimSpace(line[3]) != "" {
+		rate.From, err = time.ParseInLocation(takt.DateFormat, strings.TrimSpace(line[3]), time.Local)
+		if err != nil {
+			return Rate{}, fmt.Errorf("invalid date %q: expected YYYY-MM-DD", line[3])
+		}
+	}
+	return rate, nil
+}
+
+// ParseAmount parses a non-negative amount such as 95.50 into cents.
+func ParseAmount(value string) (int64, error) {
+	amount, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || amount < 0 || math.IsInf(amount, 0) {
+		return 0, fmt.Errorf("invalid amount %q: expected e.g. 95.50", value)
+	}
+	return int64(math.Round(amount * 100)), nil
+}
+
+// Lookup returns the rate of a session with notes starting at start: a rate
+// of one of its tags over one of its projects over the default, each the
+// latest effective at start.
+func (c RateCard) Lookup(notes string, start time.Time) (Rate, bool) {
+	projects, tags := takt.Projects(notes), takt.Tags(notes)
+	matches := func(r Rate) bool {
+		switch r.Scope {
+		case ScopeTag:
+			return slices.Contains(tags, r.Name)
+		case ScopeProject:
+			return slices.Contains(projects, r.Name)
+		}
+		return true
+	}
+
+	var best Rate
+	found := false
+	for _, scope := range []string{ScopeTag, ScopeProject, ScopeDefault} {
+		for _, r := range c {
+			if r.Scope != scope || r.From.After(start) || !matches(r) {
+				continue
+			}
+			if !found || r.From.After(best.From) {
+				best, found = r, true
+			}
+		}
+		if found {
+			return best, true
+		}
+	}
+	return Rate{}, false
+}
+
+// Entry is a billable session.
+type Entry struct {
+	Start time.Time
+	End   time.Time
+	Notes string
+}
+
+// Item is a line of an invoice.
+type Item struct {
+	Entry
+	Raw    time.Duration // worked
+	Billed time.Duration // rounded
+	Rate   int64         // hourly, in cents
+	Amount int64         // in cents
+}
+
+// Invoice is an itemized invoice.
+type Invoice struct {
+	Number     string
+	Project    string
+	Period     string // e.g. 2026-09
+	Currency   string
+	Rounding   report.Rounding
+	TaxPercent float64
+	Items      []Item
+}
+
+// AddEntries prices entries with the card, rounding each one, and adds
+// them to the items in chronological order.
+func (inv *Invoice) AddEntries(entries []Entry, card RateCard) error {
+	for _, e := range entries {
+		rate, ok := card.Lookup(e.Notes, e.Start)
+		if !ok {
+			return fmt.Errorf("no rate for the session of %s %q", e.Start.Format("2006-01-02 15:04"), e.Notes)
+		}
+		raw := e.End.Sub(e.Start)
+		billed := inv.Rounding.Round(raw)
+		inv.Items = append(inv.Items, Item{
+			Entry:  e,
+			Raw:    raw,
+			Billed: billed,
+			Rate:   rate.Cents,
+			Amount: int64(math.Round(float64(rate.Cents) * billed.Hours())),
+		})
+	}
+	slices.SortStableFunc(inv.Items, func(a, b Item) int {
+		return a.Start.Compare(b.Start)
+	})
+	return nil
+}
+
+// Raw returns the worked time of all items.
+func (inv Invoice) Raw() time.Duration {
+	var total time.Duration
+	for _, item := range inv.Items {
+		total += item.Raw
+	}
+	return total
+}
+
+// Billed returns the billed time of all items.
+func (inv Invoice) Billed() time.Duration {
+	var total time.Duration
+	for _, item := range inv.Items {
+		total += item.Billed
+	}
+	return total
+}
+
+// Subtotal returns the amount of all items, in cents.
+func (inv Invoice) Subtotal() int64 {
+	var total int64
+	for _, item := range inv.Items {
+		total += item.Amount
+	}
+	return total
+}
+
+// Tax returns the tax on the subtotal, in cents.
+func (inv Invoice) Tax() int64 {
+	return int64(math.Round(float64(inv.Subtotal()) * inv.TaxPercent / 100))
+}
+
+// Total returns the subtotal plus tax, in cents.
+func (inv Invoice) Total() int64 {
+	return inv.Subtotal() + inv.Tax()
+}
+
+// currencySymbols are written before the amount; other currencies follow
+// it by code.
+var currencySymbols = map[string]string{
+	"EUR": "€",
+	"USD": "$",
+	"GBP": "£",
+}
+
+// FormatAmount formats cents in a currency: €1,234.50 or 1,234.50 CHF.
+func FormatAmount(cents int64, currency string) string {
+	sign := ""
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	units := strconv.FormatInt(cents/100, 10)
+	var grouped strings.Builder
+	for i, digit := range units {
+		if i > 0 && (len(units)-i)%3 == 0 {
+			grouped.WriteByte(',')
+		}
+		grouped.WriteRune(digit)
+	}
+	number := fmt.Sprintf("%s.%02d", grouped.String(), cents%100)
+
+	currency = strings.ToUpper(currency)
+	if symbol, ok := currencySymbols[currency]; ok {
+		return sign + symbol + number
+	}
+	if currency == "" {
+		return sign + number
+	}
+	return sign + number + " " + currency
+}
+
+// formatHours formats a duration as decimal hours, such as 1.25.
+func formatHours(d time.Duration) string {
+	return strconv.FormatFloat(d.Hours(), 'f', 2, 64)
+}
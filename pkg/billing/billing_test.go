@@ -0,0 +1,171 @@
+package billing
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/report"
+)
+
+const testRateCard = `scope,name,rate,from
+default,,80,
+project,acme,100,
+project,+acme,120,2026-09-15
+tag,#urgent,150,
+`
+
+func at(day, hour, min int) time.Time {
+	return time.Date(2026, 9, day, hour, min, 0, 0, time.Local)
+}
+
+func TestRateCardLookup(t *testing.T) {
+	card, err := ReadRateCard(strings.NewReader(testRateCard))
+	if err != nil {
+		t.Fatalf("ReadRateCard() failed: %v", err)
+	}
+
+	tests := []struct {
+		notes string
+		start time.Time
+		want  int64
+	}{
+		{"standup", at(1, 9, 0), 8000},
+		{"+acme review", at(1, 9, 0), 10000},
+		{"+ACME review", at(15, 9, 0), 12000},
+		{"+acme fix #urgent", at(1, 9, 0), 15000},
+	}
+	for _, tt := range tests {
+		rate, ok := card.Lookup(tt.notes, tt.start)
+		if !ok || rate.Cents != tt.want {
+			t.Errorf("Lookup(%q, %s) = %d, %v, want %d", tt.notes, tt.start.Format("01-02"), rate.Cents, ok, tt.want)
+		}
+	}
+
+	if _, ok := (RateCard{}).Lookup("+acme", at(1, 9, 0)); ok {
+		t.Error("Lookup() on an empty card found a rate")
+	}
+}
+
+func TestReadRateCardInvalid(t *testing.T) {
+	for _, card := range []string{
+		"hourly,,80\n",
+		"default,acme,80\n",
+		"project,,80\n",
+		"project,acme,cheap\n",
+		"project,acme,80,September\n",
+		"project,#acme,80\n",
+		"tag,+urgent,80\n",
+		"tag,#,80\n",
+	} {
+		if _, err := ReadRateCard(strings.NewReader(card)); err == nil {
+			t.Errorf("ReadRateCard(%q) succeeded, want an error", card)
+		}
+	}
+}
+
+func TestInvoice(t *testing.T) {
+	card, err := ReadRateCard(strings.NewReader(testRateCard))
+	if err != nil {
+		t.Fatalf("ReadRateCard() failed: %v", err)
+	}
+	inv := Invoice{
+		Number:     "2026-007",
+		Project:    "acme",
+		Period:     "2026-09",
+		Currency:   "EUR",
+		Rounding:   report.Rounding{Mode: report.RoundUp, Step: 15 * time.Minute},
+		TaxPercent: 21,
+	}
+	entries := []Entry{
+		{Start: at(16, 9, 0), End: at(16, 10, 5), Notes: "+acme | deploy"},
+		{Start: at(1, 9, 0), End: at(1, 17, 0), Notes: "+acme review"},
+	}
+	if err := inv.AddEntries(entries, card); err != nil {
+		t.Fatalf("AddEntries() failed: %v", err)
+	}
+
+	// 8h at 100 plus 1h05m billed as 1h15m at 120
+	if got := inv.Subtotal(); got != 95000 {
+		t.Errorf("Subtotal() = %d, want 95000", got)
+	}
+	if got := inv.Tax(); got != 19950 {
+		t.Errorf("Tax() = %d, want 19950", got)
+	}
+	if got := inv.Raw(); got != 9*time.Hour+5*time.Minute {
+		t.Errorf("Raw() = %v", got)
+	}
+	if got := inv.Billed(); got != 9*time.Hour+15*time.Minute {
+		t.Errorf("Billed() = %v", got)
+	}
+
+	var md strings.Builder
+	if err := inv.Write(&md, FormatMarkdown); err != nil {
+		t.Fatalf("WriteMarkdown() failed: %v", err)
+	}
+	for _, want := range []string{
+		"# Invoice 2026-007",
+		"| 2026-09-01 | 09:00–17:00 | +acme review | 8.00 | 8.00 | €100.00 | €800.00 |",
+		`| 2026-09-16 | 09:00–10:05 | +acme \| deploy | 1.08 | 1.25 | €120.00 | €150.00 |`,
+		"**Tax (21%):** €199.50",
+		"**Total:** €1,149.50",
+	} {
+		if !strings.Contains(md.String(), want) {
+			t.Errorf("Markdown is missing %q:\n%s", want, md.String())
+		}
+	}
+
+	var html strings.Builder
+	if err := inv.Write(&html, FormatHTML); err != nil {
+		t.Fatalf("WriteHTML() failed: %v", err)
+	}
+	if !strings.Contains(html.String(), "acme | deploy</td>") || !strings.Contains(html.String(), "€1,149.50") {
+		t.Errorf("Unexpected HTML:\n%s", html.String())
+	}
+
+	var csv strings.Builder
+	if err := inv.Write(&csv, FormatCSV); err != nil {
+		t.Fatalf("WriteCSV() failed: %v", err)
+	}
+	for _, want := range []string{
+		"2026-09-16,09:00,10:05,+acme | deploy,1.08,1.25,120.00,150.00,EUR\n",
+		",,,total,,,,1149.50,EUR\n",
+	} {
+		if !strings.Contains(csv.String(), want) {
+			t.Errorf("CSV is missing %q:\n%s", want, csv.String())
+		}
+	}
+
+	if err := inv.Write(&csv, "pdf"); err == nil {
+		t.Error("Write() succeeded with an unsupported format")
+	}
+}
+
+func TestInvoiceMissingRate(t *testing.T) {
+	card := RateCard{{Scope: ScopeProject, Name: "acme", Cents: 10000}}
+	inv := Invoice{}
+	err := inv.AddEntries([]Entry{{Start: at(1, 9, 0), End: at(1, 10, 0), Notes: "+other"}}, card)
+	if err == nil {
+		t.Error("AddEntries() succeeded without a matching rate")
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	tests := []struct {
+		cents    int64
+		currency string
+		want     string
+	}{
+		{123450, "EUR", "€1,234.50"},
+		{5, "usd", "$0.05"},
+		{123456789, "GBP", "£1,234,567.89"},
+		{99900, "CHF", "999.00 CHF"},
+		{-1050, "EUR", "-€10.50"},
+		{100, "", "1.00"},
+	}
+	for _, tt := range tests {
+		if got := FormatAmount(tt.cents, tt.currency); got != tt.want {
+			t.Errorf("FormatAmount(%d, %q) = %q, want %q", tt.cents, tt.currency, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package billing
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+)
+
+// Invoice formats
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+	FormatCSV      = "csv"
+)
+
+// Write writes the invoice in format.
+func (inv Invoice) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatMarkdown:
+		return inv.WriteMarkdown(w)
+	case FormatHTML:
+		return inv.WriteHTML(w)
+	case FormatCSV:
+		return inv.WriteCSV(w)
+	}
+	return fmt.Errorf("unsupported invoice format: %s (must be %s, %s or %s)", format, FormatMarkdown, FormatHTML, FormatCSV)
+}
+
+// title returns the heading of the invoice.
+func (inv Invoice) title() string {
+	title := "Invoice"
+	if inv.Number != "" {
+		title += " " + inv.Number
+	}
+	return title
+}
+
+// totals returns the label and formatted amount of the subtotal, tax and
+// total lines.
+func (inv Invoice) totals() [][2]string {
+	lines := [][2]string{{"Subtotal", FormatAmount(inv.Subtotal(), inv.Currency)}}
+	if inv.TaxPercent != 0 {
+		label := fmt.Sprintf("Tax (%s%%)", strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.2f", inv.TaxPercent), "0"), "."))
+		lines = append(lines, [2]string{label, FormatAmount(inv.Tax(), inv.Currency)})
+	}
+	return append(lines, [2]string{"Total", FormatAmount(inv.Total(), inv.Currency)})
+}
+
+// markdownCell escapes the pipes of a Markdown table cell.
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// WriteMarkdown writes the invoice as Markdown with a table of items.
+func (inv Invoice) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", inv.title())
+	if inv.Project != "" {
+		fmt.Fprintf(&b, "- Project: %s\n", inv.Project)
+	}
+	fmt.Fprintf(&b, "- Period: %s\n", inv.Period)
+	fmt.Fprintf(&b, "- Rounding: %s\n\n", inv.Rounding)
+
+	b.WriteString("| Date | Time | Description | Hours | Billed | Rate | Amount |\n")
+	b.WriteString("|------|------|-------------|------:|-------:|-----:|-------:|\n")
+	for _, item := range inv.Items {
+		fmt.Fprintf(&b, "| %s | %s–%s | %s | %s | %s | %s | %s |\n",
+			item.Start.Format("2006-01-02"), item.Start.Format("15:04"), item.End.Format("15:04"),
+			markdownCell(item.Notes), formatHours(item.Raw), formatHours(item.Billed),
+			FormatAmount(item.Rate, inv.Currency), FormatAmount(item.Amount, inv.Currency))
+	}
+	fmt.Fprintf(&b, "| | | **Hours** | %s | %s | | |\n\n", formatHours(inv.Raw()), formatHours(inv.Billed()))
+
+	for _, line := range inv.totals() {
+		fmt.Fprintf(&b, "**%s:** %s  \n", line[0], line[1])
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var htmlTemplate = template.Must(template.New("invoice").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border-bottom: 1px solid #ddd; padding: 0.4em; text-align: left; }
+.num { text-align: right; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{with .Invoice.Project}}Project: {{.}}<br>
+{{end}}Period: {{.Invoice.Period}}<br>
+Rounding: {{.Invoice.Rounding}}</p>
+<table>
+<tr><th>Date</th><th>Time</th><th>Description</th><th class="num">Hours</th><th class="num">Billed</th><th class="num">Rate</th><th class="num">Amount</th></tr>
+{{range .Items}}<tr><td>{{.Date}}</td><td>{{.Time}}</td><td>{{.Notes}}</td><td class="num">{{.Raw}}</td><td class="num">{{.Billed}}</td><td class="num">{{.Rate}}</td><td class="num">{{.Amount}}</td></tr>
+{{end}}<tr><th></th><th></th><th>Hours</th><th class="num">{{.Raw}}</th><th class="num">{{.Billed}}</th><th></th><th></th></tr>
+</table>
+<table>
+{{range .Totals}}<tr><th>{{index . 0}}</th><td class="num">{{index . 1}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// htmlItem is an item formatted for the HTML template.
+type htmlItem struct {
+	Date, Time, Notes, Raw, Billed, Rate, Amount string
+}
+
+// WriteHTML writes the invoice as a standalone HTML page.
+func (inv Invoice) WriteHTML(w io.Writer) error {
+	data := struct {
+		Title       string
+		Invoice     Invoice
+		Items       []htmlItem
+		Raw, Billed string
+		Totals      [][2]string
+	}{
+		Title:   inv.title(),
+		Invoice: inv,
+		Raw:     formatHours(inv.Raw()),
+		Billed:  formatHours(inv.Billed()),
+		Totals:  inv.totals(),
+	}
+	for _, item := range inv.Items {
+		data.Items = append(data.Items, htmlItem{
+			Date:   item.Start.Format("2006-01-02"),
+			Time:   item.Start.Format("15:04") + "–" + item.End.Format("15:04"),
+			Notes:  item.Notes,
+			Raw:    formatHours(item.Raw),
+			Billed: formatHours(item.Billed),
+			Rate:   FormatAmount(item.Rate, inv.Currency),
+			Amount: FormatAmount(item.Amount, inv.Currency),
+		})
+	}
+	return htmlTemplate.Execute(w, data)
+}
+
+// WriteCSV writes the items as CSV with plain decimal amounts, for
+// spreadsheets, followed by the subtotal, tax and total lines.
+func (inv Invoice) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"date", "start", "end", "description", "hours", "billed_hours", "rate", "amount", "currency"})
+	for _, item := range inv.Items {
+		_ = cw.Write([]string{
+			item.Start.Format("2006-01-02"), item.Start.Format("15:04"), item.End.Format("15:04"),
+			item.Notes, formatHours(item.Raw), formatHours(item.Billed),
+			decimal(item.Rate), decimal(item.Amount), inv.Currency,
+		})
+	}
+	_ = cw.Write([]string{"", "", "", "subtotal", formatHours(inv.Raw()), formatHours(inv.Billed()), "", decimal(inv.Subtotal()), inv.Currency})
+	_ = cw.Write([]string{"", "", "", "tax", "", "", "", decimal(inv.Tax()), inv.Currency})
+	_ = cw.Write([]string{"", "", "", "total", "", "", "", decimal(inv.Total()), inv.Currency})
+	cw.Flush()
+	return cw.Error()
+}
+
+// decimal formats cents as a plain decimal amount, such as 1234.50.
+func decimal(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
@@ -0,0 +1,352 @@
+// Package filter parses the --where expressions that select the sessions a
+// report is made of, such as
+//
+//	project=acme and weekday in (mon,tue) and duration>2h and note~"review"
+//
+// An expression compares fields with values, and combines the comparisons
+// with and, or, not and parentheses; and binds tighter than or:
+//
+//	expr       = term { "or" term }
+//	term       = factor { "and" factor }
+//	factor     = "not" factor | "(" expr ")" | comparison
+//	comparison = FIELD OP VALUE | FIELD [ "not" ] "in" "(" VALUE { "," VALUE } ")"
+//	OP         = "=" | "!=" | "<" | "<=" | ">" | ">=" | "~" | "!~"
+//
+// Values are words or strings quoted with ' or "; keywords and field names
+// are case-insensitive. The fields are:
+//
+//	project   a +project of the notes: = != in
+//	tag       a #tag of the notes: = != in
+//	note      the check-in or check-out notes: = != in, and ~ !~ for a
+//	          case-insensitive regular expression
+//	weekday   mon ... sun of the start: = != in
+//	date      YYYY-MM-DD of the start: = != in < <= > >=
+//	month     YYYY-MM of the start: = != in < <= > >=
+//	start     HH:MM of the check-in: = != in < <= > >=
+//	end       HH:MM of the check-out: = != in < <= > >=
+//	duration  such as 90m or 1h30m, open sessions until now: = != in < <= > >=
+//
+// A negated comparison (!=, !~, not in) matches whatever the comparison
+// doesn't, so project!=acme matches the sessions without the +acme project.
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/takt"
+)
+
+// Subject is a session matched by a filter. A session without a check-in
+// has a zero Start; an open session has a zero End.
+type Subject struct {
+	Start    time.Time
+	End      time.Time
+	Notes    string // notes of the check-in
+	OutNotes string // notes of the check-out
+}
+
+// day returns the time whose date and weekday the subject has: its start,
+// or its end without one.
+func (s Subject) day() time.Time {
+	if s.Start.IsZero() {
+		return s.End
+	}
+	return s.Start
+}
+
+// Error is a syntax or value error at a position of the expression.
+type Error struct {
+	Pos int // 1-based byte offset
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("at %d: %s", e.Pos, e.Msg)
+}
+
+// Filter is a parsed expression. A nil Filter matches every session.
+type Filter struct {
+	src  string
+	root node
+}
+
+// Parse parses an expression. An empty one gives a nil Filter.
+func Parse(src string) (*Filter, error) {
+	if strings.TrimSpace(src) == "" {
+		return nil, nil
+	}
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.unexpected(t, `"and", "or" or the end`)
+	}
+	return &Filter{src: src, root: root}, nil
+}
+
+// String returns the expression f was parsed from.
+func (f *Filter) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.src
+}
+
+// Match reports whether s matches f; now is the end of an open session.
+func (f *Filter) Match(s Subject, now time.Time) bool {
+	return f == nil || f.root.match(s, now)
+}
+
+// node is a parsed expression.
+type node interface {
+	match(s Subject, now time.Time) bool
+}
+
+type andNode struct{ left, right node }
+
+func (n andNode) match(s Subject, now time.Time) bool {
+	return n.left.match(s, now) && n.right.match(s, now)
+}
+
+type orNode struct{ left, right node }
+
+func (n orNode) match(s Subject, now time.Time) bool {
+	return n.left.match(s, now) || n.right.match(s, now)
+}
+
+type notNode struct{ operand node }
+
+func (n notNode) match(s Subject, now time.Time) bool {
+	return !n.operand.match(s, now)
+}
+
+// Field kinds
+const (
+	kindWords  = iota // project, tag: any word of the notes
+	kindText          // note: the notes themselves
+	kindNumber        // the rest, encoded as ordered numbers
+)
+
+// field describes a field: the operators it supports and how to get and
+// parse its values.
+type field struct {
+	kind int
+	// ordered fields support < <= > >=
+	ordered bool
+	// what the values look like, for errors
+	expected string
+	// parse parses a value of a kindNumber field
+	parse func(value string) (int64, bool)
+	// number returns the value of a kindNumber field, if the session has one
+	number func(s Subject, now time.Time) (int64, bool)
+	// words returns the values of a kindWords field
+	words func(s Subject) []string
+	// normalize normalizes a value of a kindWords field
+	normalize func(value string) string
+}
+
+var fields = map[string]field{
+	"project": {
+		kind:      kindWords,
+		expected:  "a project",
+		words:     func(s Subject) []string { return takt.Projects(s.Notes + " " + s.OutNotes) },
+		normalize: func(v string) string { return strings.ToLower(strings.TrimPrefix(v, takt.ProjectPrefix)) },
+	},
+	"tag": {
+		kind:      kindWords,
+		expected:  "a tag",
+		words:     func(s Subject) []string { return takt.Tags(s.Notes + " " + s.OutNotes) },
+		normalize: func(v string) string { return strings.ToLower(strings.TrimPrefix(v, takt.TagPrefix)) },
+	},
+	"note": {
+		kind:     kindText,
+		expected: "a note",
+	},
+	"weekday": {
+		kind:     kindNumber,
+		expected: "a weekday such as mon",
+		parse:    parseWeekday,
+		number: func(s Subject, _ time.Time) (int64, bool) {
+			t := s.day()
+			return int64(t.Weekday()), !t.IsZero()
+		},
+	},
+	"date": {
+		kind:     kindNumber,
+		ordered:  true,
+		expected: "a date YYYY-MM-DD",
+		parse:    parseLayout("2006-01-02", dateNumber),
+		number: func(s Subject, _ time.Time) (int64, bool) {
+			t := s.day()
+			return dateNumber(t), !t.IsZero()
+		},
+	},
+	"month": {
+		kind:     kindNumber,
+		ordered:  true,
+		expected: "a month YYYY-MM",
+		parse:    parseLayout("2006-01", monthNumber),
+		number: func(s Subject, _ time.Time) (int64, bool) {
+			t := s.day()
+			return monthNumber(t), !t.IsZero()
+		},
+	},
+	"start": {
+		kind:     kindNumber,
+		ordered:  true,
+		expected: "a time HH:MM",
+		parse:    parseLayout("15:04", clockNumber),
+		number: func(s Subject, _ time.Time) (int64, bool) {
+			return clockNumber(s.Start), !s.Start.IsZero()
+		},
+	},
+	"end": {
+		kind:     kindNumber,
+		ordered:  true,
+		expected: "a time HH:MM",
+		parse:    parseLayout("15:04", clockNumber),
+		number: func(s Subject, _ time.Time) (int64, bool) {
+			return clockNumber(s.End), !s.End.IsZero()
+		},
+	},
+	"duration": {
+		kind:     kindNumber,
+		ordered:  true,
+		expected: "a duration such as 90m or 1h30m",
+		parse: func(v string) (int64, bool) {
+			d, err := time.ParseDuration(v)
+			return int64(d), err == nil && d >= 0
+		},
+		number: func(s Subject, now time.Time) (int64, bool) {
+			if s.Start.IsZero() {
+				return 0, false
+			}
+			end := s.End
+			if end.IsZero() {
+				end = now
+			}
+			return int64(end.Sub(s.Start)), true
+		},
+	},
+}
+
+// aliases are other names of fields.
+var aliases = map[string]string{
+	"notes":    "note",
+	"projects": "project",
+	"tags":     "tag",
+	"day":      "date",
+	"dow":      "weekday",
+}
+
+// fieldNames returns the names of the fields, sorted.
+func fieldNames() string {
+	var names []string
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func dateNumber(t time.Time) int64 {
+	return int64(t.Year()*10000 + int(t.Month())*100 + t.Day())
+}
+
+func monthNumber(t time.Time) int64 {
+	return int64(t.Year()*100 + int(t.Month()))
+}
+
+func clockNumber(t time.Time) int64 {
+	return int64(t.Hour()*60 + t.Minute())
+}
+
+// parseLayout returns a parser of values in layout, encoded by number.
+func parseLayout(layout string, number func(time.Time) int64) func(string) (int64, bool) {
+	return func(v string) (int64, bool) {
+		t, err := time.Parse(layout, v)
+		return number(t), err == nil
+	}
+}
+
+// parseWeekday parses a weekday name or its first three letters.
+func parseWeekday(v string) (int64, bool) {
+	v = strings.ToLower(v)
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		if v == name || v == name[:3] {
+			return int64(d), true
+		}
+	}
+	return 0, false
+}
+
+// comparison compares a field with values.
+type comparison struct {
+	field   field
+	op      string // = < <= > >= ~ in, the negations being notNodes
+	numbers []int64
+	texts   []string
+	re      *regexp.Regexp
+}
+
+func (c comparison) match(s Subject, now time.Time) bool {
+	switch c.field.kind {
+	case kindWords:
+		for _, word := range c.field.words(s) {
+			for _, text := range c.texts {
+				if word == text {
+					return true
+				}
+			}
+		}
+		return false
+	case kindText:
+		notes := []string{s.Notes, s.OutNotes}
+		for _, note := range notes {
+			if c.re != nil {
+				if c.re.MatchString(note) {
+					return true
+				}
+				continue
+			}
+			for _, text := range c.texts {
+				if strings.EqualFold(strings.TrimSpace(note), text) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	v, ok := c.field.number(s, now)
+	if !ok {
+		return false
+	}
+	switch c.op {
+	case "<":
+		return v < c.numbers[0]
+	case "<=":
+		return v <= c.numbers[0]
+	case ">":
+		return v > c.numbers[0]
+	case ">=":
+		return v >= c.numbers[0]
+	}
+	for _, n := range c.numbers {
+		if v == n {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+package filter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Thu 2025-01-09
+var (
+	testNow = time.Date(2025, 1, 9, 18, 0, 0, 0, time.UTC)
+	review  = Subject{
+		Start:    time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC), // Mon
+		End:      time.Date(2025, 1, 6, 12, 30, 0, 0, time.UTC),
+		Notes:    "Code review +acme #meeting",
+		OutNotes: "done",
+	}
+	short = Subject{
+		Start: time.Date(2025, 1, 7, 14, 0, 0, 0, time.UTC), // Tue
+		End:   time.Date(2025, 1, 7, 15, 0, 0, 0, time.UTC),
+		Notes: "Planning +Beta",
+	}
+	open = Subject{
+		Start: time.Date(2025, 1, 9, 13, 0, 0, 0, time.UTC), // Thu, 5h until now
+		Notes: "Support +acme",
+	}
+	noStart = Subject{
+		End:      time.Date(2025, 1, 8, 17, 0, 0, 0, time.UTC), // Wed
+		OutNotes: "Forgotten check-in",
+	}
+)
+
+func TestMatch(t *testing.T) {
+	subjects := map[string]Subject{"review": review, "short": short, "open": open, "noStart": noStart}
+	tests := []struct {
+		expr     string
+		expected string // names of the matching subjects, sorted
+	}{
+		{"", "noStart open review short"},
+		{"project=acme", "open review"},
+		{"project = +ACME", "open review"},
+		{"project=beta", "short"},
+		{"project!=acme", "noStart short"},
+		{"project in (beta, acme)", "open review short"},
+		{"project not in (acme)", "noStart short"},
+		{"tag=meeting", "review"},
+		{"TAG = '#meeting'", "review"},
+		{"weekday in (mon,tue)", "review short"},
+		{"weekday=wednesday", "noStart"},
+		{"weekday != thu", "noStart review short"},
+		{"date=2025-01-07", "short"},
+		{"date>=2025-01-07 and date<2025-01-09", "noStart short"},
+		{"month=2025-01", "noStart open review short"},
+		{"month<2025-01", ""},
+		{"start<10:00", "review"},
+		{"start>=13:00", "open short"},
+		{"end>12:00", "noStart review short"},
+		{"duration>2h", "open review"},
+		{"duration>4h30m", "open"},
+		{"duration<=1h", "short"},
+		{"duration in (1h, 3h30m)", "review short"},
+		{`note~"review"`, "review"},
+		{`note~'^(support|planning)'`, "open short"},
+		{`note!~review`, "noStart open short"},
+		{`notes~forgotten`, "noStart"},
+		{`note="done"`, "review"},
+		{"project=acme and weekday in (mon,tue) and duration>2h and note~\"review\"", "review"},
+		{"project=acme or project=beta", "open review short"},
+		{"project=beta or project=acme and duration>4h", "open short"},
+		{"(project=beta or project=acme) and duration>4h", "open"},
+		{"not project=acme", "noStart short"},
+		{"not (project=acme or tag=meeting) and weekday=tue", "short"},
+		{"NOT project=acme AND Weekday IN (Tue)", "short"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			f, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse() failed: %v", err)
+			}
+			var matched []string
+			for _, name := range []string{"noStart", "open", "review", "short"} {
+				if f.Match(subjects[name], testNow) {
+					matched = append(matched, name)
+				}
+			}
+			if got := strings.Join(matched, " "); got != tt.expected {
+				t.Errorf("Expected %q to match %q, got %q", tt.expr, tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"projct=acme", `at 1: unknown field "projct" (fields: date, duration, end, month, note, project, start, tag, weekday)`},
+		{"project acme", `at 9: expected an operator (= != < <= > >= ~ !~) or in, found "acme"`},
+		{"project=", "at 9: expected a value, found the end"},
+		{"project<acme", "at 8: project doesn't support <: use =, != or in"},
+		{"tag~x", "at 4: tag doesn't support ~: only note matches regular expressions"},
+		{"duration>2 hours", `at 10: invalid duration "2": expected a duration such as 90m or 1h30m`},
+		{"date=2025-13-01", `at 6: invalid date "2025-13-01": expected a date YYYY-MM-DD`},
+		{"weekday=someday", `at 9: invalid weekday "someday": expected a weekday such as mon`},
+		{"start>9am", `at 7: invalid start "9am": expected a time HH:MM`},
+		{`note~"("`, `at 6: invalid regular expression "(": error parsing regexp: missing closing ): ` + "`(`"},
+		{`note="review`, "at 6: unterminated string"},
+		{"project=acme and", "at 17: expected a field, found the end"},
+		{"project=acme or or tag=x", `at 17: expected a field, found "or"`},
+		{"project=acme tag=x", `at 14: expected "and", "or" or the end, found "tag"`},
+		{"(project=acme", `at 14: expected ")", found the end`},
+		{"project in acme", `at 12: expected "(" after in, found "acme"`},
+		{"project in (acme beta)", `at 18: expected "," or ")", found "beta"`},
+		{"project ! acme", `at 9: unexpected "!": expected != or !~`},
+		{"= acme", `at 1: expected a field, found "="`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := Parse(tt.expr)
+			if err == nil {
+				t.Fatal("Expected an error")
+			}
+			if err.Error() != tt.expected {
+				t.Errorf("Expected error\n  %s\ngot\n  %s", tt.expected, err)
+			}
+			var e *Error
+			if !errors.As(err, &e) {
+				t.Errorf("Expected an *Error, got %T", err)
+			}
+		})
+	}
+}
+
+func TestNilFilter(t *testing.T) {
+	f, err := Parse("  ")
+	if err != nil || f != nil {
+		t.Fatalf("Expected a nil filter for a blank expression, got %v, %v", f, err)
+	}
+	if !f.Match(review, testNow) || f.String() != "" {
+		t.Error("Expected a nil filter to match everything")
+	}
+}
@@ -0,0 +1,315 @@
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Token kinds
+const (
+	tokenEOF = iota
+	tokenWord
+	tokenString
+	tokenOp
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type token struct {
+	kind int
+	text string
+	pos  int // 1-based byte offset
+}
+
+// describe returns how errors name t.
+func (t token) describe() string {
+	switch t.kind {
+	case tokenEOF:
+		return "the end"
+	case tokenString:
+		return fmt.Sprintf("string %q", t.text)
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// is reports whether t is the keyword.
+func (t token) is(keyword string) bool {
+	return t.kind == tokenWord && strings.EqualFold(t.text, keyword)
+}
+
+// operators are the comparison operators, longest first.
+var operators = []string{"!=", "<=", ">=", "!~", "=", "<", ">", "~"}
+
+// negations are the negated operators and the ones they negate.
+var negations = map[string]string{"!=": "=", "!~": "~"}
+
+// lex splits src into tokens.
+func lex(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+			continue
+		case c == '(':
+			tokens = append(tokens, token{tokenLParen, "(", i + 1})
+			i++
+			continue
+		case c == ')':
+			tokens = append(tokens, token{tokenRParen, ")", i + 1})
+			i++
+			continue
+		case c == ',':
+			tokens = append(tokens, token{tokenComma, ",", i + 1})
+			i++
+			continue
+		case c == '"' || c == '\'':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(src) && src[j] != c; j++ {
+				if src[j] == '\\' && j+1 < len(src) && (src[j+1] == c || src[j+1] == '\\') {
+					j++
+				}
+				b.WriteByte(src[j])
+			}
+			if j == len(src) {
+				return nil, &Error{Pos: i + 1, Msg: "unterminated string"}
+			}
+			tokens = append(tokens, token{tokenString, b.String(), i + 1})
+			i = j + 1
+			continue
+		}
+
+		if op := operatorAt(src[i:]); op != "" {
+			tokens = append(tokens, token{tokenOp, op, i + 1})
+			i += len(op)
+			continue
+		}
+		if c == '!' {
+			return nil, &Error{Pos: i + 1, Msg: `unexpected "!": expected != or !~`}
+		}
+		j := i
+		for j < len(src) && !strings.ContainsRune(" \t\n\r(),\"'=!<>~", rune(src[j])) {
+			j++
+		}
+		tokens = append(tokens, token{tokenWord, src[i:j], i + 1})
+		i = j
+	}
+	return append(tokens, token{tokenEOF, "", len(src) + 1}), nil
+}
+
+// operatorAt returns the operator src starts with, if any.
+func operatorAt(src string) string {
+	for _, op := range operators {
+		if strings.HasPrefix(src, op) {
+			return op
+		}
+	}
+	return ""
+}
+
+// parser is a recursive descent parser of the grammar in the package
+// comment.
+type parser struct {
+	tokens []token
+	next   int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *parser) take() token {
+	t := p.tokens[p.next]
+	if t.kind != tokenEOF {
+		p.next++
+	}
+	return t
+}
+
+func (p *parser) unexpected(t token, expected string) error {
+	return &Error{Pos: t.pos, Msg: fmt.Sprintf("expected %s, found %s", expected, t.describe())}
+}
+
+// expr parses terms joined by or.
+func (p *parser) expr() (node, error) {
+	left, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().is("or") {
+		p.take()
+		right, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+// term parses factors joined by and.
+func (p *parser) term() (node, error) {
+	left, err := p.factor()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().is("and") {
+		p.take()
+		right, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+// factor parses a negation, a parenthesized expression or a comparison.
+func (p *parser) factor() (node, error) {
+	t := p.peek()
+	switch {
+	case t.is("not"):
+		p.take()
+		operand, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand}, nil
+	case t.kind == tokenLParen:
+		p.take()
+		n, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.take(); t.kind != tokenRParen {
+			return nil, p.unexpected(t, `")"`)
+		}
+		return n, nil
+	}
+	return p.comparison()
+}
+
+// comparison parses FIELD OP VALUE or FIELD [not] in (VALUES).
+func (p *parser) comparison() (node, error) {
+	name := p.take()
+	if name.kind != tokenWord || name.is("and") || name.is("or") || name.is("in") {
+		return nil, p.unexpected(name, "a field")
+	}
+	key := strings.ToLower(name.text)
+	if alias, ok := aliases[key]; ok {
+		key = alias
+	}
+	f, ok := fields[key]
+	if !ok {
+		return nil, &Error{Pos: name.pos, Msg: fmt.Sprintf("unknown field %q (fields: %s)", name.text, fieldNames())}
+	}
+
+	negate := false
+	var values []token
+	op := p.take()
+	switch {
+	case op.is("not") && p.peek().is("in"), op.is("in"):
+		if op.is("not") {
+			negate = true
+			p.take()
+		}
+		var err error
+		if values, err = p.list(); err != nil {
+			return nil, err
+		}
+		op.text = "in"
+	case op.kind == tokenOp:
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		values = []token{value}
+		if positive, ok := negations[op.text]; ok {
+			negate = true
+			op.text = positive
+		}
+	default:
+		return nil, p.unexpected(op, "an operator (= != < <= > >= ~ !~) or in")
+	}
+
+	c, err := compile(name, f, op, values)
+	if err != nil {
+		return nil, err
+	}
+	if negate {
+		return notNode{c}, nil
+	}
+	return c, nil
+}
+
+// value parses a word or a string.
+func (p *parser) value() (token, error) {
+	t := p.take()
+	if t.kind != tokenWord && t.kind != tokenString {
+		return t, p.unexpected(t, "a value")
+	}
+	return t, nil
+}
+
+// list parses (VALUE, ...).
+func (p *parser) list() ([]token, error) {
+	if t := p.take(); t.kind != tokenLParen {
+		return nil, p.unexpected(t, `"(" after in`)
+	}
+	var values []token
+	for {
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+		t := p.take()
+		if t.kind == tokenRParen {
+			return values, nil
+		}
+		if t.kind != tokenComma {
+			return nil, p.unexpected(t, `"," or ")"`)
+		}
+	}
+}
+
+// compile checks that field f supports op and parses its values.
+func compile(name token, f field, op token, values []token) (comparison, error) {
+	c := comparison{field: f, op: op.text}
+	field := strings.ToLower(name.text)
+	switch op.text {
+	case "<", "<=", ">", ">=":
+		if !f.ordered {
+			return c, &Error{Pos: op.pos, Msg: fmt.Sprintf("%s doesn't support %s: use =, != or in", field, op.text)}
+		}
+	case "~":
+		if f.kind != kindText {
+			return c, &Error{Pos: op.pos, Msg: fmt.Sprintf("%s doesn't support ~: only note matches regular expressions", field)}
+		}
+		if _, err := regexp.Compile(values[0].text); err != nil {
+			return c, &Error{Pos: values[0].pos, Msg: fmt.Sprintf("invalid regular expression %q: %v", values[0].text, err)}
+		}
+		c.re = regexp.MustCompile("(?i)" + values[0].text)
+		return c, nil
+	}
+
+	for _, value := range values {
+		switch f.kind {
+		case kindWords:
+			c.texts = append(c.texts, f.normalize(value.text))
+		case kindText:
+			c.texts = append(c.texts, strings.TrimSpace(value.text))
+		default:
+			n, ok := f.parse(value.text)
+			if !ok {
+				return c, &Error{Pos: value.pos, Msg: fmt.Sprintf("invalid %s %s: expected %s", field, value.describe(), f.expected)}
+			}
+			c.numbers = append(c.numbers, n)
+		}
+	}
+	return c, nil
+}
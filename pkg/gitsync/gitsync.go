@@ -0,0 +1,95 @@
+// Package gitsync adds, commits and pushes the records file in its git
+// repository.
+package gitsync
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// CommitMessage is the message of the commits of takt commit.
+const CommitMessage = "Automatic commit from Takt"
+
+// FindRoot finds the git root directory starting from the directory of fileName.
+func FindRoot(fileName string) (string, error) {
+	dir, err := filepath.Abs(filepath.Dir(fileName))
+	if err != nil {
+		return "", fmt.Errorf("couldn't get absolute path: %w", err)
+	}
+
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir, nil
+		}
+		if dir == "/" {
+			return "", errors.New("not in a git repository")
+		}
+		dir = filepath.Join(dir, "..")
+	}
+}
+
+// Add adds fileName to its git repository.
+func Add(fileName string) error {
+	gitRoot, err := FindRoot(fileName)
+	if err != nil {
+		return err
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(fileName))
+	if err != nil {
+		return fmt.Errorf("couldn't get absolute path: %w", err)
+	}
+
+	fileDirRel, err := filepath.Rel(gitRoot, dir)
+	if err != nil {
+		return fmt.Errorf("couldn't get relative path: %w", err)
+	}
+
+	fileNameRel := filepath.Join(fileDirRel, filepath.Base(fileName))
+	return run(exec.Command("git", "-C", gitRoot, "add", fileNameRel))
+}
+
+// Commit commits the staged changes of the git repository of fileName.
+func Commit(fileName string) error {
+	gitRoot, _ := FindRoot(fileName)
+	return run(exec.Command("git", "-C", gitRoot, "commit", "-m", CommitMessage))
+}
+
+// Push pushes the git repository of fileName.
+func Push(fileName string) error {
+	gitRoot, _ := FindRoot(fileName)
+	return run(exec.Command("git", "-C", gitRoot, "push"))
+}
+
+// run executes a git command, printing its stderr. Exit code 1, such as
+// git commit with nothing to commit, isn't an error.
+func run(cmd *exec.Cmd) error {
+	stderr, _ := cmd.StderrPipe()
+
+	if err := cmd.Start(); err != nil {
+		fmt.Print("error= " + err.Error())
+	}
+
+	slurp, _ := io.ReadAll(stderr)
+	if slurp != nil {
+		fmt.Printf("%s\n", slurp)
+	}
+
+	if err := cmd.Wait(); err != nil {
+		if e, ok := err.(interface{ ExitCode() int }); ok {
+			if e.ExitCode() != 1 {
+				// exit code is neither zero (as we have an error) or one
+				fmt.Print("error= " + err.Error())
+				return err
+			}
+		} else {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package gitsync
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindRoot(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0o755); err != nil {
+		t.Fatalf("Failed to create .git: %v", err)
+	}
+	dir := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("Failed to create %s: %v", dir, err)
+	}
+
+	got, err := FindRoot(filepath.Join(dir, "takt.csv"))
+	if err != nil {
+		t.Fatalf("FindRoot() failed: %v", err)
+	}
+	if got != root {
+		t.Errorf("FindRoot() = %q, want %q", got, root)
+	}
+}
@@ -0,0 +1,363 @@
+// Package grid draws the activity grid of the records: a symbol per day,
+// by the hours worked, in weeks from Monday to Sunday, a month calendar and
+// SVG or PNG images.
+package grid
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/ansi"
+	"github.com/asdf8601/takt-go/pkg/report"
+	"github.com/asdf8601/takt-go/pkg/takt"
+)
+
+const (
+	// Columns of a row: the date of its first day, the ISO week number and
+	// a symbol per weekday, Sunday first
+	Columns = 9
+
+	// Hour thresholds between levels with the default target hours,
+	// scaled to the target unless set explicitly
+	LowHours      = 1.0
+	MediumHours   = 4.0
+	HighHours     = 8.0
+	VeryHighHours = 12.0
+)
+
+// Thresholds are the hour limits between the five levels of the grid.
+type Thresholds [4]float64
+
+// DefaultThresholds returns the thresholds scaled to the target hours, so
+// that the "normal" level always ends at the target.
+func DefaultThresholds(targetHours float64) Thresholds {
+	scale := 1.0
+	if targetHours > 0 {
+		scale = targetHours / takt.DefaultTargetHours
+	}
+	return Thresholds{LowHours * scale, MediumHours * scale, HighHours * scale, VeryHighHours * scale}
+}
+
+// ParseThresholds parses four ascending hour limits such as "1,4,8,12". It
+// returns nil for an empty value.
+func ParseThresholds(value string) (*Thresholds, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("must have 4 comma-separated hours, got %q", value)
+	}
+	var thresholds Thresholds
+	for i, part := range parts {
+		hours, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || hours < 0 {
+			return nil, fmt.Errorf("invalid hours %q", part)
+		}
+		if i > 0 && hours <= thresholds[i-1] {
+			return nil, fmt.Errorf("must be ascending, got %q", value)
+		}
+		thresholds[i] = hours
+	}
+	return &thresholds, nil
+}
+
+// Level returns the activity level (0 minimal to 4 very heavy) of a day
+// with the given hours.
+func (th Thresholds) Level(hours float64) int {
+	switch {
+	case hours < th[0]:
+		return 0
+	case hours < th[1]:
+		return 1
+	case hours < th[2]:
+		return 2
+	case hours < th[3]:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// Ranges describes the hours covered by each level.
+func (th Thresholds) Ranges() [5]string {
+	return [5]string{
+		fmt.Sprintf("%s - %s", report.HoursToText(0), report.HoursToText(th[0])),
+		fmt.Sprintf("%s - %s", report.HoursToText(th[0]), report.HoursToText(th[1])),
+		fmt.Sprintf("%s - %s", report.HoursToText(th[1]), report.HoursToText(th[2])),
+		fmt.Sprintf("%s - %s", report.HoursToText(th[2]), report.HoursToText(th[3])),
+		fmt.Sprintf("%s or more", report.HoursToText(th[3])),
+	}
+}
+
+// Labels returns the short legend label of each level.
+func (th Thresholds) Labels() [5]string {
+	return [5]string{
+		fmt.Sprintf("<%gh", th[0]),
+		fmt.Sprintf("%g-%gh", th[0], th[1]),
+		fmt.Sprintf("%g-%gh", th[1], th[2]),
+		fmt.Sprintf("%g-%gh", th[2], th[3]),
+		fmt.Sprintf("%gh+", th[3]),
+	}
+}
+
+// Day is a single day of the grid with its worked hours.
+type Day struct {
+	Date  time.Time
+	Hours float64
+}
+
+// Range is an inclusive range of days.
+type Range struct {
+	From time.Time
+	To   time.Time
+}
+
+// YearRange returns the range covering the calendar year.
+func YearRange(year int) Range {
+	return Range{
+		From: time.Date(year, 1, 1, 0, 0, 0, 0, time.Local),
+		To:   time.Date(year, 12, 31, 0, 0, 0, 0, time.Local),
+	}
+}
+
+// Ranges returns the ranges to draw: from the from day to the to day (today
+// when empty), or the calendar years ending at year, stacked.
+func Ranges(year string, years int, from, to string, now time.Time) ([]Range, error) {
+	if from != "" || to != "" {
+		if from == "" {
+			return nil, errors.New("--to requires --from")
+		}
+		r := Range{To: now}
+		var err error
+		if r.From, err = time.ParseInLocation(takt.DateFormat, from, time.Local); err != nil {
+			return nil, fmt.Errorf("invalid --from date %q: expected YYYY-MM-DD", from)
+		}
+		if to != "" {
+			if r.To, err = time.ParseInLocation(takt.DateFormat, to, time.Local); err != nil {
+				return nil, fmt.Errorf("invalid --to date %q: expected YYYY-MM-DD", to)
+			}
+		}
+		if r.To.Before(r.From) {
+			return nil, errors.New("--to is before --from")
+		}
+		return []Range{r}, nil
+	}
+
+	y, err := strconv.Atoi(year)
+	if err != nil {
+		return nil, fmt.Errorf("invalid year format: %w", err)
+	}
+	if years < 1 {
+		years = 1
+	}
+	var ranges []Range
+	for i := years - 1; i >= 0; i-- {
+		ranges = append(ranges, YearRange(y-i))
+	}
+	return ranges, nil
+}
+
+// Days returns every day of the range, up to today, with the hours worked
+// on it.
+func Days(c report.Config, records []takt.Record, r Range) ([]Day, error) {
+	daysAgg := make(map[string]report.AggregatedRecord)
+	if len(records) > 0 {
+		agg, err := c.CalculateDuration(records, report.PeriodDay)
+		if err != nil {
+			return nil, fmt.Errorf("error calculating duration: %w", err)
+		}
+		for _, a := range agg {
+			daysAgg[a.Group] = a
+		}
+	}
+
+	var days []Day
+	today := c.Now().Format(takt.DateFormat)
+	last := r.To.Format(takt.DateFormat)
+	for t := r.From; ; t = t.AddDate(0, 0, 1) {
+		day := t.Format(takt.DateFormat)
+		if day > today || day > last {
+			break
+		}
+		days = append(days, Day{Date: t, Hours: daysAgg[day].TotalHours})
+	}
+	return days, nil
+}
+
+// Row is a week of the grid.
+type Row [Columns]string
+
+// Build lays out days in weeks from Monday to Sunday. Each row holds the
+// date of its first day, the ISO week number and a symbol per weekday
+// (Sunday first, indexed by time.Weekday).
+func Build(days []Day, style Style, th Thresholds) []Row {
+	var rows []Row
+	for _, day := range days {
+		weekday := day.Date.Weekday()
+		if len(rows) == 0 || weekday == time.Monday {
+			var row Row
+			for j := range row {
+				row[j] = "  "
+			}
+			_, week := day.Date.ISOWeek()
+			row[0] = day.Date.Format(takt.DateFormat)
+			row[1] = fmt.Sprintf("%02d", week)
+			rows = append(rows, row)
+		}
+		rows[len(rows)-1][weekday+2] = style.Symbols[th.Level(day.Hours)]
+	}
+	return rows
+}
+
+// Stats are the days of a grid.
+type Stats struct {
+	TotalDays  int
+	ActiveDays int // with more than minimal work
+}
+
+// Summarize counts the days of the rows drawn with style.
+func Summarize(rows []Row, style Style) Stats {
+	var stats Stats
+	for _, row := range rows {
+		for _, symbol := range row[2:] {
+			level := style.Level(symbol)
+			if level < 0 {
+				continue
+			}
+			stats.TotalDays++
+			if level > 0 {
+				stats.ActiveDays++
+			}
+		}
+	}
+	return stats
+}
+
+// ActivityRate returns the percentage of active days.
+func (s Stats) ActivityRate() float64 {
+	if s.TotalDays == 0 {
+		return 0
+	}
+	return float64(s.ActiveDays) / float64(s.TotalDays) * 100
+}
+
+// Write writes the rows with month separators, a summary and, with legend,
+// the legend of the levels.
+func Write(w io.Writer, rows []Row, legend bool, style Style, th Thresholds) {
+	pad := "    "
+	rule := strings.Repeat(style.Rule, 34)
+	separator := strings.Repeat(style.Separator, 34)
+
+	fmt.Fprintf(w, "%s%s\n", pad, style.Paint(ansi.Bold+ansi.Blue, fmt.Sprintf("%-10s W  M  T  W  T  F  S  S", "Date")))
+	fmt.Fprintf(w, "%s%s\n", pad, style.Paint(ansi.Blue, rule))
+
+	var currentMonth string
+	for _, week := range rows {
+		// Extract month from date for separators
+		if len(week[0]) >= 7 {
+			weekMonth := week[0][:7] // "2025-01"
+			if currentMonth != "" && currentMonth != weekMonth {
+				fmt.Fprintf(w, "%s%s\n", pad, style.Paint(ansi.Blue, separator))
+			}
+			currentMonth = weekMonth
+		}
+
+		colored := week
+		for i := 2; i < len(week); i++ {
+			if level := style.Level(week[i]); level >= 0 {
+				colored[i] = style.Paint(LevelColors[level], week[i])
+			}
+		}
+		// Monday first
+		fmt.Fprintf(w, "%s%s %s %s %s %s %s %s %s %s\n",
+			pad, style.Paint(ansi.Bold, colored[0]),
+			colored[1], colored[3], colored[4],
+			colored[5], colored[6], colored[7],
+			colored[8], colored[2])
+	}
+
+	if stats := Summarize(rows, style); stats.TotalDays > 0 {
+		fmt.Fprintf(w, "\n%s%s\n", pad, style.Paint(ansi.Bold, style.Summary))
+		fmt.Fprintf(w, "%s%s\n", pad, style.Paint(ansi.Blue, fmt.Sprintf("%s Total tracked days: %d", style.Branch, stats.TotalDays)))
+		fmt.Fprintf(w, "%s%s\n", pad, style.Paint(ansi.Blue, fmt.Sprintf("%s Active work days: %d", style.Branch, stats.ActiveDays)))
+		if stats.ActiveDays > 0 {
+			fmt.Fprintf(w, "%s%s\n", pad, style.Paint(ansi.Blue, fmt.Sprintf("%s Activity rate: %.1f%%", style.LastBranch, stats.ActivityRate())))
+		}
+	}
+
+	if legend {
+		fmt.Fprintf(w, "\n%s%s\n", pad, style.Paint(ansi.Bold, style.Legend))
+		for level, label := range th.Ranges() {
+			branch := style.Branch
+			if level == len(style.Symbols)-1 {
+				branch = style.LastBranch
+			}
+			fmt.Fprintf(w, "%s%s %s %-16s (%s)\n", pad, branch, style.Paint(LevelColors[level], style.Symbols[level]),
+				label, levelNames[level])
+		}
+	}
+}
+
+// WriteMonth writes a calendar of the month with each day's hours, weekly
+// totals and the month's balance.
+func WriteMonth(w io.Writer, c report.Config, records []takt.Record, month time.Time, style Style, th Thresholds) error {
+	from := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.Local)
+	days, err := Days(c, records, Range{From: from, To: from.AddDate(0, 1, -1)})
+	if err != nil {
+		return err
+	}
+
+	const cellFmt = "%-10s"
+	pad := "    "
+	fmt.Fprintf(w, "%s%s\n", pad, style.Paint(ansi.Bold+ansi.Blue, from.Format("January 2006")))
+	header := ""
+	for _, name := range []string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"} {
+		header += fmt.Sprintf(cellFmt, name)
+	}
+	fmt.Fprintf(w, "%s%s%s\n", pad, style.Paint(ansi.Bold, header), style.Paint(ansi.Bold, "Week"))
+
+	line := pad + strings.Repeat(fmt.Sprintf(cellFmt, ""), (int(from.Weekday())+6)%7)
+	weekHours := 0.0
+	for i, day := range days {
+		text := fmt.Sprintf(cellFmt, fmt.Sprintf("%2d %s", day.Date.Day(), calendarHours(day.Hours)))
+		if day.Hours > 0 {
+			text = style.Paint(LevelColors[th.Level(day.Hours)], text)
+		}
+		line += text
+		weekHours += day.Hours
+
+		if day.Date.Weekday() == time.Sunday || i == len(days)-1 {
+			if day.Date.Weekday() != time.Sunday {
+				line += strings.Repeat(fmt.Sprintf(cellFmt, ""), 7-(int(day.Date.Weekday())+6)%7-1)
+			}
+			fmt.Fprintf(w, "%s%s\n", line, report.HoursToText(weekHours))
+			line, weekHours = pad, 0
+		}
+	}
+
+	agg, err := c.CalculateDuration(records, report.PeriodMonth)
+	if err != nil {
+		return err
+	}
+	for _, a := range agg {
+		if a.Group == from.Format("2006-01") {
+			fmt.Fprintf(w, "\n%sTotal: %s  Days: %d  Avg: %s  Balance: %s\n", pad,
+				report.HoursToText(a.TotalHours), len(a.Dates), report.HoursToText(a.AverageHours), c.FormatOvertime(c.Balance(a)))
+		}
+	}
+	return nil
+}
+
+// calendarHours formats the hours of a calendar day, "-" when none.
+func calendarHours(hours float64) string {
+	if hours <= 0 {
+		return "-"
+	}
+	return report.HoursToText(hours)
+}
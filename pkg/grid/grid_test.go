@@ -0,0 +1,160 @@
+package grid
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/ansi"
+	"github.com/asdf8601/takt-go/pkg/report"
+	"github.com/asdf8601/takt-go/pkg/takt"
+)
+
+func TestParseThresholds(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected *Thresholds
+		wantErr  bool
+	}{
+		{"unset", "", nil, false},
+		{"valid", "0.5, 3,6,9", &Thresholds{0.5, 3, 6, 9}, false},
+		{"too_few", "1,4,8", nil, true},
+		{"not_ascending", "1,8,4,12", nil, true},
+		{"invalid", "1,4,x,12", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseThresholds(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseThresholds() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (got == nil) != (tt.expected == nil) || (got != nil && *got != *tt.expected) {
+				t.Errorf("ParseThresholds() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLevelRelativeToTarget(t *testing.T) {
+	if got := DefaultThresholds(8.0).Level(7.0); got != 2 {
+		t.Errorf("7h with 8h target: level = %d, want 2 (normal)", got)
+	}
+	six := DefaultThresholds(6.0)
+	if got := six.Level(7.0); got != 3 {
+		t.Errorf("7h with 6h target: level = %d, want 3 (heavy)", got)
+	}
+	if six != (Thresholds{0.75, 3, 6, 9}) {
+		t.Errorf("DefaultThresholds(6) = %v", six)
+	}
+	if got := DefaultThresholds(0); got != (Thresholds{LowHours, MediumHours, HighHours, VeryHighHours}) {
+		t.Errorf("DefaultThresholds(0) = %v", got)
+	}
+}
+
+func TestStyleLevel(t *testing.T) {
+	style, err := NewStyle(SymbolsASCII, ansi.ColorNever)
+	if err != nil {
+		t.Fatalf("NewStyle() failed: %v", err)
+	}
+	if style.Level("+ ") != 2 || style.Level("  ") != -1 {
+		t.Error("Unexpected symbol levels for the ascii set")
+	}
+	if got := style.Paint(ansi.Red, "x"); got != "x" {
+		t.Errorf("Paint() without color = %q, want plain text", got)
+	}
+	if _, err := NewStyle("emoji", ansi.ColorNever); err == nil {
+		t.Error("Expected error for unknown symbol set")
+	}
+}
+
+func TestBuild(t *testing.T) {
+	style, err := NewStyle(SymbolsASCII, ansi.ColorNever)
+	if err != nil {
+		t.Fatalf("NewStyle() failed: %v", err)
+	}
+	c := report.Config{TargetHours: 8, Clock: takt.FixedClock(time.Date(2025, 1, 10, 12, 0, 0, 0, time.Local))}
+	th := DefaultThresholds(8)
+
+	// Leap year: the grid must include December 31
+	days, err := Days(c, nil, YearRange(2024))
+	if err != nil {
+		t.Fatalf("Days() failed: %v", err)
+	}
+	if len(days) != 366 {
+		t.Fatalf("Expected 366 days in 2024, got %d", len(days))
+	}
+	rows := Build(days, style, th)
+	last := rows[len(rows)-1]
+	if last[0] != "2024-12-30" || last[time.Tuesday+2] != style.Symbols[0] || last[time.Wednesday+2] != "  " {
+		t.Errorf("Unexpected last week: %v", last)
+	}
+	if stats := Summarize(rows, style); stats.TotalDays != 366 || stats.ActiveDays != 0 || stats.ActivityRate() != 0 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	// 2023-01-01 is a Sunday in ISO week 52 of 2022: it gets its own row
+	days, err = Days(c, nil, Range{
+		From: time.Date(2023, 1, 1, 0, 0, 0, 0, time.Local),
+		To:   time.Date(2023, 1, 8, 0, 0, 0, 0, time.Local),
+	})
+	if err != nil {
+		t.Fatalf("Days() failed: %v", err)
+	}
+	rows = Build(days, style, th)
+	if len(rows) != 2 || rows[0][1] != "52" || rows[0][time.Sunday+2] == "  " || rows[1][0] != "2023-01-02" {
+		t.Errorf("Unexpected grid: %v", rows)
+	}
+
+	// Ranges are clipped to today
+	days, err = Days(c, nil, Range{From: time.Date(2025, 1, 8, 0, 0, 0, 0, time.Local), To: time.Date(2026, 1, 8, 0, 0, 0, 0, time.Local)})
+	if err != nil {
+		t.Fatalf("Days() failed: %v", err)
+	}
+	if len(days) != 3 {
+		t.Errorf("Expected 3 days up to today, got %d", len(days))
+	}
+}
+
+func TestRanges(t *testing.T) {
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.Local)
+	ranges, err := Ranges("2025", 3, "", "", now)
+	if err != nil || len(ranges) != 3 || ranges[0].From.Year() != 2023 || ranges[2].To.Year() != 2025 {
+		t.Errorf("Ranges(2025, 3) = %v, %v", ranges, err)
+	}
+	ranges, err = Ranges("2025", 1, "2024-10-19", "", now)
+	if err != nil || len(ranges) != 1 || !ranges[0].To.Equal(now) {
+		t.Errorf("Ranges(--from) = %v, %v", ranges, err)
+	}
+	for _, args := range [][2]string{{"", "2025-01-01"}, {"2025-01-02", "2025-01-01"}, {"2025-1-2", ""}} {
+		if _, err := Ranges("2025", 1, args[0], args[1], now); err == nil {
+			t.Errorf("Expected an error for --from %q --to %q", args[0], args[1])
+		}
+	}
+}
+
+func TestWriteMonth(t *testing.T) {
+	c := report.Config{TargetHours: 8, Clock: takt.FixedClock(time.Date(2025, 1, 10, 12, 0, 0, 0, time.Local))}
+	records := []takt.Record{
+		{Timestamp: time.Date(2024, 7, 26, 18, 0, 0, 0, time.Local), Kind: "out", Notes: ""},
+		{Timestamp: time.Date(2024, 7, 26, 9, 0, 0, 0, time.Local), Kind: "in", Notes: ""},
+		{Timestamp: time.Date(2024, 7, 25, 15, 0, 0, 0, time.Local), Kind: "out", Notes: ""},
+		{Timestamp: time.Date(2024, 7, 25, 14, 0, 0, 0, time.Local), Kind: "in", Notes: ""},
+	}
+	style, err := NewStyle(SymbolsASCII, ansi.ColorNever)
+	if err != nil {
+		t.Fatalf("NewStyle() failed: %v", err)
+	}
+
+	var out strings.Builder
+	if err := WriteMonth(&out, c, records, time.Date(2024, 7, 1, 0, 0, 0, 0, time.Local), style, DefaultThresholds(8)); err != nil {
+		t.Fatalf("WriteMonth() failed: %v", err)
+	}
+	output := out.String()
+	for _, want := range []string{"July 2024", "25 1h00m", "26 9h00m", "10h00m\n", "31 -", "Balance: -6h00m"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Calendar output missing %q:\n%s", want, output)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package grid
+
+import (
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/asdf8601/takt-go/pkg/report"
+	"github.com/asdf8601/takt-go/pkg/takt"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	// Image grid geometry in pixels
+	CellSize    = 11
+	CellStep    = 14
+	LabelMargin = 30
+	LegendStep  = 64
+
+	LayoutHorizontal = "horizontal"
+	LayoutVertical   = "vertical"
+)
+
+var (
+	// hourColors mirror the terminal grid colors, indexed by level.
+	hourColors = [...]color.RGBA{
+		{0xeb, 0xed, 0xf0, 0xff}, // gray: minimal
+		{0xf5, 0xd7, 0x6e, 0xff}, // yellow: light
+		{0x40, 0xc4, 0x63, 0xff}, // green: normal
+		{0xfb, 0x8c, 0x00, 0xff}, // orange: heavy
+		{0xe5, 0x39, 0x35, 0xff}, // red: very heavy
+	}
+
+	// balanceColors are indexed by BalanceLevel.
+	balanceColors = [...]color.RGBA{
+		{0xeb, 0xed, 0xf0, 0xff}, // gray: no work
+		{0xcf, 0x22, 0x2e, 0xff}, // red: well under target
+		{0xfb, 0x8c, 0x00, 0xff}, // orange: under target
+		{0x40, 0xc4, 0x63, 0xff}, // green: on target
+		{0x09, 0x69, 0xda, 0xff}, // blue: over target
+	}
+	balanceLegend = [...]string{"none", "<-2h", "<-30m", "+-30m", ">+30m"}
+
+	textColor = color.RGBA{0x65, 0x6d, 0x76, 0xff}
+)
+
+// BalanceLevel returns the color level of a day by its balance against the
+// target hours: 0 no work, 1 well under, 2 under, 3 on target, 4 over.
+func BalanceLevel(hours, target float64) int {
+	if hours <= 0 {
+		return 0
+	}
+	diff := hours - target
+	switch {
+	case diff < -2:
+		return 1
+	case diff < -0.5:
+		return 2
+	case diff <= 0.5:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// Cell is a colored day square.
+type Cell struct {
+	X, Y  int
+	Color color.RGBA
+	Title string
+}
+
+// Label is a piece of text anchored at its baseline.
+type Label struct {
+	X, Y int
+	Text string
+}
+
+// Image is a device-independent drawing of the activity grid that can be
+// rendered as SVG or PNG.
+type Image struct {
+	Width, Height int
+	Cells         []Cell
+	Labels        []Label
+}
+
+// NewImage lays out days either horizontally (weeks as columns, like
+// GitHub's contribution graph) or vertically (weeks as rows, like the
+// terminal grid). With balance, days are colored by their balance against
+// targetHours instead of by their level in th.
+func NewImage(days []Day, layout string, balance bool, targetHours float64, th Thresholds) (*Image, error) {
+	if len(days) == 0 {
+		return nil, fmt.Errorf("no days to draw")
+	}
+	if layout != LayoutHorizontal && layout != LayoutVertical {
+		return nil, fmt.Errorf("unsupported layout: %s (must be '%s' or '%s')", layout, LayoutHorizontal, LayoutVertical)
+	}
+
+	img := &Image{}
+	horizontal := layout == LayoutHorizontal
+	// place maps (week, weekday) to pixel coordinates for the layout.
+	place := func(week, weekday int) (int, int) {
+		if horizontal {
+			return LabelMargin + week*CellStep, LabelMargin + weekday*CellStep
+		}
+		return LabelMargin + weekday*CellStep, LabelMargin + week*CellStep
+	}
+
+	for i, name := range []string{"Mon", "", "Wed", "", "Fri", "", ""} {
+		if !horizontal {
+			x, _ := place(0, i)
+			img.Labels = append(img.Labels, Label{x + 2, LabelMargin - 6, "MTWTFSS"[i : i+1]})
+		} else if name != "" {
+			_, y := place(0, i)
+			img.Labels = append(img.Labels, Label{0, y + CellSize - 1, name})
+		}
+	}
+
+	offset := (int(days[0].Date.Weekday()) + 6) % 7 // Monday first
+	weeks := 0
+	for i, day := range days {
+		week, weekday := (i+offset)/7, (i+offset)%7
+		weeks = week + 1
+		x, y := place(week, weekday)
+
+		fill := hourColors[th.Level(day.Hours)]
+		if balance {
+			fill = balanceColors[BalanceLevel(day.Hours, targetHours)]
+		}
+		title := fmt.Sprintf("%s: %s", day.Date.Format(takt.DateFormat), report.HoursToText(day.Hours))
+		img.Cells = append(img.Cells, Cell{x, y, fill, title})
+
+		if i == 0 || day.Date.Day() == 1 {
+			month := day.Date.Format("Jan")
+			if horizontal {
+				img.Labels = append(img.Labels, Label{x, LabelMargin - 6, month})
+			} else {
+				img.Labels = append(img.Labels, Label{0, y + CellSize - 1, month})
+			}
+		}
+	}
+
+	gridWidth, gridHeight := place(weeks, 7)
+
+	colors, legend := hourColors, th.Labels()
+	if balance {
+		colors, legend = balanceColors, balanceLegend
+	}
+	legendY := gridHeight + CellStep
+	for i := range colors {
+		x := LabelMargin + i*LegendStep
+		img.Cells = append(img.Cells, Cell{x, legendY, colors[i], legend[i]})
+		img.Labels = append(img.Labels, Label{x + CellStep, legendY + CellSize - 1, legend[i]})
+	}
+
+	img.Width = max(gridWidth, LabelMargin+len(colors)*LegendStep) + CellStep
+	img.Height = legendY + CellSize + CellStep
+	return img, nil
+}
+
+// WriteSVG renders the image as SVG.
+func (g *Image) WriteSVG(w io.Writer) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		g.Width, g.Height, g.Width, g.Height)
+	fmt.Fprintf(&sb, `<rect width="100%%" height="100%%" fill="#ffffff"/>`+"\n")
+	for _, c := range g.Cells {
+		fmt.Fprintf(&sb, `<rect x="%d" y="%d" width="%d" height="%d" rx="2" fill="%s"><title>%s</title></rect>`+"\n",
+			c.X, c.Y, CellSize, CellSize, hexColor(c.Color), html.EscapeString(c.Title))
+	}
+	for _, l := range g.Labels {
+		fmt.Fprintf(&sb, `<text x="%d" y="%d" font-family="monospace" font-size="10" fill="%s">%s</text>`+"\n",
+			l.X, l.Y, hexColor(textColor), html.EscapeString(l.Text))
+	}
+	sb.WriteString("</svg>\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// WritePNG renders the image as PNG.
+func (g *Image) WritePNG(w io.Writer) error {
+	img := image.NewRGBA(image.Rect(0, 0, g.Width, g.Height))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	for _, c := range g.Cells {
+		rect := image.Rect(c.X, c.Y, c.X+CellSize, c.Y+CellSize)
+		draw.Draw(img, rect, image.NewUniform(c.Color), image.Point{}, draw.Src)
+	}
+	drawer := &font.Drawer{Dst: img, Src: image.NewUniform(textColor), Face: basicfont.Face7x13}
+	for _, l := range g.Labels {
+		drawer.Dot = fixed.P(l.X, l.Y)
+		drawer.DrawString(l.Text)
+	}
+	return png.Encode(w, img)
+}
+
+// hexColor formats c as #rrggbb.
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// WriteFile writes the image to fileName, as PNG or SVG depending on asPNG.
+func (g *Image) WriteFile(fileName string, asPNG bool) (err error) {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", fileName, err)
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	if asPNG {
+		return g.WritePNG(file)
+	}
+	return g.WriteSVG(file)
+}
@@ -0,0 +1,107 @@
+package grid
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testDays returns n consecutive days starting on start with the given hours.
+func testDays(start time.Time, hours ...float64) []Day {
+	days := make([]Day, len(hours))
+	for i, h := range hours {
+		days[i] = Day{Date: start.AddDate(0, 0, i), Hours: h}
+	}
+	return days
+}
+
+var testThresholds = DefaultThresholds(8.0)
+
+func TestBalanceLevel(t *testing.T) {
+	tests := []struct {
+		hours    float64
+		expected int
+	}{
+		{0, 0},
+		{5, 1},
+		{7, 2},
+		{8.25, 3},
+		{10, 4},
+	}
+	for _, tt := range tests {
+		if got := BalanceLevel(tt.hours, 8.0); got != tt.expected {
+			t.Errorf("BalanceLevel(%v, 8) = %d, want %d", tt.hours, got, tt.expected)
+		}
+	}
+}
+
+func TestNewImageLayout(t *testing.T) {
+	// 2025-01-01 is a Wednesday
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	days := testDays(start, 0, 2, 5, 9, 13, 0)
+
+	img, err := NewImage(days, LayoutHorizontal, false, 8.0, testThresholds)
+	if err != nil {
+		t.Fatalf("NewImage() failed: %v", err)
+	}
+	first := img.Cells[0]
+	if first.X != LabelMargin || first.Y != LabelMargin+2*CellStep {
+		t.Errorf("Wednesday should be in the first column, third row, got (%d, %d)", first.X, first.Y)
+	}
+	sixth := img.Cells[5] // Monday of the second week
+	if sixth.X != LabelMargin+CellStep || sixth.Y != LabelMargin {
+		t.Errorf("Monday should start the second column, got (%d, %d)", sixth.X, sixth.Y)
+	}
+	for i, want := range []int{0, 1, 2, 3, 4} {
+		if img.Cells[i].Color != hourColors[want] {
+			t.Errorf("Cell %d color = %v, want level %d", i, img.Cells[i].Color, want)
+		}
+	}
+
+	vertical, err := NewImage(days, LayoutVertical, true, 8.0, testThresholds)
+	if err != nil {
+		t.Fatalf("NewImage() failed: %v", err)
+	}
+	if vertical.Cells[0].X != LabelMargin+2*CellStep || vertical.Cells[0].Y != LabelMargin {
+		t.Errorf("Wednesday should be in the third column, first row, got (%d, %d)", vertical.Cells[0].X, vertical.Cells[0].Y)
+	}
+	if vertical.Cells[3].Color != balanceColors[4] {
+		t.Errorf("9h day should be colored as over target, got %v", vertical.Cells[3].Color)
+	}
+
+	if _, err := NewImage(days, "diagonal", false, 8.0, testThresholds); err == nil {
+		t.Error("Expected error for unsupported layout")
+	}
+}
+
+func TestImageRender(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	img, err := NewImage(testDays(start, 1, 2, 3), LayoutHorizontal, false, 8.0, testThresholds)
+	if err != nil {
+		t.Fatalf("NewImage() failed: %v", err)
+	}
+
+	var svg bytes.Buffer
+	if err := img.WriteSVG(&svg); err != nil {
+		t.Fatalf("WriteSVG() failed: %v", err)
+	}
+	for _, want := range []string{"<svg", ">Jan<", ">Mon<", "2025-01-02: 2h00m"} {
+		if !strings.Contains(svg.String(), want) {
+			t.Errorf("SVG output missing %q", want)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := img.WritePNG(&buf); err != nil {
+		t.Fatalf("WritePNG() failed: %v", err)
+	}
+	decoded, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("Invalid PNG: %v", err)
+	}
+	if decoded.Bounds().Dx() != img.Width || decoded.Bounds().Dy() != img.Height {
+		t.Errorf("PNG size = %v, want %dx%d", decoded.Bounds(), img.Width, img.Height)
+	}
+}
@@ -0,0 +1,102 @@
+package grid
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/asdf8601/takt-go/pkg/ansi"
+)
+
+const (
+	// Symbol sets
+	SymbolsNerdFont = "nerd-font"
+	SymbolsUnicode  = "unicode"
+	SymbolsASCII    = "ascii"
+
+	// Nerd font symbols
+	SymbolMinimal   = "󰋣 " // 0-1 hours
+	SymbolLight     = "▪ " // 1-4 hours
+	SymbolNormal    = "▮ " // 4-8 hours
+	SymbolHeavy     = "󰈸 " // 8-12 hours
+	SymbolVeryHeavy = "󰯆 " // 12+ hours
+)
+
+// LevelColors are the ANSI colors of each level.
+var LevelColors = [...]string{ansi.Gray, ansi.Yellow, ansi.Green, ansi.Orange, ansi.Red}
+
+// levelNames describe each level in the legend.
+var levelNames = [...]string{"Minimal work", "Light work", "Normal work", "Heavy work", "Very heavy work"}
+
+// Style holds the glyphs and coloring used to draw the terminal grid.
+type Style struct {
+	Symbols    [5]string
+	Color      bool
+	Rule       string // under the header
+	Separator  string // between months
+	Branch     string // summary/legend item
+	LastBranch string // last summary/legend item
+	Summary    string // summary title
+	Legend     string // legend title
+}
+
+// styles are the built-in symbol sets. Each symbol is two columns wide.
+var styles = map[string]Style{
+	SymbolsNerdFont: {
+		Symbols:    [5]string{SymbolMinimal, SymbolLight, SymbolNormal, SymbolHeavy, SymbolVeryHeavy},
+		Rule:       "═",
+		Separator:  "─",
+		Branch:     "├─",
+		LastBranch: "└─",
+		Summary:    "📊 Summary:",
+		Legend:     "🎨 Legend:",
+	},
+	SymbolsUnicode: {
+		Symbols:    [5]string{"· ", "░ ", "▒ ", "▓ ", "█ "},
+		Rule:       "═",
+		Separator:  "─",
+		Branch:     "├─",
+		LastBranch: "└─",
+		Summary:    "Summary:",
+		Legend:     "Legend:",
+	},
+	SymbolsASCII: {
+		Symbols:    [5]string{". ", "- ", "+ ", "* ", "# "},
+		Rule:       "=",
+		Separator:  "-",
+		Branch:     "|-",
+		LastBranch: "`-",
+		Summary:    "Summary:",
+		Legend:     "Legend:",
+	},
+}
+
+// NewStyle returns the style for a symbol set and color mode, coloring
+// stdout.
+func NewStyle(symbols, colorMode string) (Style, error) {
+	style, ok := styles[symbols]
+	if !ok {
+		return Style{}, fmt.Errorf("unknown symbol set: %s (must be '%s', '%s' or '%s')",
+			symbols, SymbolsNerdFont, SymbolsUnicode, SymbolsASCII)
+	}
+	color, err := ansi.UseColor(colorMode, os.Stdout)
+	if err != nil {
+		return Style{}, err
+	}
+	style.Color = color
+	return style, nil
+}
+
+// Paint wraps text in an ANSI color when coloring is enabled.
+func (s Style) Paint(code, text string) string {
+	return ansi.Paint(s.Color, code, text)
+}
+
+// Level returns the level of a symbol, or -1 for an empty cell.
+func (s Style) Level(symbol string) int {
+	for i, sym := range s.Symbols {
+		if sym == symbol {
+			return i
+		}
+	}
+	return -1
+}
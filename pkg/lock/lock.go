@@ -0,0 +1,216 @@
+// Package lock closes periods of a records file, such as a month submitted
+// to payroll. A lock keeps the SHA-256 hash of the records of its period,
+// so that changes made behind its back are detected, and Check refuses
+// changes inside locked periods.
+package lock
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/store"
+	"github.com/asdf8601/takt-go/pkg/takt"
+)
+
+// Suffix is appended to the file name of a records file for its locks.
+const Suffix = ".locks"
+
+// Header is the CSV header of a locks file.
+var Header = []string{"period", "from", "to", "hash", "locked_at"}
+
+// Lock is a closed period: the days from From to To, inclusive.
+type Lock struct {
+	Period   string
+	From, To time.Time
+	// Hash is the SHA-256 of the records of the period when locked.
+	Hash     string
+	LockedAt time.Time
+}
+
+// ParsePeriod returns the unlocked period of a day (2026-09-15), an ISO
+// week (2026-W37), a month (2026-09), a quarter (2026-Q3) or a year (2026),
+// in loc.
+func ParsePeriod(period string, loc *time.Location) (Lock, error) {
+	invalid := fmt.Errorf("invalid period %q: expected YYYY-MM-DD, YYYY-Www, YYYY-MM, YYYY-Qn or YYYY", period)
+	year, rest, _ := strings.Cut(period, "-")
+	y, err := strconv.Atoi(year)
+	if err != nil || len(year) != 4 {
+		return Lock{}, invalid
+	}
+	jan1 := time.Date(y, time.January, 1, 0, 0, 0, 0, loc)
+	l := Lock{Period: period}
+	switch {
+	case rest == "":
+		l.From, l.To = jan1, jan1.AddDate(1, 0, -1)
+	case strings.HasPrefix(rest, "W"):
+		week, err := strconv.Atoi(rest[1:])
+		if err != nil || len(rest) != 3 || week < 1 {
+			return Lock{}, invalid
+		}
+		// week 1 has January 4th
+		jan4 := jan1.AddDate(0, 0, 3)
+		l.From = jan4.AddDate(0, 0, -((int(jan4.Weekday())+6)%7)+7*(week-1))
+		if wy, _ := l.From.ISOWeek(); wy != y {
+			return Lock{}, invalid
+		}
+		l.To = l.From.AddDate(0, 0, 6)
+	case strings.HasPrefix(rest, "Q"):
+		quarter, err := strconv.Atoi(rest[1:])
+		if err != nil || quarter < 1 || quarter > 4 {
+			return Lock{}, invalid
+		}
+		l.From = jan1.AddDate(0, 3*(quarter-1), 0)
+		l.To = l.From.AddDate(0, 3, -1)
+	default:
+		if day, err := time.ParseInLocation(takt.DateFormat, period, loc); err == nil {
+			l.From, l.To = day, day
+		} else if month, err := time.ParseInLocation("2006-01", period, loc); err == nil {
+			l.From, l.To = month, month.AddDate(0, 1, -1)
+		} else {
+			return Lock{}, invalid
+		}
+	}
+	return l, nil
+}
+
+// Contains reports whether t falls on a day of the lock. The day is the date
+// of t in its own offset, as written in the records file, so a record
+// belongs to the same period whatever the local time zone.
+func (l Lock) Contains(t time.Time) bool {
+	day := t.Format(takt.DateFormat)
+	return day >= l.From.Format(takt.DateFormat) && day <= l.To.Format(takt.DateFormat)
+}
+
+// Overlaps reports whether the periods of l and other share a day.
+func (l Lock) Overlaps(other Lock) bool {
+	return !l.To.Before(other.From) && !other.To.Before(l.From)
+}
+
+// Sum returns the SHA-256 of the records of the period as CSV lines, in
+// sorted order so that reordering alone doesn't change it.
+func (l Lock) Sum(records []takt.Record) string {
+	var lines []string
+	for _, r := range records {
+		if !l.Contains(r.Timestamp) {
+			continue
+		}
+		var b strings.Builder
+		w := csv.NewWriter(&b)
+		_ = w.Write([]string{r.Timestamp.Format(takt.TimeFormat), r.Kind, r.Notes})
+		w.Flush()
+		lines = append(lines, b.String())
+	}
+	sort.Strings(lines)
+	sum := sha256.Sum256([]byte(strings.Join(lines, "")))
+	return hex.EncodeToString(sum[:])
+}
+
+// Count returns the number of records of the period.
+func (l Lock) Count(records []takt.Record) int {
+	n := 0
+	for _, r := range records {
+		if l.Contains(r.Timestamp) {
+			n++
+		}
+	}
+	return n
+}
+
+// Verify reports whether the records of the period are still those locked.
+func (l Lock) Verify(records []takt.Record) bool {
+	return l.Sum(records) == l.Hash
+}
+
+// Error is a change refused by a lock.
+type Error struct {
+	Lock Lock
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s is locked (%s to %s)", e.Lock.Period, e.Lock.From.Format(takt.DateFormat), e.Lock.To.Format(takt.DateFormat))
+}
+
+// ModifiedError is a change refused because the records of a locked
+// period no longer match its hash, as after an edit by hand.
+type ModifiedError struct {
+	Lock Lock
+}
+
+func (e *ModifiedError) Error() string {
+	return fmt.Sprintf("records of %s changed after locking (%s to %s)", e.Lock.Period, e.Lock.From.Format(takt.DateFormat), e.Lock.To.Format(takt.DateFormat))
+}
+
+// Check returns a *ModifiedError when the old records of a locked period
+// no longer match its hash, and an *Error when changing the records from
+// old to new changes any record of a locked period.
+func Check(locks []Lock, old, new []takt.Record) error {
+	for _, l := range locks {
+		if !l.Verify(old) {
+			return &ModifiedError{Lock: l}
+		}
+		if l.Sum(old) != l.Sum(new) {
+			return &Error{Lock: l}
+		}
+	}
+	return nil
+}
+
+// Read reads the locks of a locks file, in loc; a missing file has none.
+func Read(fileName string, loc *time.Location) ([]Lock, error) {
+	file, err := os.Open(fileName)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	lines, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("could not read locks: %w", err)
+	}
+
+	var locks []Lock
+	for i, line := range lines {
+		if i == 0 {
+			continue
+		}
+		if len(line) != len(Header) {
+			return nil, fmt.Errorf("%s:%d: expected %d fields", fileName, i+1, len(Header))
+		}
+		l := Lock{Period: line[0], Hash: line[3]}
+		var errs []error
+		l.From, err = time.ParseInLocation(takt.DateFormat, line[1], loc)
+		errs = append(errs, err)
+		l.To, err = time.ParseInLocation(takt.DateFormat, line[2], loc)
+		errs = append(errs, err)
+		l.LockedAt, err = time.Parse(takt.TimeFormat, line[4])
+		errs = append(errs, err)
+		if err := errors.Join(errs...); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", fileName, i+1, err)
+		}
+		locks = append(locks, l)
+	}
+	return locks, nil
+}
+
+// Write writes the locks to a locks file, atomically.
+func Write(fileName string, locks []Lock) error {
+	return store.ReplaceFile(fileName, func(out io.Writer) error {
+		w := csv.NewWriter(out)
+		_ = w.Write(Header)
+		for _, l := range locks {
+			_ = w.Write([]string{l.Period, l.From.Format(takt.DateFormat), l.To.Format(takt.DateFormat), l.Hash, l.LockedAt.Format(takt.TimeFormat)})
+		}
+		w.Flush()
+		return w.Error()
+	})
+}
@@ -0,0 +1,154 @@
+package lock
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/takt"
+)
+
+func TestParsePeriod(t *testing.T) {
+	tests := []struct {
+		period   string
+		from, to string
+	}{
+		{"2026-09-15", "2026-09-15", "2026-09-15"},
+		{"2026-W37", "2026-09-07", "2026-09-13"},
+		{"2026-W01", "2025-12-29", "2026-01-04"},
+		{"2026-W53", "2026-12-28", "2027-01-03"},
+		{"2026-09", "2026-09-01", "2026-09-30"},
+		{"2026-Q3", "2026-07-01", "2026-09-30"},
+		{"2026", "2026-01-01", "2026-12-31"},
+	}
+	for _, tt := range tests {
+		l, err := ParsePeriod(tt.period, time.UTC)
+		if err != nil {
+			t.Errorf("ParsePeriod(%q) failed: %v", tt.period, err)
+			continue
+		}
+		if got := l.From.Format(takt.DateFormat) + " " + l.To.Format(takt.DateFormat); got != tt.from+" "+tt.to {
+			t.Errorf("ParsePeriod(%q) = %s, expected %s %s", tt.period, got, tt.from, tt.to)
+		}
+	}
+
+	for _, period := range []string{"", "26-09", "2026-13", "2026-W00", "2025-W53", "2026-Q5", "2026-9", "september"} {
+		if _, err := ParsePeriod(period, time.UTC); err == nil {
+			t.Errorf("Expected an error for %q", period)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	at := func(month time.Month, day int) time.Time { return time.Date(2026, month, day, 9, 0, 0, 0, time.UTC) }
+	september, _ := ParsePeriod("2026-09", time.UTC)
+	records := []takt.Record{
+		{Timestamp: at(10, 1), Kind: takt.KindIn, Notes: "October"},
+		{Timestamp: at(9, 30).Add(8 * time.Hour), Kind: takt.KindOut},
+		{Timestamp: at(9, 30), Kind: takt.KindIn, Notes: "September"},
+	}
+	september.Hash = september.Sum(records)
+	locks := []Lock{september}
+
+	if september.Count(records) != 2 || !september.Verify(records) {
+		t.Errorf("Expected 2 verified records in %+v", september)
+	}
+	reordered := []takt.Record{records[0], records[2], records[1]}
+	if err := Check(locks, records, reordered); err != nil {
+		t.Errorf("Expected reordering to be allowed, got %v", err)
+	}
+	october := append([]takt.Record{{Timestamp: at(10, 1).Add(time.Hour), Kind: takt.KindOut}}, records...)
+	if err := Check(locks, records, october); err != nil {
+		t.Errorf("Expected a change outside the lock to be allowed, got %v", err)
+	}
+
+	edited := append([]takt.Record(nil), records...)
+	edited[2].Notes = "Edited"
+	var lockErr *Error
+	if err := Check(locks, records, edited); !errors.As(err, &lockErr) || lockErr.Lock.Period != "2026-09" {
+		t.Errorf("Expected a lock error, got %v", err)
+	} else if err.Error() != "2026-09 is locked (2026-09-01 to 2026-09-30)" {
+		t.Errorf("Unexpected message: %v", err)
+	}
+	if err := Check(locks, records, records[:1]); err == nil {
+		t.Error("Expected removing records of the lock to be refused")
+	}
+	if september.Verify(edited) {
+		t.Error("Expected the edited records not to verify")
+	}
+
+	// records changed by hand refuse any change, even outside the lock
+	var modifiedErr *ModifiedError
+	if err := Check(locks, edited, append([]takt.Record{october[0]}, edited...)); !errors.As(err, &modifiedErr) {
+		t.Errorf("Expected a modified error, got %v", err)
+	} else if err.Error() != "records of 2026-09 changed after locking (2026-09-01 to 2026-09-30)" {
+		t.Errorf("Unexpected message: %v", err)
+	}
+}
+
+func TestContainsAcrossOffsets(t *testing.T) {
+	plus1 := time.FixedZone("UTC+1", 3600)
+	september, _ := ParsePeriod("2026-09", plus1)
+	tests := []struct {
+		timestamp string
+		want      bool
+	}{
+		{"2026-09-30T23:30:00-05:00", true}, // October 1st in UTC+1
+		{"2026-09-01T00:30:00+02:00", true}, // August 31st in UTC+1
+		{"2026-10-01T00:30:00+02:00", false},
+		{"2026-08-31T23:30:00-05:00", false},
+	}
+	for _, tt := range tests {
+		ts, err := time.Parse(takt.TimeFormat, tt.timestamp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := september.Contains(ts); got != tt.want {
+			t.Errorf("Contains(%s) = %v, want %v", tt.timestamp, got, tt.want)
+		}
+	}
+
+	// a late check-in recorded in another offset stays locked
+	late, _ := time.Parse(takt.TimeFormat, "2026-09-30T23:30:00-05:00")
+	records := []takt.Record{{Timestamp: late, Kind: takt.KindIn, Notes: "late"}}
+	september.Hash = september.Sum(records)
+	edited := []takt.Record{{Timestamp: late, Kind: takt.KindIn, Notes: "edited"}}
+	var lockErr *Error
+	if err := Check([]Lock{september}, records, edited); !errors.As(err, &lockErr) {
+		t.Errorf("Expected a lock error, got %v", err)
+	}
+}
+
+func TestReadWrite(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "takt.csv"+Suffix)
+	if locks, err := Read(fileName, time.UTC); err != nil || locks != nil {
+		t.Fatalf("Expected no locks without a file, got %v, %v", locks, err)
+	}
+
+	l, _ := ParsePeriod("2026-W37", time.UTC)
+	l.Hash, l.LockedAt = "abc", time.Date(2026, 10, 2, 9, 15, 0, 0, time.UTC)
+	if err := Write(fileName, []Lock{l}); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	locks, err := Read(fileName, time.UTC)
+	if err != nil {
+		t.Fatalf("Read() failed: %v", err)
+	}
+	if len(locks) != 1 || locks[0].Period != l.Period || !locks[0].From.Equal(l.From) || !locks[0].To.Equal(l.To) ||
+		locks[0].Hash != "abc" || !locks[0].LockedAt.Equal(l.LockedAt) {
+		t.Errorf("Expected %+v, got %+v", l, locks)
+	}
+
+	if err := Write(fileName, nil); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	entries, err := os.ReadDir(filepath.Dir(fileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != filepath.Base(fileName) {
+		t.Errorf("Expected only the locks file after writing, got %v", entries)
+	}
+}
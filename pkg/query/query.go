@@ -0,0 +1,244 @@
+// Package query answers SQL queries over the time log. The records, their
+// sessions and the days they add up to are loaded into an in-memory SQLite
+// database (modernc.org/sqlite, in pure Go) as the tables described by
+// Schema; the records file itself is never written.
+package query
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/report"
+	"github.com/asdf8601/takt-go/pkg/takt"
+
+	_ "modernc.org/sqlite" // the sqlite driver
+)
+
+// Schema creates the tables of the database.
+const Schema = `
+CREATE TABLE records (
+	timestamp TEXT NOT NULL, -- RFC3339
+	kind      TEXT NOT NULL, -- in or out
+	notes     TEXT NOT NULL,
+	date      TEXT NOT NULL  -- YYYY-MM-DD
+);
+CREATE TABLE sessions (
+	start     TEXT,          -- RFC3339 of the check-in, NULL without one
+	"end"     TEXT,          -- RFC3339 of the check-out, NULL while open
+	date      TEXT NOT NULL, -- YYYY-MM-DD of the start (of the end without one)
+	week      TEXT NOT NULL, -- ISO week, 2025-W02
+	month     TEXT NOT NULL, -- 2025-01
+	weekday   TEXT NOT NULL, -- Mon ... Sun
+	hours     REAL NOT NULL, -- duration in hours, open sessions as in days
+	duration  TEXT NOT NULL, -- the same as 3h30m
+	note      TEXT NOT NULL, -- notes of the check-in
+	out_note  TEXT NOT NULL, -- notes of the check-out
+	project   TEXT,          -- first +project of the notes, without the +
+	projects  TEXT NOT NULL, -- all of them, comma-separated
+	tags      TEXT NOT NULL, -- #tags of the notes, comma-separated
+	open      INTEGER NOT NULL
+);
+CREATE TABLE days (
+	date      TEXT NOT NULL, -- YYYY-MM-DD
+	week      TEXT NOT NULL,
+	month     TEXT NOT NULL,
+	weekday   TEXT NOT NULL,
+	hours     REAL NOT NULL, -- as in the summaries, after rounding
+	raw_hours REAL NOT NULL, -- as recorded
+	target    REAL NOT NULL,
+	balance   REAL NOT NULL,
+	sessions  INTEGER NOT NULL
+);`
+
+// Session is a check-in paired with its check-out. A session without a
+// check-in has a zero Start; an open session has a zero End.
+type Session struct {
+	Start    time.Time
+	End      time.Time
+	Notes    string // notes of the check-in
+	OutNotes string // notes of the check-out
+}
+
+// day returns the time whose date the session has: its start, or its end
+// without one.
+func (s Session) day() time.Time {
+	if s.Start.IsZero() {
+		return s.End
+	}
+	return s.Start
+}
+
+// Tables are the contents of the tables.
+type Tables struct {
+	Records  []takt.Record
+	Sessions []Session
+	// Days are the day totals of report.Config.CalculateDuration.
+	Days []report.AggregatedRecord
+}
+
+// Result is the result of a query.
+type Result struct {
+	Columns []string
+	Rows    [][]any
+}
+
+// Run loads the tables into a new database and runs the query on it; c
+// tells the target hours and the end of open sessions.
+func Run(ctx context.Context, c report.Config, tables Tables, query string) (Result, error) {
+	if strings.TrimSpace(query) == "" {
+		return Result{}, errors.New("empty query")
+	}
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		return Result{}, err
+	}
+	defer db.Close()
+	// every connection to :memory: is a database of its own
+	db.SetMaxOpenConns(1)
+
+	if err := load(ctx, db, c, tables); err != nil {
+		return Result{}, fmt.Errorf("failed to load the tables: %w", err)
+	}
+	if _, err := db.ExecContext(ctx, "PRAGMA query_only = ON"); err != nil {
+		return Result{}, err
+	}
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return Result{}, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return Result{}, err
+	}
+	result := Result{Columns: columns}
+	for rows.Next() {
+		values := make([]any, len(columns))
+		pointers := make([]any, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return Result{}, err
+		}
+		for i, v := range values {
+			if b, ok := v.([]byte); ok {
+				values[i] = string(b)
+			}
+		}
+		result.Rows = append(result.Rows, values)
+	}
+	return result, rows.Err()
+}
+
+// load creates the tables and fills them in one transaction.
+func load(ctx context.Context, db *sql.DB, c report.Config, tables Tables) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if _, err := tx.ExecContext(ctx, Schema); err != nil {
+		return err
+	}
+
+	insert := func(table string, columns int, rows func(add func(values ...any) error) error) error {
+		stmt, err := tx.PrepareContext(ctx, fmt.Sprintf("INSERT INTO %s VALUES (%s)",
+			table, strings.TrimSuffix(strings.Repeat("?, ", columns), ", ")))
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		return rows(func(values ...any) error {
+			_, err := stmt.ExecContext(ctx, values...)
+			return err
+		})
+	}
+
+	err = insert("records", 4, func(add func(...any) error) error {
+		for _, r := range tables.Records {
+			if err := add(r.Timestamp.Format(time.RFC3339), r.Kind, r.Notes, r.Timestamp.Format("2006-01-02")); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	perDay := map[string]int{}
+	err = insert("sessions", 14, func(add func(...any) error) error {
+		for _, s := range tables.Sessions {
+			day := s.day()
+			date := day.Format("2006-01-02")
+			perDay[date]++
+			hours := 0.0
+			if !s.Start.IsZero() {
+				end, counted := s.End, true
+				if end.IsZero() {
+					// as in the days, by the open session policy
+					end, counted = c.CloseOpen(takt.Record{Timestamp: s.Start, Kind: takt.KindIn})
+				}
+				if counted {
+					hours = end.Sub(s.Start).Hours()
+				}
+			}
+			notes := s.Notes + " " + s.OutNotes
+			projects := takt.Projects(notes)
+			var project any
+			if len(projects) > 0 {
+				project = projects[0]
+			}
+			err := add(timeValue(s.Start), timeValue(s.End), date, week(day), day.Format("2006-01"),
+				day.Format("Mon"), hours, report.HoursToText(hours), s.Notes, s.OutNotes, project,
+				strings.Join(projects, ","), strings.Join(takt.Tags(notes), ","), s.End.IsZero())
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = insert("days", 9, func(add func(...any) error) error {
+		for _, d := range tables.Days {
+			day, err := time.Parse("2006-01-02", d.Group)
+			if err != nil {
+				return err
+			}
+			balance := c.Balance(d)
+			err = add(d.Group, week(day), day.Format("2006-01"), day.Format("Mon"),
+				d.TotalHours, d.RawHours, d.TotalHours-balance, balance, perDay[d.Group])
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// timeValue returns t in RFC3339, or NULL when zero.
+func timeValue(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.Format(time.RFC3339)
+}
+
+// week returns the ISO week of t, as in the week summaries.
+func week(t time.Time) string {
+	year, week := t.ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
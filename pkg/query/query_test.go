@@ -0,0 +1,171 @@
+package query
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/report"
+	"github.com/asdf8601/takt-go/pkg/takt"
+)
+
+func testTables(t *testing.T) (report.Config, Tables) {
+	t.Helper()
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2025, 1, day, hour, minute, 0, 0, time.UTC)
+	}
+	records := []takt.Record{
+		{Timestamp: at(7, 13, 0), Kind: takt.KindIn, Notes: "Support +beta"},
+		{Timestamp: at(7, 12, 0), Kind: takt.KindOut},
+		{Timestamp: at(7, 9, 0), Kind: takt.KindIn, Notes: "Review +acme #meeting"},
+		{Timestamp: at(6, 17, 30), Kind: takt.KindOut, Notes: "Done"},
+		{Timestamp: at(6, 9, 0), Kind: takt.KindIn, Notes: "Build +acme"},
+	}
+	c := report.Config{TargetHours: 8, Clock: takt.FixedClock(at(7, 15, 0))}
+	days, err := c.CalculateDuration(records, report.PeriodDay)
+	if err != nil {
+		t.Fatalf("CalculateDuration() failed: %v", err)
+	}
+	return c, Tables{
+		Records: records,
+		Sessions: []Session{
+			{Start: at(7, 13, 0), Notes: "Support +beta"},
+			{Start: at(7, 9, 0), End: at(7, 12, 0), Notes: "Review +acme #meeting"},
+			{Start: at(6, 9, 0), End: at(6, 17, 30), Notes: "Build +acme", OutNotes: "Done"},
+		},
+		Days: days,
+	}
+}
+
+func TestRun(t *testing.T) {
+	c, tables := testTables(t)
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{
+			"SELECT count(*) AS n, min(kind) AS kind FROM records",
+			"n  kind\n5  in\n",
+		},
+		{
+			"SELECT project, sum(hours) AS hours, max(tags) AS tags FROM sessions GROUP BY project ORDER BY project",
+			"project  hours  tags\nacme      11.5  meeting\nbeta         2\n",
+		},
+		{
+			`SELECT date, week, weekday, duration, "end", open FROM sessions ORDER BY start`,
+			"date        week      weekday  duration  end                   open\n" +
+				"2025-01-06  2025-W02  Mon      8h30m     2025-01-06T17:30:00Z     0\n" +
+				"2025-01-07  2025-W02  Tue      3h00m     2025-01-07T12:00:00Z     0\n" +
+				"2025-01-07  2025-W02  Tue      2h00m                              1\n",
+		},
+		{
+			"SELECT date, hours, target, balance, sessions FROM days ORDER BY date",
+			"date        hours  target  balance  sessions\n" +
+				"2025-01-06    8.5       8      0.5         1\n" +
+				"2025-01-07      5       8       -3         2\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			result, err := Run(context.Background(), c, tables, tt.query)
+			if err != nil {
+				t.Fatalf("Run() failed: %v", err)
+			}
+			var b bytes.Buffer
+			if err := result.Write(&b, FormatTable); err != nil {
+				t.Fatalf("Write() failed: %v", err)
+			}
+			if b.String() != tt.expected {
+				t.Errorf("Expected\n%s\ngot\n%s", tt.expected, b.String())
+			}
+		})
+	}
+}
+
+func TestRunOpenPolicy(t *testing.T) {
+	c, tables := testTables(t)
+	// the next morning, with the session of the 7th still open
+	c.Clock = takt.FixedClock(time.Date(2025, 1, 8, 9, 0, 0, 0, time.UTC))
+	tests := []struct {
+		policy   string
+		expected string
+	}{
+		{"now", "hours  day\n   20   23\n"},
+		{"cap:1h", "hours  day\n    1    4\n"},
+		{"exclude", "hours  day\n    0    3\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			policy, err := report.ParseOpenPolicy(tt.policy)
+			if err != nil {
+				t.Fatalf("ParseOpenPolicy() failed: %v", err)
+			}
+			c.OpenPolicy = policy
+			if tables.Days, err = c.CalculateDuration(tables.Records, report.PeriodDay); err != nil {
+				t.Fatalf("CalculateDuration() failed: %v", err)
+			}
+			result, err := Run(context.Background(), c, tables,
+				"SELECT s.hours, d.hours AS day FROM sessions s JOIN days d USING (date) WHERE s.open")
+			if err != nil {
+				t.Fatalf("Run() failed: %v", err)
+			}
+			var b bytes.Buffer
+			if err := result.Write(&b, FormatTable); err != nil {
+				t.Fatalf("Write() failed: %v", err)
+			}
+			if b.String() != tt.expected {
+				t.Errorf("Expected\n%s\ngot\n%s", tt.expected, b.String())
+			}
+		})
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	c, tables := testTables(t)
+	for _, query := range []string{"", "SELEC 1", "SELECT * FROM nothing", "DELETE FROM records"} {
+		if _, err := Run(context.Background(), c, tables, query); err == nil {
+			t.Errorf("Expected an error for %q", query)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	result := Result{
+		Columns: []string{"project", "hours", "note"},
+		Rows:    [][]any{{"acme", 11.5, `Review, "final"`}, {nil, int64(2), ""}},
+	}
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{FormatCSV, "project,hours,note\nacme,11.5,\"Review, \"\"final\"\"\"\n,2,\n"},
+		{FormatJSON, `[
+  {
+    "project": "acme",
+    "hours": 11.5,
+    "note": "Review, \"final\""
+  },
+  {
+    "project": null,
+    "hours": 2,
+    "note": ""
+  }
+]
+`},
+	}
+	for _, tt := range tests {
+		var b bytes.Buffer
+		if err := result.Write(&b, tt.format); err != nil {
+			t.Fatalf("Write(%s) failed: %v", tt.format, err)
+		}
+		if b.String() != tt.expected {
+			t.Errorf("Expected %s\n%s\ngot\n%s", tt.format, tt.expected, b.String())
+		}
+	}
+	var out bytes.Buffer
+	if err := result.Write(&out, "xml"); err == nil || !strings.Contains(err.Error(), "unsupported format") {
+		t.Errorf("Expected an unsupported format error, got %v", err)
+	}
+}
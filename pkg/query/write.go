@@ -0,0 +1,153 @@
+package query
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Result formats
+const (
+	FormatTable = "table"
+	FormatCSV   = "csv"
+	FormatJSON  = "json"
+)
+
+// Write writes the result in format.
+func (r Result) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatTable:
+		return r.WriteTable(w)
+	case FormatCSV:
+		return r.WriteCSV(w)
+	case FormatJSON:
+		return r.WriteJSON(w)
+	}
+	return fmt.Errorf("unsupported format: %s (must be %s, %s or %s)", format, FormatTable, FormatCSV, FormatJSON)
+}
+
+// text formats a value for the table and CSV formats: NULL is empty and
+// reals drop trailing zeros.
+func text(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	}
+	return fmt.Sprint(v)
+}
+
+// WriteTable writes the result as aligned columns under a header, numbers
+// aligned right.
+func (r Result) WriteTable(w io.Writer) error {
+	widths := make([]int, len(r.Columns))
+	numeric := make([]bool, len(r.Columns))
+	for i, column := range r.Columns {
+		widths[i] = utf8.RuneCountInString(column)
+		numeric[i] = len(r.Rows) > 0
+	}
+	cells := make([][]string, len(r.Rows))
+	for i, row := range r.Rows {
+		cells[i] = make([]string, len(row))
+		for j, v := range row {
+			cells[i][j] = strings.ReplaceAll(text(v), "\n", " ")
+			widths[j] = max(widths[j], utf8.RuneCountInString(cells[i][j]))
+			switch v.(type) {
+			case int64, float64, nil:
+			default:
+				numeric[j] = false
+			}
+		}
+	}
+
+	var b strings.Builder
+	line := func(values []string) {
+		var fields []string
+		for i, v := range values {
+			pad := strings.Repeat(" ", widths[i]-utf8.RuneCountInString(v))
+			if numeric[i] {
+				fields = append(fields, pad+v)
+			} else {
+				fields = append(fields, v+pad)
+			}
+		}
+		b.WriteString(strings.TrimRight(strings.Join(fields, "  "), " ") + "\n")
+	}
+	line(r.Columns)
+	for _, row := range cells {
+		line(row)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteCSV writes the result as CSV with a header.
+func (r Result) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(r.Columns); err != nil {
+		return err
+	}
+	for _, row := range r.Rows {
+		record := make([]string, len(row))
+		for i, v := range row {
+			record[i] = text(v)
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// object is a row as a JSON object, with its keys in the order of the
+// columns.
+type object struct {
+	columns []string
+	values  []any
+}
+
+func (o object) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, column := range o.columns {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, err := json.Marshal(column)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(o.values[i])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// WriteJSON writes the result as a JSON array of objects keyed by column.
+func (r Result) WriteJSON(w io.Writer) error {
+	objects := make([]object, len(r.Rows))
+	for i, row := range r.Rows {
+		objects[i] = object{columns: r.Columns, values: row}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(objects)
+}
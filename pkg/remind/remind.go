@@ -0,0 +1,130 @@
+// Package remind decides which reminders of takt daemon are due: not
+// checked in by a time on a workday, the target reached, a long day or a
+// long break.
+package remind
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/report"
+	"github.com/asdf8601/takt-go/pkg/takt"
+)
+
+const (
+	// Reminder rules
+	CheckInBy = "checkin-by" // not checked in by a time on a workday
+	Target    = "target"     // target hours reached today
+	Over      = "over"       // worked over a limit today
+	Break     = "break"      // on a break for over a limit
+
+	Default = "checkin-by=09:30,target,over=10h,break=1h"
+)
+
+// Rule is a rule of takt daemon's reminders.
+type Rule struct {
+	Name  string
+	At    time.Duration // time of day for checkin-by
+	Limit time.Duration // for over and break
+}
+
+// Notification is a reminder sent to the sinks.
+type Notification struct {
+	Rule    string    `json:"rule"`
+	Title   string    `json:"title"`
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+
+	// Key identifies the occurrence, so that it is sent once
+	Key string `json:"-"`
+}
+
+// Parse parses comma-separated rules: checkin-by=HH:MM, target,
+// over=DURATION and break=DURATION, or none.
+func Parse(value string) ([]Rule, error) {
+	if value == "none" {
+		return nil, nil
+	}
+	var rules []Rule
+	for _, part := range strings.Split(value, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(part), "=")
+		rule := Rule{Name: name}
+		var err error
+		switch name {
+		case CheckInBy:
+			rule.At, err = takt.ParseTimeOfDay(arg)
+		case Target:
+			if arg != "" {
+				err = fmt.Errorf("%s takes no value", name)
+			}
+		case Over, Break:
+			rule.Limit, err = time.ParseDuration(arg)
+			if err == nil && rule.Limit <= 0 {
+				err = errors.New("must be positive")
+			}
+		default:
+			err = fmt.Errorf("unsupported rule (must be %s=HH:MM, %s, %s=DURATION, %s=DURATION or none)",
+				CheckInBy, Target, Over, Break)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid reminder %q: %w", part, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// Due returns the notifications of the rules for records (newest first) at
+// now.
+func Due(c report.Config, rules []Rule, records []takt.Record, now time.Time) []Notification {
+	today := now.Format(takt.DateFormat)
+	checkedIn := len(records) > 0 && records[0].Kind == takt.KindIn
+
+	worked := 0.0
+	if days, err := c.CalculateDuration(records, report.PeriodDay); err == nil && len(days) > 0 && days[0].Group == today {
+		worked = days[0].TotalHours
+	}
+	checkedInToday := false
+	for _, r := range records {
+		if r.Timestamp.Format(takt.DateFormat) != today {
+			break
+		}
+		if r.Kind == takt.KindIn {
+			checkedInToday = true
+		}
+	}
+
+	var due []Notification
+	add := func(rule, key, title, message string) {
+		due = append(due, Notification{Rule: rule, Title: title, Message: message, Time: now, Key: rule + "/" + key})
+	}
+	for _, rule := range rules {
+		switch rule.Name {
+		case CheckInBy:
+			by := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).Add(rule.At)
+			if c.Schedule.IsWorkday(now) && !now.Before(by) && !checkedInToday {
+				add(rule.Name, today, "Not checked in", fmt.Sprintf("Not checked in by %s", by.Format("15:04")))
+			}
+		case Target:
+			if checkedIn && worked >= c.TargetHours {
+				add(rule.Name, today, "Target reached", fmt.Sprintf("Target of %s reached", report.HoursToText(c.TargetHours)))
+			}
+		case Over:
+			if checkedIn && worked >= rule.Limit.Hours() {
+				add(rule.Name, today, "Long day", fmt.Sprintf("Over %s today", report.HoursToText(rule.Limit.Hours())))
+			}
+		case Break:
+			if len(records) == 0 || checkedIn {
+				continue
+			}
+			out := records[0].Timestamp
+			if out.Format(takt.DateFormat) == today && now.Before(c.Schedule.EndOfDay(now)) && now.Sub(out) >= rule.Limit {
+				add(rule.Name, out.Format(takt.TimeFormat), "Still on a break",
+					fmt.Sprintf("On a break since %s, over %s", out.Format("15:04"), report.HoursToText(rule.Limit.Hours())))
+			}
+		}
+	}
+	return due
+}
@@ -0,0 +1,48 @@
+package remind
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/report"
+	"github.com/asdf8601/takt-go/pkg/takt"
+)
+
+func TestParse(t *testing.T) {
+	rules, err := Parse(Default)
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+	if len(rules) != 4 || rules[0].At.String() != "9h30m0s" || rules[2].Limit.String() != "10h0m0s" || rules[3].Limit.String() != "1h0m0s" {
+		t.Errorf("Parse() = %+v", rules)
+	}
+	if rules, err := Parse("none"); err != nil || rules != nil {
+		t.Errorf("Parse(none) = %v, %v", rules, err)
+	}
+	for _, value := range []string{"", "target=8h", "over", "over=-1h", "checkin-by=late", "lunch"} {
+		if _, err := Parse(value); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", value)
+		}
+	}
+}
+
+func TestDue(t *testing.T) {
+	at := func(hour, minute int) time.Time { return time.Date(2025, 1, 9, hour, minute, 0, 0, time.UTC) } // Thursday
+	c := report.Config{TargetHours: 8, Schedule: takt.Schedule{DayEnd: takt.DefaultDayEnd}, Clock: takt.FixedClock(at(17, 30))}
+	rules, _ := Parse(Default)
+	records := []takt.Record{
+		{Timestamp: at(9, 0), Kind: takt.KindIn},
+	}
+
+	var got []string
+	for _, n := range Due(c, rules, records, at(17, 30)) {
+		got = append(got, n.Key+" "+n.Message)
+	}
+	if want := "target/2025-01-09 Target of 8h00m reached"; strings.Join(got, "\n") != want {
+		t.Errorf("Due() = %q, want %q", got, want)
+	}
+	if due := Due(c, rules, nil, at(10, 0)); len(due) != 1 || due[0].Rule != CheckInBy {
+		t.Errorf("Expected the check-in reminder, got %+v", due)
+	}
+}
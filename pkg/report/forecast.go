@@ -0,0 +1,114 @@
+package report
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/takt"
+)
+
+// Forecast is what is left to work to meet the targets of today and of the
+// period containing it.
+type Forecast struct {
+	Period   string    // label of the period, such as 2025-W02
+	From, To time.Time // first and last day of the period
+	Now      time.Time
+
+	Target float64 // hours of the period's workdays
+	Worked float64 // hours worked in the period so far
+	// Balance is the work of the days before today against their target.
+	Balance float64
+
+	TodayTarget float64 // zero on a day off
+	TodayWorked float64
+	// Since is the start of the open session, zero when checked out.
+	Since time.Time
+
+	// Rest are the workdays after today with their share of what is left
+	// once today's target is met.
+	Rest []PlannedDay
+}
+
+// PlannedDay is a day of a forecast with the hours to work on it.
+type PlannedDay struct {
+	Date  time.Time
+	Hours float64
+}
+
+// Remaining returns the hours left to meet the period's target.
+func (f Forecast) Remaining() float64 {
+	return max(f.Target-f.Worked, 0)
+}
+
+// TodayRemaining returns the hours left to meet today's target.
+func (f Forecast) TodayRemaining() float64 {
+	return max(f.TodayTarget-f.TodayWorked, 0)
+}
+
+// CheckedIn reports whether a session is open.
+func (f Forecast) CheckedIn() bool {
+	return !f.Since.IsZero()
+}
+
+// LeaveAt returns when to check out to have worked hours more, working on
+// from now.
+func (f Forecast) LeaveAt(hours float64) time.Time {
+	return f.Now.Add(time.Duration(hours * float64(time.Hour))).Truncate(time.Minute)
+}
+
+// Forecast forecasts the week or the month of now from records (newest
+// first), with the open session counted until now.
+func (c Config) Forecast(records []takt.Record, period string) (Forecast, error) {
+	if period != PeriodWeek && period != PeriodMonth {
+		return Forecast{}, fmt.Errorf("unsupported forecast period: %s (must be %s or %s)", period, PeriodWeek, PeriodMonth)
+	}
+	now := c.Now()
+	from, to, label, err := PeriodRange(period, now)
+	if err != nil {
+		return Forecast{}, err
+	}
+	f := Forecast{Period: label, From: from, To: to, Now: now}
+	if len(records) > 0 && records[0].Kind == takt.KindIn {
+		f.Since = records[0].Timestamp
+	}
+
+	worked := map[string]float64{}
+	if len(records) > 0 {
+		// the open session counts until now whatever the policy, since
+		// the forecast starts from now
+		c.OpenPolicy = OpenPolicy{Mode: OpenNow}
+		days, err := c.CalculateDuration(records, PeriodDay)
+		if err != nil {
+			return Forecast{}, err
+		}
+		for _, d := range days {
+			worked[d.Group] = d.TotalHours
+		}
+	}
+
+	today := now.Format(takt.DateFormat)
+	var restDays []time.Time
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		date := day.Format(takt.DateFormat)
+		target := 0.0
+		if c.Schedule.IsWorkday(day) {
+			target = c.TargetHours
+		}
+		f.Target += target
+		f.Worked += worked[date]
+		switch {
+		case date < today:
+			f.Balance += worked[date] - target
+		case date == today:
+			f.TodayTarget, f.TodayWorked = target, worked[date]
+		case target > 0:
+			restDays = append(restDays, day)
+		}
+	}
+
+	left := f.Remaining() - f.TodayRemaining()
+	for _, day := range restDays {
+		f.Rest = append(f.Rest, PlannedDay{Date: day, Hours: max(left, 0) / float64(len(restDays))})
+	}
+	return f, nil
+}
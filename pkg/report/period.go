@@ -0,0 +1,204 @@
+package report
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/takt"
+)
+
+// DefaultSprint is two weeks from Monday, 2024-01-01.
+var DefaultSprint = Sprint{Anchor: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Days: 14}
+
+// Sprint is a run of periods of Days days, one of which starts on Anchor.
+type Sprint struct {
+	Anchor time.Time
+	Days   int
+}
+
+// IsZero reports whether s is the zero Sprint.
+func (s Sprint) IsZero() bool {
+	return s.Anchor.IsZero() && s.Days == 0
+}
+
+// ParseSprint parses ANCHOR/LENGTH, such as 2025-01-06/2w or 2025-01-06/10d.
+// ANCHOR is the start date of any sprint, LENGTH is in days or weeks; either
+// may be left out for the default.
+func ParseSprint(value string) (Sprint, error) {
+	s := DefaultSprint
+	anchor, length, _ := strings.Cut(value, "/")
+	if anchor != "" {
+		t, err := time.Parse(takt.DateFormat, anchor)
+		if err != nil {
+			return Sprint{}, fmt.Errorf("invalid sprint anchor %q: expected YYYY-MM-DD", anchor)
+		}
+		s.Anchor = t
+	}
+	if length != "" {
+		unit := 1
+		switch {
+		case strings.HasSuffix(length, "w"):
+			unit = 7
+		case !strings.HasSuffix(length, "d"):
+			return Sprint{}, fmt.Errorf("invalid sprint length %q: expected days or weeks, such as 10d or 2w", length)
+		}
+		n, err := strconv.Atoi(length[:len(length)-1])
+		if err != nil || n <= 0 {
+			return Sprint{}, fmt.Errorf("invalid sprint length %q: expected days or weeks, such as 10d or 2w", length)
+		}
+		s.Days = n * unit
+	}
+	return s, nil
+}
+
+// String returns the sprint as parsed by ParseSprint.
+func (s Sprint) String() string {
+	if s.IsZero() {
+		s = DefaultSprint
+	}
+	length := strconv.Itoa(s.Days) + "d"
+	if s.Days%7 == 0 {
+		length = strconv.Itoa(s.Days/7) + "w"
+	}
+	return s.Anchor.Format(takt.DateFormat) + "/" + length
+}
+
+// Start returns the first day of the sprint of t.
+func (s Sprint) Start(t time.Time) time.Time {
+	if s.IsZero() {
+		s = DefaultSprint
+	}
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	anchor := time.Date(s.Anchor.Year(), s.Anchor.Month(), s.Anchor.Day(), 0, 0, 0, 0, time.UTC)
+	days := int(day.Sub(anchor).Hours() / 24)
+	n := days / s.Days
+	if days%s.Days < 0 {
+		n-- // floor for days before the anchor
+	}
+	return anchor.AddDate(0, 0, n*s.Days)
+}
+
+// ParseMonth parses a month as a number (4) or an English name (apr or
+// april).
+func ParseMonth(value string) (time.Month, error) {
+	if n, err := strconv.Atoi(value); err == nil && n >= 1 && n <= 12 {
+		return time.Month(n), nil
+	}
+	value = strings.ToLower(value)
+	for m := time.January; m <= time.December; m++ {
+		name := strings.ToLower(m.String())
+		if value == name || (len(value) == 3 && strings.HasPrefix(name, value)) {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid month %q: expected 1-12 or a name such as apr", value)
+}
+
+// fiscalYear returns the label of the fiscal year starting in month that
+// t falls in: FY2025 when it starts in January, FY2025-26 otherwise.
+func fiscalYear(t time.Time, start time.Month) string {
+	year := t.Year()
+	if t.Month() < start {
+		year--
+	}
+	if start <= time.January {
+		return fmt.Sprintf("FY%d", year)
+	}
+	return fmt.Sprintf("FY%d-%02d", year, (year+1)%100)
+}
+
+// Labeler returns the function that labels a time with its period, such as
+// 2025-01-09, 2025-W02, 2025-01, 2025-Q1, 2025, FY2025-26 or the first day
+// of its sprint. The period is an expression: day, week, month, quarter,
+// year, fiscal-year[:MONTH] (fiscal-year:apr) or sprint[:ANCHOR/LENGTH]
+// (sprint:2025-01-06/2w).
+func Labeler(period string) (func(time.Time) string, error) {
+	name, arg, hasArg := strings.Cut(period, ":")
+	if hasArg && name != PeriodFiscalYear && name != PeriodSprint {
+		return nil, fmt.Errorf("unsupported period: %s (%s takes no argument)", period, name)
+	}
+	switch name {
+	case PeriodDay:
+		return func(t time.Time) string {
+			return t.Format(takt.DateFormat)
+		}, nil
+	case PeriodWeek:
+		return func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-W%02d", year, week)
+		}, nil
+	case PeriodMonth:
+		return func(t time.Time) string {
+			return t.Format("2006-01")
+		}, nil
+	case PeriodQuarter:
+		return func(t time.Time) string {
+			return fmt.Sprintf("%d-Q%d", t.Year(), (int(t.Month())+2)/3)
+		}, nil
+	case PeriodYear:
+		return func(t time.Time) string {
+			return t.Format("2006")
+		}, nil
+	case PeriodFiscalYear:
+		start := time.January
+		if hasArg {
+			var err error
+			if start, err = ParseMonth(arg); err != nil {
+				return nil, err
+			}
+		}
+		return func(t time.Time) string {
+			return fiscalYear(t, start)
+		}, nil
+	case PeriodSprint:
+		sprint, err := ParseSprint(arg)
+		if err != nil {
+			return nil, err
+		}
+		return func(t time.Time) string {
+			return sprint.Start(t).Format(takt.DateFormat)
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported period: %s (must be %s, %s, %s, %s, %s, %s[:MONTH] or %s[:ANCHOR/LENGTH])",
+			period, PeriodDay, PeriodWeek, PeriodMonth, PeriodQuarter, PeriodYear, PeriodFiscalYear, PeriodSprint)
+	}
+}
+
+// Period returns the period expression with the configured fiscal year
+// start and sprint filled in, so that Labeler needs no Config.
+func (c Config) Period(period string) string {
+	switch {
+	case period == PeriodFiscalYear && c.FiscalYearStart > time.January:
+		return fmt.Sprintf("%s:%d", PeriodFiscalYear, c.FiscalYearStart)
+	case period == PeriodSprint && !c.Sprint.IsZero():
+		return PeriodSprint + ":" + c.Sprint.String()
+	}
+	return period
+}
+
+// longLabels reports whether the labels of period are longer than those of
+// weeks, months, quarters and years.
+func longLabels(period string) bool {
+	name, _, _ := strings.Cut(period, ":")
+	return name == PeriodFiscalYear || name == PeriodSprint
+}
+
+// PeriodRange returns the first and the last day of the day, week or month
+// of t, and its label.
+func PeriodRange(period string, t time.Time) (time.Time, time.Time, string, error) {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch period {
+	case PeriodDay:
+		return day, day, day.Format(takt.DateFormat), nil
+	case PeriodWeek:
+		from := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7)) // Monday
+		year, week := t.ISOWeek()
+		return from, from.AddDate(0, 0, 6), fmt.Sprintf("%d-W%02d", year, week), nil
+	case PeriodMonth:
+		from := day.AddDate(0, 0, 1-day.Day())
+		return from, from.AddDate(0, 1, -1), t.Format("2006-01"), nil
+	}
+	return time.Time{}, time.Time{}, "", fmt.Errorf("unsupported period: %s (must be %s, %s or %s)", period, PeriodDay, PeriodWeek, PeriodMonth)
+}
@@ -0,0 +1,556 @@
+// Package report aggregates takt records into daily, weekly, monthly and
+// yearly totals and formats their balance against the target hours.
+package report
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/takt"
+)
+
+// Periods supported by CalculateDuration, see Labeler
+const (
+	PeriodDay        = "day"
+	PeriodWeek       = "week"
+	PeriodMonth      = "month"
+	PeriodQuarter    = "quarter"
+	PeriodYear       = "year"
+	PeriodFiscalYear = "fiscal-year"
+	PeriodSprint     = "sprint"
+)
+
+// InferredNotes are the notes of the check-out inferred for an open session.
+const InferredNotes = "Inferred by takt."
+
+// Policies for open sessions, whose check-out is missing
+const (
+	OpenNow      = "now"        // count until now
+	OpenCap      = "cap"        // count until now, at most the cap
+	OpenEndOfDay = "end-of-day" // count until now, at most the end of its day
+	OpenExclude  = "exclude"    // don't count once stale
+)
+
+// Markers of the summary row with the open session, and of rows with
+// sessions shorter than the minimum
+const (
+	MarkerOpen     = "*"
+	MarkerExcluded = "!"
+	MarkerShort    = "~"
+)
+
+// Scopes of rounding
+const (
+	RoundPerSession = "session"
+	RoundPerDay     = "day"
+)
+
+// AggregatedRecord is the work done in a period.
+type AggregatedRecord struct {
+	Group        string
+	TotalHours   float64
+	Dates        []string
+	Notes        []string
+	AverageHours float64
+	// RawHours are the hours as recorded, before rounding and without
+	// dropping short sessions.
+	RawHours float64
+	// Short counts the sessions shorter than the minimum, which last
+	// ShortHours.
+	Short      int
+	ShortHours float64
+}
+
+// Config configures reports.
+type Config struct {
+	// TargetHours are the hours expected per working day.
+	TargetHours float64
+	// Clock closes an open session at its current time; nil means the
+	// system clock.
+	Clock takt.Clock
+	// Columns adds columns to summaries; nil adds none.
+	Columns ColumnsFunc
+	// Schedule ends working days for OpenEndOfDay and staleness.
+	Schedule takt.Schedule
+	// OpenPolicy decides how long an open session counts.
+	OpenPolicy OpenPolicy
+	// Rounding rounds each session, or each day's total with RoundPerDay.
+	Rounding Rounding
+	// RoundPer is RoundPerSession (when empty) or RoundPerDay.
+	RoundPer string
+	// MinSession drops or flags sessions shorter than its length.
+	MinSession MinSession
+	// FiscalYearStart is the first month of fiscal years; zero means
+	// January.
+	FiscalYearStart time.Month
+	// Sprint is the default of the sprint period; its zero value is
+	// DefaultSprint.
+	Sprint Sprint
+	// Notes prints the notes of each summary row under it.
+	Notes bool
+}
+
+// OpenPolicy decides how long an open session counts. Its zero value is
+// OpenNow.
+type OpenPolicy struct {
+	Mode string
+	// Cap is the longest an open session counts with OpenCap.
+	Cap time.Duration
+}
+
+// ParseOpenPolicy parses a policy: now, cap:DURATION (such as cap:10h),
+// end-of-day or exclude.
+func ParseOpenPolicy(value string) (OpenPolicy, error) {
+	switch value {
+	case "", OpenNow:
+		return OpenPolicy{Mode: OpenNow}, nil
+	case OpenEndOfDay, OpenExclude:
+		return OpenPolicy{Mode: value}, nil
+	}
+	if rest, ok := strings.CutPrefix(value, OpenCap+":"); ok {
+		limit, err := time.ParseDuration(rest)
+		if err != nil || limit <= 0 {
+			return OpenPolicy{}, fmt.Errorf("invalid cap %q: expected a positive duration such as 10h", rest)
+		}
+		return OpenPolicy{Mode: OpenCap, Cap: limit}, nil
+	}
+	return OpenPolicy{}, fmt.Errorf("unsupported open session policy: %s (must be %s, %s:DURATION, %s or %s)",
+		value, OpenNow, OpenCap, OpenEndOfDay, OpenExclude)
+}
+
+// String returns the policy as parsed by ParseOpenPolicy.
+func (p OpenPolicy) String() string {
+	switch p.Mode {
+	case "":
+		return OpenNow
+	case OpenCap:
+		return OpenCap + ":" + shortDuration(p.Cap)
+	}
+	return p.Mode
+}
+
+// Column is an extra summary column with a value per group.
+type Column struct {
+	Name   string
+	Values map[string]string
+}
+
+// ColumnsFunc returns extra columns for the rows of a summary, given the
+// records they aggregate.
+type ColumnsFunc func(period string, rows []AggregatedRecord, records []takt.Record) ([]Column, error)
+
+// Now returns the current time of the configured clock, the system clock
+// when none is set.
+func (c Config) Now() time.Time {
+	if c.Clock == nil {
+		return takt.SystemClock.Now()
+	}
+	return c.Clock.Now()
+}
+
+// CalculateDuration aggregates records (newest first) by period, newest
+// period first. An open session counts as the OpenPolicy says.
+func (c Config) CalculateDuration(records []takt.Record, period string) ([]AggregatedRecord, error) {
+	if len(records) == 0 {
+		return nil, errors.New("no records to process")
+	}
+
+	labeler, err := Labeler(c.Period(period))
+	if err != nil {
+		return nil, err
+	}
+
+	c.InferLastOut(&records)
+	aggregations := c.aggregate(records, labeler)
+	var out []AggregatedRecord
+	for _, k := range SortedKeys(aggregations) {
+		v := aggregations[k]
+		v.Dates = unique(v.Dates)
+		// a group of dropped sessions only has no days to average
+		if len(v.Dates) > 0 {
+			v.AverageHours = v.TotalHours / float64(len(v.Dates))
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+// AggregateBy aggregates the records (newest first) by the groupFunc of
+// each check-in.
+func AggregateBy(records []takt.Record, groupFunc func(time.Time) string) map[string]AggregatedRecord {
+	aggregations := make(map[string]AggregatedRecord)
+
+	var lastOutTime time.Time
+	for _, record := range records {
+		if record.Kind == takt.KindOut {
+			lastOutTime = record.Timestamp
+		} else if record.Kind == takt.KindIn && !lastOutTime.IsZero() {
+			groupKey := groupFunc(record.Timestamp)
+			duration := lastOutTime.Sub(record.Timestamp).Hours()
+
+			if agg, exists := aggregations[groupKey]; exists {
+				agg.TotalHours += duration
+				agg.Dates = append(agg.Dates, record.Timestamp.Format(takt.DateFormat))
+				agg.Notes = append(agg.Notes, record.Notes)
+				aggregations[groupKey] = agg
+			} else {
+				aggregations[groupKey] = AggregatedRecord{
+					Group:      groupKey,
+					TotalHours: duration,
+					Dates:      []string{record.Timestamp.Format(takt.DateFormat)},
+					Notes:      []string{record.Notes},
+				}
+			}
+			lastOutTime = time.Time{} // reset
+		}
+	}
+
+	return aggregations
+}
+
+// aggregate is AggregateBy with the minimum session length and the
+// rounding applied, keeping the raw hours.
+func (c Config) aggregate(records []takt.Record, groupFunc func(time.Time) string) map[string]AggregatedRecord {
+	aggregations := make(map[string]AggregatedRecord)
+	add := func(in takt.Record, raw time.Duration, counted bool) {
+		groupKey := groupFunc(in.Timestamp)
+		agg, exists := aggregations[groupKey]
+		if !exists {
+			agg = AggregatedRecord{Group: groupKey}
+		}
+		agg.RawHours += raw.Hours()
+		if c.MinSession.IsShort(raw) {
+			agg.Short++
+			agg.ShortHours += raw.Hours()
+		}
+		if counted {
+			if c.RoundPer != RoundPerDay {
+				raw = c.Rounding.Round(raw)
+			}
+			agg.TotalHours += raw.Hours()
+			agg.Dates = append(agg.Dates, in.Timestamp.Format(takt.DateFormat))
+			agg.Notes = append(agg.Notes, in.Notes)
+		}
+		aggregations[groupKey] = agg
+	}
+
+	// first check-in and counted time of each day, to round per day
+	dayStart := map[string]takt.Record{}
+	dayTotal := map[string]time.Duration{}
+	var lastOutTime time.Time
+	for _, record := range records {
+		if record.Kind == takt.KindOut {
+			lastOutTime = record.Timestamp
+		} else if record.Kind == takt.KindIn && !lastOutTime.IsZero() {
+			raw := lastOutTime.Sub(record.Timestamp)
+			counted := !(c.MinSession.Mode == MinSessionDrop && c.MinSession.IsShort(raw))
+			add(record, raw, counted)
+			if counted {
+				day := record.Timestamp.Format(takt.DateFormat)
+				dayStart[day] = record
+				dayTotal[day] += raw
+			}
+			lastOutTime = time.Time{} // reset
+		}
+	}
+
+	if c.RoundPer == RoundPerDay && !c.Rounding.IsZero() {
+		for day, total := range dayTotal {
+			groupKey := groupFunc(dayStart[day].Timestamp)
+			agg := aggregations[groupKey]
+			agg.TotalHours += (c.Rounding.Round(total) - total).Hours()
+			aggregations[groupKey] = agg
+		}
+	}
+	return aggregations
+}
+
+// InferLastOut adds an "out" record to the beginning of the records if the
+// last record is "in", when the open session policy counts it. It returns
+// the number of records added.
+func (c Config) InferLastOut(records *[]takt.Record) int {
+	if len(*records) > 0 && (*records)[0].Kind == takt.KindIn {
+		until, counted := c.CloseOpen((*records)[0])
+		if !counted {
+			return 0
+		}
+		record := takt.Record{Timestamp: until, Kind: takt.KindOut, Notes: InferredNotes}
+		*records = append([]takt.Record{record}, *records...)
+		return 1
+	}
+	return 0
+}
+
+// StaleAt returns when an open session starting at in looks forgotten:
+// after the cap with OpenCap, at the end of its working day otherwise (at
+// midnight when checked in after it).
+func (c Config) StaleAt(in takt.Record) time.Time {
+	if c.OpenPolicy.Mode == OpenCap {
+		return in.Timestamp.Add(c.OpenPolicy.Cap)
+	}
+	end := c.Schedule.EndOfDay(in.Timestamp)
+	if !end.After(in.Timestamp) {
+		y, m, d := in.Timestamp.Date()
+		end = time.Date(y, m, d+1, 0, 0, 0, 0, in.Timestamp.Location())
+	}
+	return end
+}
+
+// Forgotten reports whether an open session starting at in looks forgotten
+// now: past the cap with OpenCap, otherwise past its end of day and still
+// open on a later day than it started.
+func (c Config) Forgotten(in takt.Record) bool {
+	now := c.Now()
+	if !now.After(c.StaleAt(in)) {
+		return false
+	}
+	if c.OpenPolicy.Mode == OpenCap {
+		return true
+	}
+	y, m, d := in.Timestamp.Date()
+	return !now.Before(time.Date(y, m, d+1, 0, 0, 0, 0, in.Timestamp.Location()))
+}
+
+// CloseOpen returns until when an open session starting at in counts, and
+// false when the policy excludes it.
+func (c Config) CloseOpen(in takt.Record) (time.Time, bool) {
+	now := c.Now()
+	staleAt := c.StaleAt(in)
+	if now.After(staleAt) {
+		switch c.OpenPolicy.Mode {
+		case OpenCap, OpenEndOfDay:
+			return staleAt, true
+		case OpenExclude:
+			return time.Time{}, false
+		}
+	}
+	return now, true
+}
+
+// openNote returns the summary marker and footnote of an open session.
+func (c Config) openNote(in takt.Record) (string, string) {
+	since := in.Timestamp.Format("2006-01-02 15:04")
+	until, counted := c.CloseOpen(in)
+	switch {
+	case !counted:
+		return MarkerExcluded, fmt.Sprintf("%s Open session since %s not counted: check out to count it", MarkerExcluded, since)
+	case until.Equal(c.Now()):
+		return MarkerOpen, fmt.Sprintf("%s Open session since %s counted until now", MarkerOpen, since)
+	default:
+		return MarkerOpen, fmt.Sprintf("%s Open session since %s counted until %s (%s)", MarkerOpen, since, until.Format("2006-01-02 15:04"), c.OpenPolicy)
+	}
+}
+
+// SortedKeys returns the keys of a map sorted in descending order.
+func SortedKeys(m map[string]AggregatedRecord) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(keys)))
+	return keys
+}
+
+// unique returns a slice with unique items, in their first order.
+func unique(items []string) []string {
+	out := []string{}
+	seen := map[string]bool{}
+	for _, it := range items {
+		if !seen[it] {
+			seen[it] = true
+			out = append(out, it)
+		}
+	}
+	return out
+}
+
+// Balance returns the overtime (positive) or undertime (negative) of an
+// aggregation, with expected hours = target hours * working days.
+func (c Config) Balance(a AggregatedRecord) float64 {
+	expectedHours := c.TargetHours * float64(len(a.Dates))
+	return a.TotalHours - expectedHours
+}
+
+// HoursToText converts hours to a human-readable format.
+func HoursToText(totalHours float64) string {
+	if totalHours <= 0 {
+		return "00h00m"
+	} else if totalHours <= 24 {
+		hours := int(totalHours)
+		minutes := int(math.Round((totalHours - float64(hours)) * 60))
+		return fmt.Sprintf("%dh%02dm", hours, minutes)
+	} else {
+		days := int(totalHours / 24)
+		hours := int(totalHours) % 24
+		minutes := int(math.Round((totalHours - float64(days*24+hours)) * 60))
+		return fmt.Sprintf("%dd%02dh%02dm", days, hours, minutes)
+	}
+}
+
+// FormatOvertime formats the overtime/undertime difference with a sign,
+// using the target hours as the day unit.
+func (c Config) FormatOvertime(difference float64) string {
+	if difference == 0 {
+		return "00h00m"
+	}
+
+	sign := ""
+	if difference > 0 {
+		sign = "+"
+	} else {
+		sign = "-"
+	}
+
+	// Use absolute value for formatting
+	absDiff := math.Abs(difference)
+
+	// Calculate days based on the target hours
+	targetHour := c.TargetHours
+	if targetHour == 0 {
+		targetHour = takt.DefaultTargetHours // fallback to default if not set
+	}
+
+	if absDiff >= targetHour {
+		days := int(absDiff / targetHour)
+		remainingHours := absDiff - (float64(days) * targetHour)
+		hours := int(remainingHours)
+		minutes := int(math.Round((remainingHours - float64(hours)) * 60))
+
+		// Handle case where minutes round to 60
+		if minutes >= 60 {
+			hours += minutes / 60
+			minutes = minutes % 60
+		}
+
+		if hours == 0 && minutes == 0 {
+			return fmt.Sprintf("%s%dd", sign, days)
+		} else if minutes == 0 {
+			return fmt.Sprintf("%s%dd%dh", sign, days, hours)
+		} else {
+			return fmt.Sprintf("%s%dd%dh%02dm", sign, days, hours, minutes)
+		}
+	} else {
+		hours := int(absDiff)
+		minutes := int(math.Round((absDiff - float64(hours)) * 60))
+
+		// Handle case where minutes round to 60
+		if minutes >= 60 {
+			hours += minutes / 60
+			minutes = minutes % 60
+		}
+
+		return fmt.Sprintf("%s%dh%02dm", sign, hours, minutes)
+	}
+}
+
+// Summary writes the table of the head latest periods (all when head < 1)
+// with their totals, days, averages and balances.
+func (c Config) Summary(w io.Writer, records []takt.Record, period string, head int) error {
+	period = c.Period(period)
+	agg, err := c.CalculateDuration(records, period)
+	if err != nil {
+		return fmt.Errorf("error calculating duration: %w", err)
+	}
+
+	if head < 1 || head > len(agg) {
+		head = len(agg)
+	}
+
+	var columns []Column
+	if c.Columns != nil {
+		columns, err = c.Columns(period, agg[:head], records)
+		if err != nil {
+			return fmt.Errorf("error adding columns: %w", err)
+		}
+	}
+
+	var outFmt string
+	switch {
+	case period == PeriodDay:
+		outFmt = "%-12s %6s\t%4s\t%6s\t%8s"
+	case longLabels(period):
+		// wider date and total hours columns for fiscal years and sprints
+		outFmt = "%-12s %10s\t%4s\t%6s\t%8s"
+	default:
+		// wider total hours column for week, month, quarter, year
+		outFmt = "%-8s %10s\t%4s\t%6s\t%8s"
+	}
+	// the raw total next to the rounded one, so that nothing is hidden
+	showRaw := !c.Rounding.IsZero() || c.MinSession.Mode == MinSessionDrop
+	if showRaw {
+		outFmt = strings.Replace(outFmt, "s\t", "s\t%6s\t", 1)
+	}
+	row := func(group, total, raw, days, avg, balance string) string {
+		if showRaw {
+			return fmt.Sprintf(outFmt, group, total, raw, days, avg, balance)
+		}
+		return fmt.Sprintf(outFmt, group, total, days, avg, balance)
+	}
+	header := row("Date", "Total", "Raw", "Days", "Avg", "Balance")
+	for _, col := range columns {
+		header += "\t" + col.Name
+	}
+	if _, err := fmt.Fprintln(w, header); err != nil {
+		return err
+	}
+
+	var openGroup, marker, footnote string
+	if records[0].Kind == takt.KindIn {
+		label, err := Labeler(period)
+		if err != nil {
+			return err
+		}
+		openGroup = label(records[0].Timestamp)
+		marker, footnote = c.openNote(records[0])
+	}
+
+	short, shortHours := 0, 0.0
+	for _, a := range agg[:head] {
+		hhmm := HoursToText(a.TotalHours)
+		ndays := strconv.Itoa(len(a.Dates))
+		avg := HoursToText(a.AverageHours)
+		overtime := c.FormatOvertime(c.Balance(a))
+		line := row(a.Group, hhmm, HoursToText(a.RawHours), ndays, avg, overtime)
+		for _, col := range columns {
+			line += "\t" + col.Values[a.Group]
+		}
+		if a.Group == openGroup {
+			line += " " + marker
+		}
+		if a.Short > 0 {
+			line += " " + MarkerShort
+			short += a.Short
+			shortHours += a.ShortHours
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+		if c.Notes {
+			for _, note := range unique(a.Notes) {
+				if note == "" {
+					continue
+				}
+				if _, err := fmt.Fprintf(w, "    - %s\n", note); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if footnote != "" {
+		if _, err := fmt.Fprintln(w, footnote); err != nil {
+			return err
+		}
+	}
+	if short > 0 {
+		if _, err := fmt.Fprintln(w, c.MinSession.note(short, shortHours)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
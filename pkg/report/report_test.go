@@ -0,0 +1,480 @@
+package report
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/takt"
+)
+
+func TestCalculateDurationOpenSession(t *testing.T) {
+	now := time.Date(2025, 1, 9, 12, 0, 0, 0, time.UTC)
+	c := Config{TargetHours: 8, Clock: takt.FixedClock(now)}
+	records := []takt.Record{
+		{Timestamp: now.Add(-3 * time.Hour), Kind: takt.KindIn, Notes: "open"},
+		{Timestamp: now.Add(-24 * time.Hour), Kind: takt.KindOut},
+		{Timestamp: now.Add(-33 * time.Hour), Kind: takt.KindIn},
+	}
+
+	days, err := c.CalculateDuration(records, PeriodDay)
+	if err != nil {
+		t.Fatalf("CalculateDuration() failed: %v", err)
+	}
+	if len(days) != 2 || days[0].Group != "2025-01-09" || days[0].TotalHours != 3 || days[1].TotalHours != 9 {
+		t.Errorf("Unexpected days: %+v", days)
+	}
+	if got := c.Balance(days[1]); got != 1 {
+		t.Errorf("Expected a balance of 1h, got %v", got)
+	}
+	if len(records) != 3 {
+		t.Errorf("Expected the caller's records to be left alone, got %d", len(records))
+	}
+
+	if _, err := c.CalculateDuration(records, "fortnight"); err == nil {
+		t.Error("Expected an error for an unsupported period")
+	}
+	if _, err := c.CalculateDuration(nil, PeriodDay); err == nil {
+		t.Error("Expected an error without records")
+	}
+}
+
+func TestFormatOvertime(t *testing.T) {
+	tests := []struct {
+		target     float64
+		difference float64
+		expected   string
+	}{
+		{8, 0, "00h00m"},
+		{8, 0.5, "+0h30m"},
+		{8, -2, "-2h00m"},
+		{8, 16, "+2d"},
+		{7.5, 8.5, "+1d1h"},
+		{0, 9.25, "+1d1h15m"},
+	}
+	for _, tt := range tests {
+		c := Config{TargetHours: tt.target}
+		if got := c.FormatOvertime(tt.difference); got != tt.expected {
+			t.Errorf("FormatOvertime(%v) with target %v = %q, expected %q", tt.difference, tt.target, got, tt.expected)
+		}
+	}
+}
+
+func TestSummary(t *testing.T) {
+	now := time.Date(2025, 1, 9, 18, 0, 0, 0, time.UTC)
+	c := Config{TargetHours: 8, Clock: takt.FixedClock(now)}
+	records := []takt.Record{
+		{Timestamp: now.Add(-time.Hour), Kind: takt.KindOut},
+		{Timestamp: now.Add(-9*time.Hour - 30*time.Minute), Kind: takt.KindIn},
+		{Timestamp: now.Add(-24 * time.Hour), Kind: takt.KindOut},
+		{Timestamp: now.Add(-40 * time.Hour), Kind: takt.KindIn},
+	}
+
+	var sb strings.Builder
+	if err := c.Summary(&sb, records, PeriodDay, 10); err != nil {
+		t.Fatalf("Summary() failed: %v", err)
+	}
+	expected := "Date          Total\tDays\t   Avg\t Balance\n" +
+		"2025-01-09    8h30m\t   1\t 8h30m\t  +0h30m\n" +
+		"2025-01-08   16h00m\t   1\t16h00m\t     +1d\n"
+	if sb.String() != expected {
+		t.Errorf("Expected:\n%q\ngot:\n%q", expected, sb.String())
+	}
+
+	sb.Reset()
+	if err := c.Summary(&sb, records, PeriodWeek, 1); err != nil {
+		t.Fatalf("Summary() failed: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(sb.String()), "\n"); len(lines) != 2 || !strings.HasPrefix(lines[1], "2025-W02") {
+		t.Errorf("Unexpected weekly summary:\n%s", sb.String())
+	}
+}
+
+func TestSummaryColumns(t *testing.T) {
+	now := time.Date(2025, 1, 9, 18, 0, 0, 0, time.UTC)
+	records := []takt.Record{
+		{Timestamp: now.Add(-time.Hour), Kind: takt.KindOut},
+		{Timestamp: now.Add(-9 * time.Hour), Kind: takt.KindIn, Notes: "+acme"},
+	}
+	var gotPeriod string
+	var gotRecords int
+	c := Config{TargetHours: 8, Clock: takt.FixedClock(now), Columns: func(period string, rows []AggregatedRecord, records []takt.Record) ([]Column, error) {
+		gotPeriod, gotRecords = period, len(records)
+		return []Column{{Name: "Project", Values: map[string]string{rows[0].Group: "acme"}}}, nil
+	}}
+
+	var sb strings.Builder
+	if err := c.Summary(&sb, records, PeriodDay, 10); err != nil {
+		t.Fatalf("Summary() failed: %v", err)
+	}
+	expected := "Date          Total\tDays\t   Avg\t Balance\tProject\n" +
+		"2025-01-09    8h00m\t   1\t 8h00m\t  00h00m\tacme\n"
+	if sb.String() != expected {
+		t.Errorf("Expected:\n%q\ngot:\n%q", expected, sb.String())
+	}
+	if gotPeriod != PeriodDay || gotRecords != len(records) {
+		t.Errorf("Columns called with %q and %d records", gotPeriod, gotRecords)
+	}
+
+	c.Columns = func(string, []AggregatedRecord, []takt.Record) ([]Column, error) {
+		return nil, errors.New("boom")
+	}
+	if err := c.Summary(&sb, records, PeriodDay, 10); err == nil {
+		t.Error("Expected the columns error")
+	}
+}
+
+func TestOpenPolicy(t *testing.T) {
+	friday := time.Date(2025, 1, 3, 9, 0, 0, 0, time.UTC)
+	monday := time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC)
+	records := []takt.Record{
+		{Timestamp: friday, Kind: takt.KindIn},
+		{Timestamp: friday.Add(-time.Hour), Kind: takt.KindOut},
+		{Timestamp: friday.Add(-3 * time.Hour), Kind: takt.KindIn},
+	}
+	schedule := takt.Schedule{DayEnd: 17 * time.Hour}
+
+	tests := []struct {
+		policy string
+		now    time.Time
+		hours  float64
+		marker string
+	}{
+		{"now", monday, 2 + 73, MarkerOpen},
+		{"cap:10h", monday, 2 + 10, MarkerOpen},
+		{"end-of-day", monday, 2 + 8, MarkerOpen},
+		{"exclude", monday, 2, MarkerExcluded},
+		{"exclude", friday.Add(2 * time.Hour), 2 + 2, MarkerOpen},
+		{"end-of-day", friday.Add(2 * time.Hour), 2 + 2, MarkerOpen},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			policy, err := ParseOpenPolicy(tt.policy)
+			if err != nil {
+				t.Fatalf("ParseOpenPolicy() failed: %v", err)
+			}
+			if policy.String() != tt.policy {
+				t.Errorf("String() = %q, want %q", policy.String(), tt.policy)
+			}
+			c := Config{TargetHours: 8, Clock: takt.FixedClock(tt.now), Schedule: schedule, OpenPolicy: policy}
+
+			days, err := c.CalculateDuration(records, PeriodDay)
+			if err != nil {
+				t.Fatalf("CalculateDuration() failed: %v", err)
+			}
+			if len(days) != 1 || days[0].TotalHours != tt.hours {
+				t.Errorf("CalculateDuration() = %+v, want %vh on one day", days, tt.hours)
+			}
+
+			var sb strings.Builder
+			if err := c.Summary(&sb, records, PeriodDay, 10); err != nil {
+				t.Fatalf("Summary() failed: %v", err)
+			}
+			lines := strings.Split(strings.TrimSpace(sb.String()), "\n")
+			if len(lines) != 3 || !strings.HasSuffix(lines[1], " "+tt.marker) || !strings.HasPrefix(lines[2], tt.marker+" Open session since 2025-01-03 09:00") {
+				t.Errorf("Unexpected summary:\n%s", sb.String())
+			}
+		})
+	}
+
+	for _, value := range []string{"later", "cap:", "cap:-1h", "cap:10"} {
+		if _, err := ParseOpenPolicy(value); err == nil {
+			t.Errorf("ParseOpenPolicy(%q) succeeded, want an error", value)
+		}
+	}
+}
+
+func TestStaleAtLateCheckIn(t *testing.T) {
+	in := takt.Record{Timestamp: time.Date(2025, 1, 3, 20, 0, 0, 0, time.UTC), Kind: takt.KindIn}
+	c := Config{Schedule: takt.Schedule{DayEnd: 18 * time.Hour}}
+	if got, want := c.StaleAt(in), time.Date(2025, 1, 4, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("StaleAt() = %v, want %v", got, want)
+	}
+}
+
+func TestForgotten(t *testing.T) {
+	in := takt.Record{Timestamp: time.Date(2025, 1, 3, 9, 0, 0, 0, time.UTC), Kind: takt.KindIn}
+	tests := []struct {
+		name string
+		mode string
+		now  time.Time
+		want bool
+	}{
+		{"same day after end of day", "", time.Date(2025, 1, 3, 18, 30, 0, 0, time.UTC), false},
+		{"same day end of day policy", OpenEndOfDay, time.Date(2025, 1, 3, 23, 0, 0, 0, time.UTC), false},
+		{"next day", "", time.Date(2025, 1, 4, 8, 0, 0, 0, time.UTC), true},
+		{"within cap", OpenCap, time.Date(2025, 1, 3, 18, 30, 0, 0, time.UTC), false},
+		{"past cap", OpenCap, time.Date(2025, 1, 3, 19, 30, 0, 0, time.UTC), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Config{
+				Schedule:   takt.Schedule{DayEnd: 18 * time.Hour},
+				OpenPolicy: OpenPolicy{Mode: tt.mode, Cap: 10 * time.Hour},
+				Clock:      takt.FixedClock(tt.now),
+			}
+			if got := c.Forgotten(in); got != tt.want {
+				t.Errorf("Forgotten() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRounding(t *testing.T) {
+	tests := []struct {
+		value string
+		in    time.Duration
+		want  time.Duration
+	}{
+		{"none", 7*time.Minute + 30*time.Second, 7*time.Minute + 30*time.Second},
+		{"up:15m", 16 * time.Minute, 30 * time.Minute},
+		{"up:15m", 15 * time.Minute, 15 * time.Minute},
+		{"down:15m", 29 * time.Minute, 15 * time.Minute},
+		{"nearest:15m", 22*time.Minute + 30*time.Second, 30 * time.Minute},
+		{"nearest:6m", 2 * time.Minute, 0},
+		{"nearest:1m", 59*time.Second + 7*time.Hour + 59*time.Minute, 8 * time.Hour},
+	}
+	for _, tt := range tests {
+		r, err := ParseRounding(tt.value)
+		if err != nil {
+			t.Fatalf("ParseRounding(%q) failed: %v", tt.value, err)
+		}
+		if r.String() != tt.value {
+			t.Errorf("String() = %q, want %q", r.String(), tt.value)
+		}
+		if got := r.Round(tt.in); got != tt.want {
+			t.Errorf("%s rounds %v to %v, want %v", tt.value, tt.in, got, tt.want)
+		}
+	}
+
+	for _, value := range []string{"up", "sideways:15m", "up:0m", "up:soon"} {
+		if _, err := ParseRounding(value); err == nil {
+			t.Errorf("ParseRounding(%q) succeeded, want an error", value)
+		}
+	}
+}
+
+// roundingTestRecords are two days of 7h59m: on the 9th in one session, on
+// the 10th in three, one of them 30 seconds long.
+func roundingTestRecords() []takt.Record {
+	at := func(day, hour, min, sec int) time.Time {
+		return time.Date(2025, 1, day, hour, min, sec, 0, time.UTC)
+	}
+	return []takt.Record{
+		{Timestamp: at(10, 17, 0, 0), Kind: takt.KindOut},
+		{Timestamp: at(10, 13, 0, 30), Kind: takt.KindIn},
+		{Timestamp: at(10, 13, 0, 0), Kind: takt.KindOut},
+		{Timestamp: at(10, 12, 59, 30), Kind: takt.KindIn, Notes: "oops"},
+		{Timestamp: at(10, 12, 0, 0), Kind: takt.KindOut},
+		{Timestamp: at(10, 8, 1, 0), Kind: takt.KindIn},
+		{Timestamp: at(9, 16, 59, 0), Kind: takt.KindOut},
+		{Timestamp: at(9, 9, 0, 0), Kind: takt.KindIn},
+	}
+}
+
+func TestCalculateDurationRounding(t *testing.T) {
+	tests := []struct {
+		rounding, per, min string
+		want               []string // day totals, newest first
+	}{
+		{"none", RoundPerSession, "none", []string{"7h59m", "7h59m"}},
+		{"nearest:15m", RoundPerSession, "none", []string{"8h00m", "8h00m"}},
+		{"up:15m", RoundPerSession, "none", []string{"8h15m", "8h00m"}},
+		{"up:15m", RoundPerDay, "none", []string{"8h00m", "8h00m"}},
+		{"down:6m", RoundPerDay, "none", []string{"7h54m", "7h54m"}},
+		{"none", RoundPerSession, "drop:1m", []string{"7h58m", "7h59m"}},
+		{"up:15m", RoundPerSession, "drop:1m", []string{"8h00m", "8h00m"}},
+		{"none", RoundPerSession, "flag:1m", []string{"7h59m", "7h59m"}},
+	}
+	for _, tt := range tests {
+		rounding, err := ParseRounding(tt.rounding)
+		if err != nil {
+			t.Fatal(err)
+		}
+		minSession, err := ParseMinSession(tt.min)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := Config{TargetHours: 8, Rounding: rounding, RoundPer: tt.per, MinSession: minSession}
+		days, err := c.CalculateDuration(roundingTestRecords(), PeriodDay)
+		if err != nil {
+			t.Fatalf("CalculateDuration() failed: %v", err)
+		}
+		var got []string
+		for _, d := range days {
+			got = append(got, HoursToText(d.TotalHours))
+			if HoursToText(d.RawHours) != "7h59m" {
+				t.Errorf("%s per %s, %s: raw %s = %s, want 7h59m", tt.rounding, tt.per, tt.min, d.Group, HoursToText(d.RawHours))
+			}
+		}
+		if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("%s per %s, %s: totals = %v, want %v", tt.rounding, tt.per, tt.min, got, tt.want)
+		}
+	}
+}
+
+func TestSummaryRounding(t *testing.T) {
+	c := Config{
+		TargetHours: 8,
+		Rounding:    Rounding{Mode: RoundNearest, Step: 15 * time.Minute},
+		MinSession:  MinSession{Mode: MinSessionDrop, Length: time.Minute},
+	}
+	var sb strings.Builder
+	if err := c.Summary(&sb, roundingTestRecords(), PeriodDay, 10); err != nil {
+		t.Fatalf("Summary() failed: %v", err)
+	}
+	expected := "Date          Total\t   Raw\tDays\t   Avg\t Balance\n" +
+		"2025-01-10    8h00m\t 7h59m\t   1\t 8h00m\t  00h00m ~\n" +
+		"2025-01-09    8h00m\t 7h59m\t   1\t 8h00m\t  00h00m\n" +
+		"~ 1 session shorter than 1m (0h01m) dropped from the totals\n"
+	if sb.String() != expected {
+		t.Errorf("Expected:\n%q\ngot:\n%q", expected, sb.String())
+	}
+}
+
+func TestParseMinSession(t *testing.T) {
+	for _, value := range []string{"none", "flag:1m", "drop:30s"} {
+		m, err := ParseMinSession(value)
+		if err != nil {
+			t.Fatalf("ParseMinSession(%q) failed: %v", value, err)
+		}
+		if m.String() != value {
+			t.Errorf("String() = %q, want %q", m.String(), value)
+		}
+	}
+	for _, value := range []string{"1m", "hide:1m", "drop:0s", "flag:soon"} {
+		if _, err := ParseMinSession(value); err == nil {
+			t.Errorf("ParseMinSession(%q) succeeded, want an error", value)
+		}
+	}
+}
+
+func TestLabeler(t *testing.T) {
+	jan5 := time.Date(2025, 1, 5, 10, 0, 0, 0, time.UTC)
+	apr1 := time.Date(2025, 4, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		period string
+		t      time.Time
+		want   string
+	}{
+		{"day", jan5, "2025-01-05"},
+		{"week", jan5, "2025-W01"},
+		{"month", apr1, "2025-04"},
+		{"quarter", jan5, "2025-Q1"},
+		{"quarter", apr1, "2025-Q2"},
+		{"year", apr1, "2025"},
+		{"fiscal-year", jan5, "FY2025"},
+		{"fiscal-year:apr", jan5, "FY2024-25"},
+		{"fiscal-year:4", apr1, "FY2025-26"},
+		{"sprint", jan5, "2024-12-30"},
+		{"sprint:2025-01-06/2w", jan5, "2024-12-23"},
+		{"sprint:2025-01-06/10d", time.Date(2025, 1, 16, 0, 0, 0, 0, time.UTC), "2025-01-16"},
+		{"sprint:/1w", jan5, "2024-12-30"},
+	}
+	for _, tt := range tests {
+		label, err := Labeler(tt.period)
+		if err != nil {
+			t.Fatalf("Labeler(%q) failed: %v", tt.period, err)
+		}
+		if got := label(tt.t); got != tt.want {
+			t.Errorf("Labeler(%q)(%s) = %q, want %q", tt.period, tt.t.Format("2006-01-02"), got, tt.want)
+		}
+	}
+
+	for _, period := range []string{"fortnight", "month:2", "fiscal-year:smarch", "sprint:2025-13-01", "sprint:/0w", "sprint:/2m"} {
+		if _, err := Labeler(period); err == nil {
+			t.Errorf("Labeler(%q) succeeded, want an error", period)
+		}
+	}
+}
+
+func TestConfigPeriod(t *testing.T) {
+	sprint, err := ParseSprint("2025-01-06/3w")
+	if err != nil {
+		t.Fatalf("ParseSprint() failed: %v", err)
+	}
+	c := Config{TargetHours: 8, FiscalYearStart: time.April, Sprint: sprint}
+	for period, want := range map[string]string{
+		PeriodFiscalYear: "fiscal-year:4",
+		PeriodSprint:     "sprint:2025-01-06/3w",
+		"sprint:/1w":     "sprint:/1w",
+		PeriodQuarter:    PeriodQuarter,
+	} {
+		if got := c.Period(period); got != want {
+			t.Errorf("Period(%q) = %q, want %q", period, got, want)
+		}
+	}
+
+	records := []takt.Record{
+		{Timestamp: time.Date(2025, 4, 1, 17, 0, 0, 0, time.UTC), Kind: takt.KindOut},
+		{Timestamp: time.Date(2025, 4, 1, 9, 0, 0, 0, time.UTC), Kind: takt.KindIn},
+		{Timestamp: time.Date(2025, 3, 31, 17, 0, 0, 0, time.UTC), Kind: takt.KindOut},
+		{Timestamp: time.Date(2025, 3, 31, 9, 0, 0, 0, time.UTC), Kind: takt.KindIn},
+	}
+	var sb strings.Builder
+	if err := c.Summary(&sb, records, PeriodFiscalYear, 10); err != nil {
+		t.Fatalf("Summary() failed: %v", err)
+	}
+	expected := "Date              Total\tDays\t   Avg\t Balance\n" +
+		"FY2025-26         8h00m\t   1\t 8h00m\t  00h00m\n" +
+		"FY2024-25         8h00m\t   1\t 8h00m\t  00h00m\n"
+	if sb.String() != expected {
+		t.Errorf("Expected:\n%q\ngot:\n%q", expected, sb.String())
+	}
+}
+
+func TestForecast(t *testing.T) {
+	// Thursday 14:00, checked in since 13:00
+	now := time.Date(2025, 1, 9, 14, 0, 0, 0, time.UTC)
+	at := func(day, hour int) time.Time {
+		return time.Date(2025, 1, day, hour, 0, 0, 0, time.UTC)
+	}
+	records := []takt.Record{
+		{Timestamp: at(9, 13), Kind: takt.KindIn},
+		{Timestamp: at(9, 12), Kind: takt.KindOut},
+		{Timestamp: at(9, 9), Kind: takt.KindIn},
+		{Timestamp: at(8, 18), Kind: takt.KindOut},
+		{Timestamp: at(8, 9), Kind: takt.KindIn},
+		{Timestamp: at(7, 16), Kind: takt.KindOut},
+		{Timestamp: at(7, 9), Kind: takt.KindIn},
+		{Timestamp: at(6, 17), Kind: takt.KindOut},
+		{Timestamp: at(6, 9), Kind: takt.KindIn},
+	}
+	c := Config{TargetHours: 8, Clock: takt.FixedClock(now), OpenPolicy: OpenPolicy{Mode: OpenExclude}}
+
+	f, err := c.Forecast(records, PeriodWeek)
+	if err != nil {
+		t.Fatalf("Forecast() failed: %v", err)
+	}
+	if f.Period != "2025-W02" || f.From.Format("01-02") != "01-06" || f.To.Format("01-02") != "01-12" {
+		t.Errorf("Unexpected period: %s from %s to %s", f.Period, f.From, f.To)
+	}
+	// 8h + 7h + 9h before today, 4h today
+	if f.Target != 40 || f.Worked != 28 || f.Balance != 0 || f.TodayWorked != 4 || !f.CheckedIn() {
+		t.Errorf("Unexpected forecast: %+v", f)
+	}
+	if f.Remaining() != 12 || f.TodayRemaining() != 4 {
+		t.Errorf("Remaining() = %v, TodayRemaining() = %v, want 12 and 4", f.Remaining(), f.TodayRemaining())
+	}
+	if got := f.LeaveAt(f.TodayRemaining()).Format("15:04"); got != "18:00" {
+		t.Errorf("LeaveAt() = %s, want 18:00", got)
+	}
+	if len(f.Rest) != 1 || f.Rest[0].Date.Format("01-02") != "01-10" || f.Rest[0].Hours != 8 {
+		t.Errorf("Rest = %+v, want 8h on Friday", f.Rest)
+	}
+
+	f, err = c.Forecast(records, PeriodMonth)
+	if err != nil {
+		t.Fatalf("Forecast() failed: %v", err)
+	}
+	// 23 workdays in January 2025, 16 of them after today
+	if f.Period != "2025-01" || f.Target != 23*8 || len(f.Rest) != 16 {
+		t.Errorf("Unexpected month forecast: %s, target %v, %d days left", f.Period, f.Target, len(f.Rest))
+	}
+
+	if _, err := c.Forecast(records, PeriodYear); err == nil {
+		t.Error("Expected an error for an unsupported period")
+	}
+}
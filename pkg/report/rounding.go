@@ -0,0 +1,142 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Rounding modes
+const (
+	RoundNone    = "none"
+	RoundNearest = "nearest"
+	RoundUp      = "up"
+	RoundDown    = "down"
+)
+
+// Rounding rounds durations to a step. Its zero value doesn't round.
+type Rounding struct {
+	Mode string
+	Step time.Duration
+}
+
+// ParseRounding parses none or MODE:STEP, such as up:15m or nearest:6m.
+func ParseRounding(value string) (Rounding, error) {
+	if value == "" || value == RoundNone {
+		return Rounding{}, nil
+	}
+	mode, step, ok := strings.Cut(value, ":")
+	if !ok {
+		return Rounding{}, fmt.Errorf("invalid rounding %q: expected MODE:STEP, such as up:15m", value)
+	}
+	switch mode {
+	case RoundNearest, RoundUp, RoundDown:
+	default:
+		return Rounding{}, fmt.Errorf("unsupported rounding mode: %s (must be %s, %s or %s)", mode, RoundNearest, RoundUp, RoundDown)
+	}
+	d, err := time.ParseDuration(step)
+	if err != nil || d <= 0 {
+		return Rounding{}, fmt.Errorf("invalid rounding step %q: expected a positive duration such as 15m", step)
+	}
+	return Rounding{Mode: mode, Step: d}, nil
+}
+
+// IsZero reports whether r doesn't round.
+func (r Rounding) IsZero() bool {
+	return r.Mode == "" || r.Mode == RoundNone || r.Step <= 0
+}
+
+// Round rounds d to a multiple of the step.
+func (r Rounding) Round(d time.Duration) time.Duration {
+	if r.IsZero() {
+		return d
+	}
+	switch r.Mode {
+	case RoundUp:
+		if rest := d % r.Step; rest != 0 {
+			return d - rest + r.Step
+		}
+		return d
+	case RoundDown:
+		return d - d%r.Step
+	default:
+		return d.Round(r.Step)
+	}
+}
+
+// String returns the rounding as parsed by ParseRounding.
+func (r Rounding) String() string {
+	if r.IsZero() {
+		return RoundNone
+	}
+	return r.Mode + ":" + shortDuration(r.Step)
+}
+
+// shortDuration formats d without zero units, such as 10h or 1h30m rather
+// than 10h0m0s or 1h30m0s.
+func shortDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// What to do with sessions shorter than the minimum
+const (
+	MinSessionFlag = "flag" // count them, marked in summaries
+	MinSessionDrop = "drop" // leave them out of the totals
+)
+
+// MinSession is the minimum session length. Its zero value has none.
+type MinSession struct {
+	Mode   string
+	Length time.Duration
+}
+
+// ParseMinSession parses none, flag:DURATION or drop:DURATION, such as
+// drop:1m.
+func ParseMinSession(value string) (MinSession, error) {
+	if value == "" || value == RoundNone {
+		return MinSession{}, nil
+	}
+	mode, length, ok := strings.Cut(value, ":")
+	if !ok || (mode != MinSessionFlag && mode != MinSessionDrop) {
+		return MinSession{}, fmt.Errorf("invalid minimum session %q: expected %s:DURATION or %s:DURATION, such as drop:1m",
+			value, MinSessionFlag, MinSessionDrop)
+	}
+	d, err := time.ParseDuration(length)
+	if err != nil || d <= 0 {
+		return MinSession{}, fmt.Errorf("invalid minimum session length %q: expected a positive duration such as 1m", length)
+	}
+	return MinSession{Mode: mode, Length: d}, nil
+}
+
+// IsShort reports whether a session lasting d is shorter than the minimum.
+func (m MinSession) IsShort(d time.Duration) bool {
+	return m.Mode != "" && d < m.Length
+}
+
+// String returns the minimum as parsed by ParseMinSession.
+func (m MinSession) String() string {
+	if m.Mode == "" {
+		return RoundNone
+	}
+	return m.Mode + ":" + shortDuration(m.Length)
+}
+
+// note returns the summary footnote of count short sessions lasting hours.
+func (m MinSession) note(count int, hours float64) string {
+	sessions := "sessions"
+	if count == 1 {
+		sessions = "session"
+	}
+	action := "flagged"
+	if m.Mode == MinSessionDrop {
+		action = "dropped from the totals"
+	}
+	return fmt.Sprintf("%s %d %s shorter than %s (%s) %s", MarkerShort, count, sessions, shortDuration(m.Length), HoursToText(hours), action)
+}
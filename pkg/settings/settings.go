@@ -0,0 +1,406 @@
+// Package settings loads the configuration of takt from TAKT_*
+// environment variables.
+package settings
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/grid"
+	"github.com/asdf8601/takt-go/pkg/remind"
+	"github.com/asdf8601/takt-go/pkg/report"
+	"github.com/asdf8601/takt-go/pkg/takt"
+)
+
+const (
+	// Hook events
+	HookPreCheck  = "pre-check"
+	HookPostCheck = "post-check"
+	HookPostEdit  = "post-edit"
+	HookPostSync  = "post-sync"
+
+	DefaultIdleSource    = "auto"
+	DefaultIdleThreshold = 15 * time.Minute
+
+	// DefaultNotify is the notification sink of takt daemon unless
+	// TAKT_NOTIFY is set.
+	DefaultNotify = "stdout"
+
+	// DefaultCurrency is the currency of invoices unless TAKT_CURRENCY is set.
+	DefaultCurrency = "EUR"
+)
+
+// HookEnv maps each hook event to the environment variable with its command.
+var HookEnv = map[string]string{
+	HookPreCheck:  "TAKT_HOOK_PRE_CHECK",
+	HookPostCheck: "TAKT_HOOK_POST_CHECK",
+	HookPostEdit:  "TAKT_HOOK_POST_EDIT",
+	HookPostSync:  "TAKT_HOOK_POST_SYNC",
+}
+
+// Config holds application configuration
+type Config struct {
+	Editor          string
+	FileName        string
+	TargetHours     float64
+	GridThresholds  *grid.Thresholds
+	GridSymbols     string
+	Now             time.Time         // pinned current time, zero for the wall clock
+	Hooks           map[string]string // hook event to command
+	WasmPlugins     []string          // WASM plugin files and directories
+	IdleSource      string            // idle source of takt daemon
+	IdleThreshold   time.Duration     // idleness that checks out
+	Schedule        takt.Schedule
+	OpenPolicy      report.OpenPolicy // how long open sessions count
+	Reminders       []remind.Rule     // reminders of takt daemon
+	Notify          []string          // notification sinks of takt daemon
+	Rounding        report.Rounding   // rounding of report durations
+	RoundPer        string            // round per session or per day
+	MinSession      report.MinSession // minimum session length of reports
+	FiscalYearStart time.Month        // first month of fiscal years
+	Sprint          report.Sprint     // sprints of the sprint period
+	Rates           string            // rate card of takt invoice
+	BillingRounding report.Rounding   // rounding of invoiced sessions
+	TaxPercent      float64           // tax of invoices
+	Currency        string            // currency of invoices
+}
+
+// Load initializes configuration from environment variables
+func Load() (*Config, error) {
+	fileName, err := getFileName("TAKT_FILE", "~/takt.csv")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file name: %w", err)
+	}
+
+	targetHours, err := getTargetHours("TAKT_TARGET_HOURS", takt.DefaultTargetHours)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get target hours: %w", err)
+	}
+
+	gridThresholds, err := getGridThresholds("TAKT_GRID_THRESHOLDS")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get grid thresholds: %w", err)
+	}
+
+	now, err := ParseNow(os.Getenv("TAKT_NOW"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get TAKT_NOW: %w", err)
+	}
+
+	wasmPlugins, err := getWasmPlugins("TAKT_WASM_PLUGINS")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get WASM plugins: %w", err)
+	}
+
+	idleThreshold, err := getIdleThreshold("TAKT_IDLE_THRESHOLD", DefaultIdleThreshold)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idle threshold: %w", err)
+	}
+
+	idleSource := os.Getenv("TAKT_IDLE_SOURCE")
+	if idleSource == "" {
+		idleSource = DefaultIdleSource
+	}
+
+	dayEnd, err := getTimeOfDay("TAKT_DAY_END", takt.DefaultDayEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get day end: %w", err)
+	}
+
+	var workdays []time.Weekday
+	if value := os.Getenv("TAKT_WORKDAYS"); value != "" {
+		if workdays, err = takt.ParseWorkdays(value); err != nil {
+			return nil, fmt.Errorf("failed to get workdays: %w", err)
+		}
+	}
+
+	reminders := os.Getenv("TAKT_REMINDERS")
+	if reminders == "" {
+		reminders = remind.Default
+	}
+	reminderRules, err := remind.Parse(reminders)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reminders: %w", err)
+	}
+
+	openPolicy, err := report.ParseOpenPolicy(os.Getenv("TAKT_OPEN_SESSIONS"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open session policy: %w", err)
+	}
+
+	rounding, err := report.ParseRounding(os.Getenv("TAKT_ROUNDING"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rounding: %w", err)
+	}
+
+	roundPer := os.Getenv("TAKT_ROUNDING_PER")
+	switch roundPer {
+	case "":
+		roundPer = report.RoundPerSession
+	case report.RoundPerSession, report.RoundPerDay:
+	default:
+		return nil, fmt.Errorf("failed to get rounding scope: unsupported %s (must be %s or %s)",
+			roundPer, report.RoundPerSession, report.RoundPerDay)
+	}
+
+	minSession, err := report.ParseMinSession(os.Getenv("TAKT_MIN_SESSION"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get minimum session: %w", err)
+	}
+
+	var fiscalYearStart time.Month
+	if value := os.Getenv("TAKT_FISCAL_YEAR_START"); value != "" {
+		if fiscalYearStart, err = report.ParseMonth(value); err != nil {
+			return nil, fmt.Errorf("failed to get fiscal year start: %w", err)
+		}
+	}
+
+	sprint, err := report.ParseSprint(os.Getenv("TAKT_SPRINT"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sprint: %w", err)
+	}
+
+	rates, err := getFileName("TAKT_RATES", "~/.takt_rates.csv")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rate card: %w", err)
+	}
+
+	billingRounding, err := report.ParseRounding(os.Getenv("TAKT_BILLING_ROUNDING"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get billing rounding: %w", err)
+	}
+
+	taxPercent, err := getTaxPercent("TAKT_TAX")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tax: %w", err)
+	}
+
+	currency := os.Getenv("TAKT_CURRENCY")
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+
+	gridSymbols := os.Getenv("TAKT_GRID_SYMBOLS")
+	if gridSymbols == "" {
+		gridSymbols = grid.SymbolsNerdFont
+	}
+
+	return &Config{
+		Editor:          os.Getenv("TAKT_EDITOR"),
+		FileName:        fileName,
+		TargetHours:     targetHours,
+		GridThresholds:  gridThresholds,
+		GridSymbols:     gridSymbols,
+		Now:             now,
+		Hooks:           loadHooks(),
+		WasmPlugins:     wasmPlugins,
+		IdleSource:      idleSource,
+		IdleThreshold:   idleThreshold,
+		Schedule:        takt.Schedule{DayEnd: dayEnd, Workdays: workdays},
+		OpenPolicy:      openPolicy,
+		Reminders:       reminderRules,
+		Notify:          getSinks("TAKT_NOTIFY"),
+		Rounding:        rounding,
+		RoundPer:        roundPer,
+		MinSession:      minSession,
+		FiscalYearStart: fiscalYearStart,
+		Sprint:          sprint,
+		Rates:           rates,
+		BillingRounding: billingRounding,
+		TaxPercent:      taxPercent,
+		Currency:        currency,
+	}, nil
+}
+
+// GridThresholdsOrDefault returns the explicit grid thresholds, or else the
+// defaults scaled to the target hours.
+func (c *Config) GridThresholdsOrDefault() grid.Thresholds {
+	if c.GridThresholds != nil {
+		return *c.GridThresholds
+	}
+	return grid.DefaultThresholds(c.TargetHours)
+}
+
+// nowFormats are the layouts accepted by TAKT_NOW and --now, besides
+// RFC3339. Times without a zone are local.
+var nowFormats = []string{"2006-01-02T15:04", "2006-01-02 15:04", "2006-01-02 15:04:05"}
+
+// ParseNow parses a pinned current time. A date alone means the end of that
+// day, so that reports include the whole day. It returns the zero time for
+// an empty value.
+func ParseNow(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(takt.TimeFormat, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range nowFormats {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if t, err := time.ParseInLocation(takt.DateFormat, value, time.Local); err == nil {
+		return t.AddDate(0, 0, 1).Add(-time.Second), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: expected RFC3339, YYYY-MM-DD HH:MM or YYYY-MM-DD", value)
+}
+
+// AbsPath returns the absolute path by expanding the tilde (~) to the user's home directory.
+func AbsPath(path string) (string, error) {
+	if strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("could not get user home directory: %w", err)
+		}
+		return filepath.Join(home, path[2:]), nil
+	}
+	return path, nil
+}
+
+// loadHooks reads the hook commands from the environment.
+func loadHooks() map[string]string {
+	hooks := map[string]string{}
+	for event, key := range HookEnv {
+		if command := os.Getenv(key); command != "" {
+			hooks[event] = command
+		}
+	}
+	return hooks
+}
+
+// getFileName returns the file name from the environment variable or the default value.
+func getFileName(key, dflt string) (string, error) {
+	path := os.Getenv(key)
+
+	if path == "" {
+		return AbsPath(dflt)
+	}
+
+	return AbsPath(path)
+}
+
+// getGridThresholds returns the grid thresholds in the environment variable,
+// or nil when it is unset.
+func getGridThresholds(key string) (*grid.Thresholds, error) {
+	thresholds, err := grid.ParseThresholds(os.Getenv(key))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", key, err)
+	}
+	return thresholds, nil
+}
+
+// getTimeOfDay returns the HH:MM time of day from the environment variable
+// or the default value.
+func getTimeOfDay(key string, dflt time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return dflt, nil
+	}
+	return takt.ParseTimeOfDay(value)
+}
+
+// getTargetHours returns the target hours from the environment variable or the default value.
+// Supports both float format (e.g., "7.5") and time format (e.g., "7:30").
+func getTargetHours(key string, dflt float64) (float64, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return dflt, nil
+	}
+
+	// Check if the value contains a colon (HH:MM format)
+	if strings.Contains(value, ":") {
+		parts := strings.Split(value, ":")
+		if len(parts) != 2 {
+			return dflt, nil
+		}
+
+		hours, err := strconv.Atoi(parts[0])
+		if err != nil || hours < 0 {
+			return dflt, nil
+		}
+
+		minutes, err := strconv.Atoi(parts[1])
+		if err != nil || minutes < 0 || minutes >= 60 {
+			return dflt, nil
+		}
+
+		// Convert to decimal hours
+		return float64(hours) + float64(minutes)/60.0, nil
+	}
+
+	// Try to parse as float
+	hours, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return dflt, nil
+	}
+
+	return hours, nil
+}
+
+// getWasmPlugins returns the plugin files and directories listed in the
+// environment variable, separated like PATH.
+func getWasmPlugins(key string) ([]string, error) {
+	var paths []string
+	for _, path := range filepath.SplitList(os.Getenv(key)) {
+		if path == "" {
+			continue
+		}
+		path, err := AbsPath(path)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// getIdleThreshold returns the idle threshold from the environment variable
+// or the default value.
+func getIdleThreshold(key string, dflt time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return dflt, nil
+	}
+	threshold, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if threshold <= 0 {
+		return 0, fmt.Errorf("must be positive, got %s", value)
+	}
+	return threshold, nil
+}
+
+// getSinks returns the sinks listed in the environment variable, separated
+// by semicolons, or stdout.
+func getSinks(key string) []string {
+	var specs []string
+	for _, spec := range strings.Split(os.Getenv(key), ";") {
+		if spec = strings.TrimSpace(spec); spec != "" {
+			specs = append(specs, spec)
+		}
+	}
+	if len(specs) == 0 {
+		return []string{DefaultNotify}
+	}
+	return specs
+}
+
+// getTaxPercent returns the tax percentage in the environment variable, or
+// zero.
+func getTaxPercent(key string) (float64, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return 0, nil
+	}
+	tax, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+	if err != nil || tax < 0 {
+		return 0, fmt.Errorf("invalid tax %q: expected a percentage such as 21", value)
+	}
+	return tax, nil
+}
@@ -0,0 +1,108 @@
+package settings
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/grid"
+)
+
+func TestGetTargetHours(t *testing.T) {
+	tests := []struct {
+		name     string
+		envValue string
+		dflt     float64
+		expected float64
+	}{
+		{"default", "", 8.0, 8.0},
+		{"float_format", "7.5", 8.0, 7.5},
+		{"time_format", "7:30", 8.0, 7.5},
+		{"invalid_format", "invalid", 8.0, 8.0},
+		{"invalid_time", "7:99", 8.0, 8.0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Set environment variable
+			key := "TEST_TARGET_HOURS"
+			if tt.envValue != "" {
+				if err := os.Setenv(key, tt.envValue); err != nil {
+					t.Fatalf("Failed to set environment variable: %v", err)
+				}
+			} else {
+				if err := os.Unsetenv(key); err != nil {
+					t.Fatalf("Failed to unset environment variable: %v", err)
+				}
+			}
+			defer func() {
+				if err := os.Unsetenv(key); err != nil {
+					t.Logf("Failed to unset environment variable: %v", err)
+				}
+			}()
+
+			result, err := getTargetHours(key, tt.dflt)
+			if err != nil {
+				t.Errorf("getTargetHours() returned error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("getTargetHours() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	cfg, err := Load()
+	if err != nil {
+		t.Errorf("Load() failed: %v", err)
+	}
+	if cfg == nil {
+		t.Error("Load() returned nil config")
+	}
+}
+
+func TestLoadGridThresholds(t *testing.T) {
+	t.Setenv("TAKT_GRID_THRESHOLDS", "1,8,4,12")
+	if _, err := Load(); err == nil {
+		t.Error("Load() accepted descending grid thresholds")
+	}
+
+	t.Setenv("TAKT_GRID_THRESHOLDS", "")
+	t.Setenv("TAKT_TARGET_HOURS", "6")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if got, want := cfg.GridThresholdsOrDefault(), grid.DefaultThresholds(6); got != want {
+		t.Errorf("GridThresholdsOrDefault() = %v, want %v", got, want)
+	}
+	cfg.GridThresholds = &grid.Thresholds{1, 4, 8, 12}
+	if got := cfg.GridThresholdsOrDefault(); got != *cfg.GridThresholds {
+		t.Errorf("GridThresholdsOrDefault() = %v, want %v", got, *cfg.GridThresholds)
+	}
+}
+
+func TestParseNow(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected time.Time
+		wantErr  bool
+	}{
+		{"", time.Time{}, false},
+		{"2025-01-10T15:00:00Z", time.Date(2025, 1, 10, 15, 0, 0, 0, time.UTC), false},
+		{"2025-01-10 15:04", time.Date(2025, 1, 10, 15, 4, 0, 0, time.Local), false},
+		{"2025-01-10", time.Date(2025, 1, 10, 23, 59, 59, 0, time.Local), false},
+		{"yesterday", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseNow(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseNow(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !got.Equal(tt.expected) {
+			t.Errorf("ParseNow(%q) = %v, expected %v", tt.value, got, tt.expected)
+		}
+	}
+}
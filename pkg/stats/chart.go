@@ -0,0 +1,143 @@
+package stats
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/report"
+)
+
+// Ticks are the levels of a sparkline, lowest first.
+var Ticks = []rune("▁▂▃▄▅▆▇█")
+
+const (
+	// BarWidth is the width of the longest histogram bar.
+	BarWidth = 30
+	// DayTurn is the hour at which histograms of the day start.
+	DayTurn = 4
+)
+
+// Sparkline draws values as one tick each, scaled to the largest.
+func Sparkline(values []float64) string {
+	top := 0.0
+	for _, v := range values {
+		top = math.Max(top, v)
+	}
+	var b strings.Builder
+	for _, v := range values {
+		i := 0
+		if top > 0 && v > 0 {
+			i = int(math.Round(v / top * float64(len(Ticks)-1)))
+		}
+		b.WriteRune(Ticks[i])
+	}
+	return b.String()
+}
+
+// Bar draws value as a bar of up to width blocks, scaled to top.
+func Bar(value, top float64, width int) string {
+	if top <= 0 || value <= 0 {
+		return ""
+	}
+	n := int(math.Round(value / top * float64(width)))
+	if n == 0 {
+		n = 1 // show that there is something
+	}
+	return strings.Repeat("█", n)
+}
+
+// WriteText writes the stats with sparklines and histograms.
+func (s Stats) WriteText(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Stats from %s to %s\n", s.From, s.To)
+	fmt.Fprintf(&b, "  Active days: %d of %d (%.1f%%), %s in total\n",
+		s.ActiveDays, s.Days, s.ActivityRate, report.HoursToText(s.TotalHours))
+
+	var daily []float64
+	for _, d := range s.Daily {
+		daily = append(daily, d.Hours)
+	}
+	fmt.Fprintf(&b, "  Daily hours: %s\n", Sparkline(daily))
+
+	b.WriteString("\nAverage per weekday worked\n")
+	top := 0.0
+	for _, wd := range s.Weekdays {
+		top = math.Max(top, wd.AverageHours)
+	}
+	for _, wd := range s.Weekdays {
+		fmt.Fprintf(&b, "  %s %6s %3dd  %s\n", wd.Weekday, report.HoursToText(wd.AverageHours), wd.Days, Bar(wd.AverageHours, top, BarWidth))
+	}
+
+	writeHistogram(&b, "\nFirst check-in of the day\n", s.Starts)
+	writeHistogram(&b, "\nLast check-out of the day\n", s.Ends)
+
+	b.WriteString("\nStreaks of workdays at the target\n")
+	fmt.Fprintf(&b, "  Longest: %s\n", formatStreak(s.LongestStreak))
+	fmt.Fprintf(&b, "  Current: %s\n", formatStreak(s.CurrentStreak))
+
+	if len(s.LatestEnds) > 0 {
+		b.WriteString("\nLatest check-out per week\n")
+		var latest time.Duration
+		for _, e := range s.LatestEnds {
+			latest = max(latest, e.After)
+		}
+		for _, e := range s.LatestEnds {
+			fmt.Fprintf(&b, "  %s  %s %s  %s\n", e.Week, e.Date, formatEnd(e), Bar(e.After.Hours(), latest.Hours(), BarWidth))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeHistogram writes the hours of the day with counts, from the first
+// to the last with any. Days are taken to turn at DayTurn, so that late
+// check-outs after midnight follow those of the evening.
+func writeHistogram(b *strings.Builder, title string, counts [24]int) {
+	b.WriteString(title)
+	first, last, top := -1, -1, 0
+	for i := 0; i < 24; i++ {
+		n := counts[(DayTurn+i)%24]
+		if n == 0 {
+			continue
+		}
+		if first < 0 {
+			first = i
+		}
+		last = i
+		if n > top {
+			top = n
+		}
+	}
+	if first < 0 {
+		b.WriteString("  none\n")
+		return
+	}
+	for i := first; i <= last; i++ {
+		hour := (DayTurn + i) % 24
+		fmt.Fprintf(b, "  %02d:00 %3d  %s\n", hour, counts[hour], Bar(float64(counts[hour]), float64(top), BarWidth))
+	}
+}
+
+// formatStreak formats a streak as "5 days (2025-01-06 to 2025-01-10)".
+func formatStreak(s Streak) string {
+	switch s.Days {
+	case 0:
+		return "none"
+	case 1:
+		return fmt.Sprintf("1 day (%s)", s.From)
+	}
+	return fmt.Sprintf("%d days (%s to %s)", s.Days, s.From, s.To)
+}
+
+// formatEnd formats the time of a check-out, with +1 when it is after
+// midnight.
+func formatEnd(e WeekEnd) string {
+	end := e.End.Format("15:04")
+	if e.After >= 24*time.Hour {
+		end += "+1"
+	}
+	return end
+}
@@ -0,0 +1,212 @@
+// Package stats analyzes when work happens: hours per weekday, start and
+// end times, streaks at the target and the latest check-out of each week.
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/takt"
+)
+
+// Options configure Compute.
+type Options struct {
+	// TargetHours are the hours of a day meeting the target.
+	TargetHours float64
+	// Schedule tells the workdays; days off neither break nor extend
+	// streaks.
+	Schedule takt.Schedule
+	// From and To bound the days analyzed, inclusive; zero means the first
+	// day with sessions and the day of Now.
+	From, To time.Time
+	// Now is the current time. Its day doesn't break the current streak
+	// until it is over; zero means the last day with sessions is over.
+	Now time.Time
+}
+
+// Session is a check-in paired with its check-out. Sessions without a
+// check-in or a check-out are left out.
+type Session struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Day is the work of a calendar day.
+type Day struct {
+	Date  string    `json:"date"`
+	Hours float64   `json:"hours"`
+	Start time.Time `json:"start"` // first check-in
+	End   time.Time `json:"end"`   // last check-out
+}
+
+// Weekday is the work of a weekday over the analyzed days.
+type Weekday struct {
+	Weekday      string  `json:"weekday"`
+	Days         int     `json:"days"` // days worked
+	TotalHours   float64 `json:"total_hours"`
+	AverageHours float64 `json:"average_hours"` // per day worked
+}
+
+// Streak is a run of workdays meeting the target.
+type Streak struct {
+	Days int    `json:"days"`
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+}
+
+// WeekEnd is the latest check-out of a week, on the day of Date.
+type WeekEnd struct {
+	Week string    `json:"week"`
+	Date string    `json:"date"`
+	End  time.Time `json:"end"`
+	// After is the time from the start of Date to End, past 24h for a
+	// check-out after midnight.
+	After time.Duration `json:"-"`
+}
+
+// Stats are the analytics of a range of days.
+type Stats struct {
+	From         string  `json:"from"`
+	To           string  `json:"to"`
+	Days         int     `json:"days"`        // calendar days in the range
+	ActiveDays   int     `json:"active_days"` // days with sessions
+	ActivityRate float64 `json:"activity_rate"`
+	TotalHours   float64 `json:"total_hours"`
+	TargetHours  float64 `json:"target_hours"`
+
+	Weekdays []Weekday `json:"weekdays"` // Monday first
+	// Starts and Ends count the days whose first check-in and last
+	// check-out fall in each hour of the day.
+	Starts [24]int `json:"starts"`
+	Ends   [24]int `json:"ends"`
+
+	LongestStreak Streak    `json:"longest_streak"`
+	CurrentStreak Streak    `json:"current_streak"`
+	LatestEnds    []WeekEnd `json:"latest_ends"` // newest week first
+	Daily         []Day     `json:"daily"`       // every day of the range, oldest first
+}
+
+// Compute analyzes the closed sessions. Sessions count on the day of their
+// check-in, like report.AggregateBy.
+func Compute(sessions []Session, opts Options) (Stats, error) {
+	days := map[string]*Day{}
+	var first, last time.Time
+	for _, session := range sessions {
+		if session.Start.IsZero() || session.End.IsZero() {
+			continue
+		}
+		start, end := session.Start.In(time.Local), session.End.In(time.Local)
+		date := start.Format(takt.DateFormat)
+		d, ok := days[date]
+		if !ok {
+			d = &Day{Date: date, Start: start, End: end}
+			days[date] = d
+		}
+		d.Hours += end.Sub(start).Hours()
+		if start.Before(d.Start) {
+			d.Start = start
+		}
+		if end.After(d.End) {
+			d.End = end
+		}
+		if first.IsZero() || start.Before(first) {
+			first = start
+		}
+		if start.After(last) {
+			last = start
+		}
+	}
+	if len(days) == 0 {
+		return Stats{}, fmt.Errorf("no sessions to analyze")
+	}
+
+	from, to := dateOf(first), dateOf(last)
+	if !opts.From.IsZero() {
+		from = dateOf(opts.From)
+	}
+	if !opts.To.IsZero() {
+		to = dateOf(opts.To)
+	} else if !opts.Now.IsZero() {
+		to = dateOf(opts.Now)
+	}
+	if to.Before(from) {
+		return Stats{}, fmt.Errorf("the range ends before it starts: %s to %s", from.Format(takt.DateFormat), to.Format(takt.DateFormat))
+	}
+
+	s := Stats{From: from.Format(takt.DateFormat), To: to.Format(takt.DateFormat), TargetHours: opts.TargetHours}
+	weekdays := [7]Weekday{}
+	latest := map[string]WeekEnd{}
+	var streak Streak
+	var today time.Time
+	if !opts.Now.IsZero() {
+		today = dateOf(opts.Now)
+	}
+	for t := from; !t.After(to); t = t.AddDate(0, 0, 1) {
+		s.Days++
+		date := t.Format(takt.DateFormat)
+		d, worked := days[date]
+		if !worked {
+			d = &Day{Date: date}
+		}
+		s.Daily = append(s.Daily, *d)
+
+		// streaks skip days off unless they are worked
+		switch {
+		case worked && opts.TargetHours > 0 && d.Hours >= opts.TargetHours:
+			if streak.Days == 0 {
+				streak.From = date
+			}
+			streak.Days++
+			streak.To = date
+		case t.Equal(today):
+			// today may still reach the target
+		case worked || opts.Schedule.IsWorkday(t):
+			streak = Streak{}
+		}
+		if streak.Days > s.LongestStreak.Days {
+			s.LongestStreak = streak
+		}
+		if !worked {
+			continue
+		}
+
+		s.ActiveDays++
+		s.TotalHours += d.Hours
+		w := &weekdays[(int(t.Weekday())+6)%7] // Monday first
+		w.Days++
+		w.TotalHours += d.Hours
+		s.Starts[d.Start.Hour()]++
+		s.Ends[d.End.Hour()]++
+
+		year, week := t.ISOWeek()
+		label := fmt.Sprintf("%d-W%02d", year, week)
+		if e, ok := latest[label]; !ok || d.End.Sub(t) > e.After {
+			latest[label] = WeekEnd{Week: label, Date: date, End: d.End, After: d.End.Sub(t)}
+		}
+	}
+	s.CurrentStreak = streak
+	s.ActivityRate = float64(s.ActiveDays) / float64(s.Days) * 100
+
+	for i := range weekdays {
+		weekdays[i].Weekday = time.Weekday((i + 1) % 7).String()[:3]
+		if weekdays[i].Days > 0 {
+			weekdays[i].AverageHours = weekdays[i].TotalHours / float64(weekdays[i].Days)
+		}
+	}
+	s.Weekdays = weekdays[:]
+
+	for _, e := range latest {
+		s.LatestEnds = append(s.LatestEnds, e)
+	}
+	sort.Slice(s.LatestEnds, func(i, j int) bool {
+		return s.LatestEnds[i].Week > s.LatestEnds[j].Week
+	})
+	return s, nil
+}
+
+// dateOf returns midnight of t's day, in the local time zone.
+func dateOf(t time.Time) time.Time {
+	t = t.In(time.Local)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
@@ -0,0 +1,142 @@
+package stats
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// session returns a session on 2025-01-DAY.
+func session(day int, start, end string) Session {
+	at := func(hhmm string) time.Time {
+		t, _ := time.Parse("15:04", hhmm)
+		return time.Date(2025, 1, day, t.Hour(), t.Minute(), 0, 0, time.UTC)
+	}
+	out := at(end)
+	if !out.After(at(start)) {
+		out = out.AddDate(0, 0, 1)
+	}
+	return Session{Start: at(start), End: out}
+}
+
+func testSessions() []Session {
+	return []Session{
+		session(14, "10:00", "19:00"), // Tue 9h
+		session(13, "14:00", "18:00"), // Mon 4h in two sessions
+		session(13, "08:00", "12:00"),
+		session(10, "09:00", "00:30"),                         // Fri 15h30m, past midnight
+		session(9, "09:00", "17:00"),                          // Thu 8h
+		session(8, "09:00", "12:00"),                          // Wed 3h
+		{Start: time.Date(2025, 1, 15, 9, 0, 0, 0, time.UTC)}, // open, left out
+	}
+}
+
+func TestCompute(t *testing.T) {
+	original := time.Local
+	time.Local = time.UTC
+	t.Cleanup(func() { time.Local = original })
+
+	s, err := Compute(testSessions(), Options{TargetHours: 8})
+	if err != nil {
+		t.Fatalf("Compute() failed: %v", err)
+	}
+	if s.From != "2025-01-08" || s.To != "2025-01-14" || s.Days != 7 || s.ActiveDays != 5 {
+		t.Errorf("Unexpected range: %+v", s)
+	}
+	if s.TotalHours != 43.5 {
+		t.Errorf("TotalHours = %v, want 43.5", s.TotalHours)
+	}
+	if mon := s.Weekdays[0]; mon.Weekday != "Mon" || mon.Days != 1 || mon.AverageHours != 8 {
+		t.Errorf("Monday = %+v, want 8h on one day", mon)
+	}
+	if sun := s.Weekdays[6]; sun.Weekday != "Sun" || sun.Days != 0 {
+		t.Errorf("Sunday = %+v, want no days", sun)
+	}
+	if s.Starts[8] != 1 || s.Starts[9] != 3 || s.Starts[10] != 1 || s.Starts[14] != 0 {
+		t.Errorf("Starts = %v", s.Starts)
+	}
+	if s.Ends[0] != 1 || s.Ends[18] != 1 || s.Ends[12] != 1 {
+		t.Errorf("Ends = %v", s.Ends)
+	}
+
+	// Thu and Fri meet the target, the weekend is skipped, then Mon and Tue
+	want := Streak{Days: 4, From: "2025-01-09", To: "2025-01-14"}
+	if s.LongestStreak != want || s.CurrentStreak != want {
+		t.Errorf("Streaks = %+v and %+v, want %+v", s.LongestStreak, s.CurrentStreak, want)
+	}
+
+	if len(s.LatestEnds) != 2 || s.LatestEnds[0].Week != "2025-W03" || s.LatestEnds[1].Date != "2025-01-10" {
+		t.Fatalf("LatestEnds = %+v", s.LatestEnds)
+	}
+
+	var b strings.Builder
+	if err := s.WriteText(&b); err != nil {
+		t.Fatalf("WriteText() failed: %v", err)
+	}
+	for _, line := range []string{
+		"  Active days: 5 of 7 (71.4%), 1d19h30m in total\n",
+		"  Daily hours: ▂▅█▁▁▅▅\n",
+		"  Fri 15h30m   1d  ██████████████████████████████\n",
+		"  Longest: 4 days (2025-01-09 to 2025-01-14)\n",
+		"  2025-W02  2025-01-10 00:30+1  ██████████████████████████████\n",
+		"  23:00   0  \n  00:00   1  ██████████████████████████████\n",
+	} {
+		if !strings.Contains(b.String(), line) {
+			t.Errorf("Text is missing %q:\n%s", line, b.String())
+		}
+	}
+}
+
+func TestComputeRange(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.Local)
+	to := time.Date(2025, 1, 31, 0, 0, 0, 0, time.Local)
+	s, err := Compute(testSessions(), Options{TargetHours: 8, From: from, To: to})
+	if err != nil {
+		t.Fatalf("Compute() failed: %v", err)
+	}
+	if s.Days != 31 || len(s.Daily) != 31 || s.CurrentStreak.Days != 0 {
+		t.Errorf("Unexpected stats of January: %d days, current streak %+v", s.Days, s.CurrentStreak)
+	}
+
+	if _, err := Compute(testSessions(), Options{From: to, To: from}); err == nil {
+		t.Error("Expected an error for a reversed range")
+	}
+	if _, err := Compute(nil, Options{}); err == nil {
+		t.Error("Expected an error without sessions")
+	}
+}
+
+func TestComputeUntilNow(t *testing.T) {
+	original := time.Local
+	time.Local = time.UTC
+	t.Cleanup(func() { time.Local = original })
+
+	// Wednesday morning, after Mon and Tue at the target
+	sessions := append([]Session{session(15, "08:00", "10:00")}, testSessions()...)
+	s, err := Compute(sessions, Options{TargetHours: 8, Now: time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatalf("Compute() failed: %v", err)
+	}
+	want := Streak{Days: 4, From: "2025-01-09", To: "2025-01-14"}
+	if s.To != "2025-01-15" || s.CurrentStreak != want {
+		t.Errorf("Range to %s, current streak %+v, want to 2025-01-15 and %+v", s.To, s.CurrentStreak, want)
+	}
+
+	// a workday missed before today ends it
+	s, err = Compute(testSessions(), Options{TargetHours: 8, Now: time.Date(2025, 1, 16, 9, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatalf("Compute() failed: %v", err)
+	}
+	if s.To != "2025-01-16" || s.CurrentStreak.Days != 0 {
+		t.Errorf("Range to %s, current streak %+v, want to 2025-01-16 and none", s.To, s.CurrentStreak)
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	if got := Sparkline([]float64{0, 1, 2, 4, 8}); got != "▁▂▃▅█" {
+		t.Errorf("Sparkline() = %q", got)
+	}
+	if got := Bar(1, 100, 10); got != "█" {
+		t.Errorf("Bar() of a small value = %q, want one block", got)
+	}
+}
@@ -0,0 +1,397 @@
+// Package store reads and writes takt records in a CSV file, newest first,
+// with a header line and a .bak backup of the previous contents.
+package store
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/takt"
+)
+
+// BackupSuffix is appended to the file name of the backup.
+const BackupSuffix = ".bak"
+
+// Store is a records file.
+type Store struct {
+	FileName string
+	// Clock stamps new records and rejects future ones; nil means the
+	// system clock.
+	Clock takt.Clock
+	// Logger reports invalid lines dropped while reading; nil means the
+	// standard logger.
+	Logger *log.Logger
+	// Guard, when set, vets every change of the file with the records
+	// before and after it; an error refuses the change.
+	Guard func(old, new []takt.Record) error
+}
+
+// New returns the store of fileName.
+func New(fileName string, clock takt.Clock) *Store {
+	return &Store{FileName: fileName, Clock: clock}
+}
+
+// now returns the current time of the store's clock.
+func (s *Store) now() time.Time {
+	if s.Clock == nil {
+		return takt.SystemClock.Now()
+	}
+	return s.Clock.Now()
+}
+
+// logf logs to the store's logger.
+func (s *Store) logf(format string, args ...any) {
+	if s.Logger == nil {
+		log.Printf(format, args...)
+		return
+	}
+	s.Logger.Printf(format, args...)
+}
+
+// guard runs the Guard, if any, on the change of the file to records.
+func (s *Store) guard(records []takt.Record) error {
+	if s.Guard == nil {
+		return nil
+	}
+	old, err := s.current()
+	if err != nil {
+		return err
+	}
+	return s.Guard(old, records)
+}
+
+// current returns the records of the file as they are, invalid ones
+// included as long as they have a time; a missing file has none.
+func (s *Store) current() ([]takt.Record, error) {
+	file, err := os.Open(s.FileName)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	lines, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("could not read CSV: %w", err)
+	}
+	var records []takt.Record
+	for i, line := range lines {
+		if i == 0 || len(line) == 0 {
+			continue
+		}
+		timestamp, err := time.Parse(takt.TimeFormat, line[0])
+		if err != nil {
+			continue
+		}
+		record := takt.Record{Timestamp: timestamp}
+		if len(line) > 1 {
+			record.Kind = line[1]
+		}
+		if len(line) > 2 {
+			record.Notes = strings.Join(line[2:], ",")
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// Create creates the file with just the header.
+func (s *Store) Create() error {
+	return s.WriteAtomic(nil)
+}
+
+// Read reads the head newest records (all when head < 0). Missing files are
+// created; invalid lines are logged and removed from the file.
+func (s *Store) Read(head int) ([]takt.Record, error) {
+	if _, err := os.Stat(s.FileName); os.IsNotExist(err) {
+		if err := s.Create(); err != nil {
+			return nil, fmt.Errorf("failed to create file: %w", err)
+		}
+	}
+
+	// Create backup before reading
+	if err := s.Backup(); err != nil {
+		return nil, fmt.Errorf("could not create backup: %w", err)
+	}
+
+	lines, err := readCSV(s.FileName)
+	if err != nil {
+		// Try to recover from backup
+		if records, err := s.Recover(); err == nil {
+			return records, nil
+		}
+		return nil, fmt.Errorf("could not read CSV: %w", err)
+	}
+
+	if head == 0 || len(lines) < 2 {
+		return nil, nil
+	}
+
+	records, invalidLines := parseLines(lines[1:], s.now())
+	if len(invalidLines) > 0 {
+		s.logf("Warning: found %d invalid records at lines: %v", len(invalidLines), invalidLines)
+
+		// Write only valid records back to file
+		if err := s.WriteValid(records); err != nil {
+			s.logf("Error: could not clean up invalid records: %v", err)
+		}
+	}
+
+	if head > 0 && len(records) > head {
+		return records[:head], nil
+	}
+	return records, nil
+}
+
+// readCSV reads all lines of a CSV file.
+func readCSV(fileName string) ([][]string, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("could not open file: %w", err)
+	}
+	defer file.Close()
+	return csv.NewReader(file).ReadAll()
+}
+
+// Parse parses the records of a records file without writing anything:
+// lines starting with # are comments, and invalid lines are left out and
+// returned by their 1-based numbers after the header. The records are
+// sorted newest first.
+func Parse(r io.Reader, now time.Time) ([]takt.Record, []int, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	lines, err := reader.ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not read CSV: %w", err)
+	}
+	if len(lines) < 2 {
+		return nil, nil, nil
+	}
+	records, invalidLines := parseLines(lines[1:], now)
+	takt.SortRecords(records)
+	return records, invalidLines, nil
+}
+
+// parseLines parses CSV lines without the header into valid records and
+// the 1-based numbers of the invalid lines.
+func parseLines(lines [][]string, now time.Time) ([]takt.Record, []int) {
+	var records []takt.Record
+	var invalidLines []int
+	for i, line := range lines {
+		if len(line) != 3 {
+			invalidLines = append(invalidLines, i+1)
+			continue
+		}
+
+		timestamp, err := time.Parse(takt.TimeFormat, line[0])
+		if err != nil {
+			invalidLines = append(invalidLines, i+1)
+			continue
+		}
+
+		record := takt.Record{Timestamp: timestamp, Kind: line[1], Notes: line[2]}
+		if err := takt.ValidateRecord(record, now); err != nil {
+			invalidLines = append(invalidLines, i+1)
+			continue
+		}
+
+		records = append(records, record)
+	}
+	return records, invalidLines
+}
+
+// Backup copies the file to its backup.
+func (s *Store) Backup() error {
+	source, err := os.Open(s.FileName)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	destination, err := os.Create(s.FileName + BackupSuffix)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(destination, source); err != nil {
+		_ = destination.Close()
+		return err
+	}
+	return destination.Close()
+}
+
+// Recover reads the records of the backup.
+func (s *Store) Recover() ([]takt.Record, error) {
+	lines, err := readCSV(s.FileName + BackupSuffix)
+	if err != nil {
+		return nil, fmt.Errorf("could not recover from backup: %w", err)
+	}
+	if len(lines) < 2 {
+		return nil, nil
+	}
+	records, _ := parseLines(lines[1:], s.now())
+	return records, nil
+}
+
+// WriteValid writes records back to the file in place.
+func (s *Store) WriteValid(records []takt.Record) error {
+	if err := s.guard(records); err != nil {
+		return err
+	}
+	file, err := os.Create(s.FileName)
+	if err != nil {
+		return err
+	}
+	if err := writeCSV(file, records); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// WriteAtomic writes records to a temporary file next to the file and
+// renames it over the file, so readers never see a partially written file.
+func (s *Store) WriteAtomic(records []takt.Record) error {
+	if err := s.guard(records); err != nil {
+		return err
+	}
+	return ReplaceFile(s.FileName, func(w io.Writer) error {
+		return writeCSV(w, records)
+	})
+}
+
+// Prepend adds a record at the top of the file, atomically.
+func (s *Store) Prepend(record takt.Record) error {
+	line, err := CSVLine(record.Timestamp.Format(takt.TimeFormat), record.Kind, record.Notes)
+	if err != nil {
+		return fmt.Errorf("failed to format record: %w", err)
+	}
+	if s.Guard != nil {
+		old, err := s.current()
+		if err != nil {
+			return err
+		}
+		if err := s.Guard(old, append([]takt.Record{record}, old...)); err != nil {
+			return err
+		}
+	}
+
+	prevFile, err := os.Open(s.FileName)
+	if err != nil {
+		return err
+	}
+	defer prevFile.Close()
+
+	return ReplaceFile(s.FileName, func(w io.Writer) error {
+		if _, err := fmt.Fprintf(w, "%s\n%s\n", strings.Join(takt.Header, ","), line); err != nil {
+			return err
+		}
+
+		// drop the header
+		prevReader := bufio.NewReader(prevFile)
+		if _, _, err := prevReader.ReadLine(); err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		_, err := io.Copy(w, prevReader)
+		return err
+	})
+}
+
+// Toggle adds an "in" or "out" record at now, whichever follows the
+// latest one.
+func (s *Store) Toggle(notes string) (takt.Record, error) {
+	record, err := s.Next(notes)
+	if err != nil {
+		return takt.Record{}, err
+	}
+	if err := s.Prepend(record); err != nil {
+		return takt.Record{}, fmt.Errorf("failed to write records: %w", err)
+	}
+	return record, nil
+}
+
+// Next returns the record Toggle would add, without adding it.
+func (s *Store) Next(notes string) (takt.Record, error) {
+	records, err := s.Read(1)
+	if err != nil {
+		return takt.Record{}, fmt.Errorf("failed to read records: %w", err)
+	}
+
+	// stamped at the file's precision
+	now := s.now().Truncate(time.Second)
+	return takt.Record{Timestamp: now, Kind: takt.NextKind(records), Notes: notes}, nil
+}
+
+// CSVLine formats fields as a single CSV line without the trailing newline,
+// quoting notes that contain commas or quotes.
+func CSVLine(fields ...string) (string, error) {
+	var sb strings.Builder
+	writer := csv.NewWriter(&sb)
+	if err := writer.Write(fields); err != nil {
+		return "", err
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(sb.String(), "\n"), nil
+}
+
+// writeCSV writes the header and records.
+func writeCSV(w io.Writer, records []takt.Record) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(takt.Header); err != nil {
+		return err
+	}
+	for _, record := range records {
+		line := []string{record.Timestamp.Format(takt.TimeFormat), record.Kind, record.Notes}
+		if err := writer.Write(line); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// ReplaceFile writes a temporary file next to fileName with write, then
+// renames it over fileName keeping its permissions.
+func ReplaceFile(fileName string, write func(io.Writer) error) error {
+	tmpFile, err := os.CreateTemp(filepath.Dir(fileName), ".takt_*.csv")
+	if err != nil {
+		return fmt.Errorf("could not create temp file: %w", err)
+	}
+	defer func() {
+		// no-op once renamed
+		_ = os.Remove(tmpFile.Name())
+	}()
+
+	if err := write(tmpFile); err != nil {
+		_ = tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Sync(); err != nil {
+		_ = tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(fileName); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.Chmod(tmpFile.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile.Name(), fileName)
+}
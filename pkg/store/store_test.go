@@ -0,0 +1,167 @@
+package store
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/takt"
+)
+
+// newTestStore returns a store over a file with the given content in a
+// temporary directory, with a fixed clock.
+func newTestStore(t *testing.T, content string, now time.Time) *Store {
+	t.Helper()
+	fileName := filepath.Join(t.TempDir(), "takt.csv")
+	if content != "" {
+		if err := os.WriteFile(fileName, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write test data: %v", err)
+		}
+	}
+	return New(fileName, takt.FixedClock(now))
+}
+
+func TestToggle(t *testing.T) {
+	now := time.Date(2025, 1, 9, 9, 0, 0, 0, time.UTC)
+	s := newTestStore(t, "", now)
+
+	record, err := s.Toggle("start, with a comma")
+	if err != nil {
+		t.Fatalf("Toggle() failed: %v", err)
+	}
+	if record.Kind != takt.KindIn || !record.Timestamp.Equal(now) {
+		t.Errorf("Unexpected first record: %+v", record)
+	}
+
+	s.Clock = takt.FixedClock(now.Add(8 * time.Hour))
+	if record, err = s.Toggle("done"); err != nil || record.Kind != takt.KindOut {
+		t.Fatalf("Toggle() = %+v, %v", record, err)
+	}
+
+	data, err := os.ReadFile(s.FileName)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	expected := "timestamp,kind,notes\n" +
+		"2025-01-09T17:00:00Z,out,done\n" +
+		"2025-01-09T09:00:00Z,in,\"start, with a comma\"\n"
+	if string(data) != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, data)
+	}
+}
+
+func TestReadDropsInvalidLines(t *testing.T) {
+	now := time.Date(2025, 1, 9, 12, 0, 0, 0, time.UTC)
+	s := newTestStore(t, "timestamp,kind,notes\n"+
+		"2025-01-10T09:00:00Z,in,future\n"+
+		"2025-01-09T11:00:00Z,out,\n"+
+		"not a time,in,\n"+
+		"2025-01-09T09:00:00Z,in,work\n", now)
+	var logs bytes.Buffer
+	s.Logger = log.New(&logs, "", 0)
+
+	records, err := s.Read(-1)
+	if err != nil {
+		t.Fatalf("Read() failed: %v", err)
+	}
+	if len(records) != 2 || records[1].Notes != "work" {
+		t.Errorf("Unexpected records: %+v", records)
+	}
+	if !strings.Contains(logs.String(), "found 2 invalid records at lines: [1 3]") {
+		t.Errorf("Unexpected log: %q", logs.String())
+	}
+	if data, _ := os.ReadFile(s.FileName); strings.Contains(string(data), "future") {
+		t.Errorf("Expected invalid lines to be removed, got:\n%s", data)
+	}
+	if _, err := os.Stat(s.FileName + BackupSuffix); err != nil {
+		t.Errorf("Expected a backup: %v", err)
+	}
+
+	head, err := s.Read(1)
+	if err != nil || len(head) != 1 || head[0].Kind != takt.KindOut {
+		t.Errorf("Read(1) = %+v, %v", head, err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	now := time.Date(2025, 1, 9, 12, 0, 0, 0, time.UTC)
+	records, invalidLines, err := Parse(strings.NewReader("# user: Ana\n"+
+		"timestamp,kind,notes\n"+
+		"2025-01-09T09:00:00Z,in,work\n"+
+		"2025-01-09T11:00:00Z,out\n"+
+		"# a comment\n"+
+		"2025-01-09T11:00:00Z,out,done\n"), now)
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+	if len(records) != 2 || records[0].Notes != "done" || records[1].Notes != "work" {
+		t.Errorf("Expected the records newest first, got %+v", records)
+	}
+	if len(invalidLines) != 1 || invalidLines[0] != 2 {
+		t.Errorf("Expected line 2 to be invalid, got %v", invalidLines)
+	}
+}
+
+func TestWriteAtomicKeepsPermissions(t *testing.T) {
+	now := time.Date(2025, 1, 9, 12, 0, 0, 0, time.UTC)
+	s := newTestStore(t, "timestamp,kind,notes\n", now)
+	if err := os.Chmod(s.FileName, 0o600); err != nil {
+		t.Fatalf("Chmod failed: %v", err)
+	}
+
+	records := []takt.Record{{Timestamp: now, Kind: takt.KindIn, Notes: "x"}}
+	if err := s.WriteAtomic(records); err != nil {
+		t.Fatalf("WriteAtomic() failed: %v", err)
+	}
+	info, err := os.Stat(s.FileName)
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected permissions 0600, got %v", info.Mode().Perm())
+	}
+	entries, _ := os.ReadDir(filepath.Dir(s.FileName))
+	if len(entries) != 1 {
+		t.Errorf("Expected no temporary files left, got %d entries", len(entries))
+	}
+}
+
+func TestGuard(t *testing.T) {
+	now := time.Date(2025, 1, 9, 12, 0, 0, 0, time.UTC)
+	content := "timestamp,kind,notes\n" +
+		"2025-01-10T09:00:00Z,in,future\n" +
+		"2025-01-09T09:00:00Z,in,work\n"
+	s := newTestStore(t, content, now)
+	var logs bytes.Buffer
+	s.Logger = log.New(&logs, "", 0)
+	var seen [][]takt.Record
+	s.Guard = func(old, new []takt.Record) error {
+		seen = append(seen, old)
+		return errors.New("refused")
+	}
+
+	if _, err := s.Read(-1); err != nil {
+		t.Fatalf("Read() failed: %v", err)
+	}
+	if !strings.Contains(logs.String(), "refused") {
+		t.Errorf("Expected the refused cleanup to be logged, got %q", logs.String())
+	}
+	if err := s.WriteAtomic(nil); err == nil || err.Error() != "refused" {
+		t.Errorf("WriteAtomic() = %v, expected refused", err)
+	}
+	if err := s.Prepend(takt.Record{Timestamp: now, Kind: takt.KindOut}); err == nil || err.Error() != "refused" {
+		t.Errorf("Prepend() = %v, expected refused", err)
+	}
+	if data, _ := os.ReadFile(s.FileName); string(data) != content {
+		t.Errorf("Expected the file to be left alone, got:\n%s", data)
+	}
+	// the guard sees the invalid but dated lines of the file as records
+	if len(seen) != 3 || len(seen[0]) != 2 {
+		t.Errorf("Unexpected records seen by the guard: %+v", seen)
+	}
+}
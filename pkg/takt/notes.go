@@ -0,0 +1,32 @@
+package takt
+
+import "strings"
+
+// Prefixes of the words of notes that name a project (+acme) or a tag
+// (#focus)
+const (
+	ProjectPrefix = "+"
+	TagPrefix     = "#"
+)
+
+// Projects returns the projects named in notes, lowercased, without the
+// prefix.
+func Projects(notes string) []string {
+	return words(notes, ProjectPrefix)
+}
+
+// Tags returns the tags in notes, lowercased, without the prefix.
+func Tags(notes string) []string {
+	return words(notes, TagPrefix)
+}
+
+// words returns the words of notes with prefix, lowercased, without it.
+func words(notes, prefix string) []string {
+	var out []string
+	for _, word := range strings.Fields(notes) {
+		if name := strings.TrimPrefix(word, prefix); name != word && name != "" {
+			out = append(out, strings.ToLower(name))
+		}
+	}
+	return out
+}
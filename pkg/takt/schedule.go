@@ -0,0 +1,95 @@
+package takt
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultDayEnd is the end of a working day when none is configured.
+const DefaultDayEnd = 18 * time.Hour
+
+// Schedule is the working schedule.
+type Schedule struct {
+	// DayEnd is the end of a working day, as the time since midnight.
+	DayEnd time.Duration
+	// Workdays are the working days of the week; nil means Monday to
+	// Friday.
+	Workdays []time.Weekday
+}
+
+// IsWorkday reports whether t falls on a working day.
+func (s Schedule) IsWorkday(t time.Time) bool {
+	if s.Workdays == nil {
+		return t.Weekday() != time.Saturday && t.Weekday() != time.Sunday
+	}
+	for _, day := range s.Workdays {
+		if t.Weekday() == day {
+			return true
+		}
+	}
+	return false
+}
+
+// weekdays are the names of the days of the week, as in time.Weekday.
+var weekdays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// ParseWorkdays parses working days such as "mon-fri", "mon,wed,fri" or
+// "sun-thu".
+func ParseWorkdays(value string) ([]time.Weekday, error) {
+	day := func(name string) (time.Weekday, error) {
+		name = strings.ToLower(strings.TrimSpace(name))
+		for i, short := range weekdays {
+			if name == short || name == strings.ToLower(time.Weekday(i).String()) {
+				return time.Weekday(i), nil
+			}
+		}
+		return 0, fmt.Errorf("invalid day %q: expected mon, tue, wed, thu, fri, sat or sun", name)
+	}
+
+	var days []time.Weekday
+	seen := map[time.Weekday]bool{}
+	for _, part := range strings.Split(value, ",") {
+		first, last, isRange := strings.Cut(part, "-")
+		from, err := day(first)
+		if err != nil {
+			return nil, err
+		}
+		to := from
+		if isRange {
+			if to, err = day(last); err != nil {
+				return nil, err
+			}
+		}
+		for d := from; ; d = (d + 1) % 7 {
+			if !seen[d] {
+				seen[d] = true
+				days = append(days, d)
+			}
+			if d == to {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+// EndOfDay returns the end of the working day of t, in t's location.
+func (s Schedule) EndOfDay(t time.Time) time.Time {
+	return atTimeOfDay(t, s.DayEnd)
+}
+
+// atTimeOfDay returns the time of day d on the date of t, in t's location.
+func atTimeOfDay(t time.Time, d time.Duration) time.Time {
+	y, m, day := t.Date()
+	return time.Date(y, m, day, int(d/time.Hour), int(d%time.Hour/time.Minute), 0, 0, t.Location())
+}
+
+// ParseTimeOfDay parses HH:MM into the time since midnight.
+func ParseTimeOfDay(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q: expected HH:MM", value)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
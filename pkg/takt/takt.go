@@ -0,0 +1,86 @@
+// Package takt holds the core types of takt: records of checking in and out,
+// and the clock that stamps them.
+package takt
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	// Record kinds
+	KindIn  = "in"
+	KindOut = "out"
+
+	// Time format constants
+	TimeFormat = time.RFC3339
+	DateFormat = "2006-01-02"
+
+	DefaultTargetHours = 8.0
+)
+
+// Header is the header line of a records file.
+var Header = []string{"timestamp", "kind", "notes"}
+
+// Record is a check-in or check-out.
+type Record struct {
+	Timestamp time.Time
+	Kind      string
+	Notes     string
+}
+
+// Clock tells the current time. Everything that depends on "now" (stamping
+// records, open sessions, rejecting future records) asks a Clock, so that
+// callers can pin time.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc adapts a function to a Clock.
+type ClockFunc func() time.Time
+
+// Now returns f().
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// SystemClock is the wall clock.
+var SystemClock Clock = ClockFunc(time.Now)
+
+// FixedClock returns a clock stopped at t.
+func FixedClock(t time.Time) Clock {
+	return ClockFunc(func() time.Time { return t })
+}
+
+// ValidateRecord checks that a record has a timestamp no later than now and
+// a known kind.
+func ValidateRecord(record Record, now time.Time) error {
+	if record.Timestamp.IsZero() {
+		return errors.New("invalid timestamp")
+	}
+	if record.Kind != KindIn && record.Kind != KindOut {
+		return fmt.Errorf("invalid kind: %s (must be '%s' or '%s')", record.Kind, KindIn, KindOut)
+	}
+	if record.Timestamp.After(now) {
+		return fmt.Errorf("timestamp in future: %v", record.Timestamp)
+	}
+	return nil
+}
+
+// SortRecords sorts records newest first, the order used in the file.
+func SortRecords(records []Record) {
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Timestamp.After(records[j].Timestamp)
+	})
+}
+
+// NextKind returns the kind of the record that follows records (newest
+// first): "in" after a check-out or when there are none, "out" otherwise.
+func NextKind(records []Record) string {
+	if len(records) == 0 || records[0].Kind == KindOut {
+		return KindIn
+	}
+	return KindOut
+}
@@ -0,0 +1,120 @@
+package takt
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestValidateRecord(t *testing.T) {
+	now := time.Date(2025, 1, 9, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		record  Record
+		wantErr bool
+	}{
+		{"valid in", Record{now.Add(-time.Hour), KindIn, "notes"}, false},
+		{"valid out at now", Record{now, KindOut, ""}, false},
+		{"zero timestamp", Record{time.Time{}, KindIn, ""}, true},
+		{"unknown kind", Record{now, "pause", ""}, true},
+		{"future", Record{now.Add(time.Second), KindIn, ""}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateRecord(tt.record, now); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateRecord() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNextKindAndSort(t *testing.T) {
+	now := time.Date(2025, 1, 9, 12, 0, 0, 0, time.UTC)
+	records := []Record{
+		{now.Add(-3 * time.Hour), KindIn, ""},
+		{now.Add(-time.Hour), KindOut, ""},
+	}
+	SortRecords(records)
+	if records[0].Kind != KindOut {
+		t.Fatalf("Expected the newest record first, got %+v", records)
+	}
+	if got := NextKind(records); got != KindIn {
+		t.Errorf("Expected %q after a check-out, got %q", KindIn, got)
+	}
+	if got := NextKind(records[1:]); got != KindOut {
+		t.Errorf("Expected %q after a check-in, got %q", KindOut, got)
+	}
+	if got := NextKind(nil); got != KindIn {
+		t.Errorf("Expected %q without records, got %q", KindIn, got)
+	}
+}
+
+func TestFixedClock(t *testing.T) {
+	at := time.Date(2025, 1, 9, 12, 0, 0, 0, time.UTC)
+	if got := FixedClock(at).Now(); !got.Equal(at) {
+		t.Errorf("Expected %v, got %v", at, got)
+	}
+}
+
+func TestScheduleEndOfDay(t *testing.T) {
+	dayEnd, err := ParseTimeOfDay("17:30")
+	if err != nil {
+		t.Fatalf("ParseTimeOfDay() failed: %v", err)
+	}
+	zone := time.FixedZone("CET", 3600)
+	got := Schedule{DayEnd: dayEnd}.EndOfDay(time.Date(2025, 1, 9, 9, 15, 0, 0, zone))
+	if want := time.Date(2025, 1, 9, 17, 30, 0, 0, zone); !got.Equal(want) {
+		t.Errorf("EndOfDay() = %v, want %v", got, want)
+	}
+
+	for _, value := range []string{"", "25:00", "9am"} {
+		if _, err := ParseTimeOfDay(value); err == nil {
+			t.Errorf("ParseTimeOfDay(%q) succeeded, want an error", value)
+		}
+	}
+}
+
+func TestParseWorkdays(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []time.Weekday
+	}{
+		{"mon-fri", []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}},
+		{"Monday,wed, FRI", []time.Weekday{time.Monday, time.Wednesday, time.Friday}},
+		{"sun-thu", []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday}},
+		{"fri-mon", []time.Weekday{time.Friday, time.Saturday, time.Sunday, time.Monday}},
+	}
+	for _, tt := range tests {
+		got, err := ParseWorkdays(tt.value)
+		if err != nil {
+			t.Fatalf("ParseWorkdays(%q) failed: %v", tt.value, err)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("ParseWorkdays(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+
+	for _, value := range []string{"", "mon-", "funday"} {
+		if _, err := ParseWorkdays(value); err == nil {
+			t.Errorf("ParseWorkdays(%q) succeeded, want an error", value)
+		}
+	}
+
+	saturday := time.Date(2025, 1, 11, 10, 0, 0, 0, time.UTC)
+	if (Schedule{}).IsWorkday(saturday) {
+		t.Error("Saturday is a workday by default")
+	}
+	if !(Schedule{Workdays: []time.Weekday{time.Saturday}}).IsWorkday(saturday) {
+		t.Error("Saturday isn't a workday when configured")
+	}
+}
+
+func TestProjectsAndTags(t *testing.T) {
+	notes := "Review +Acme #urgent and + # #focus +admin"
+	if got := fmt.Sprint(Projects(notes)); got != "[acme admin]" {
+		t.Errorf("Projects() = %s", got)
+	}
+	if got := fmt.Sprint(Tags(notes)); got != "[urgent focus]" {
+		t.Errorf("Tags() = %s", got)
+	}
+}
@@ -0,0 +1,282 @@
+// Package team adds up the logs of a team, one records file per person:
+// the hours of every week or month, the balances against each person's
+// schedule and the workdays without any work.
+package team
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/report"
+	"github.com/asdf8601/takt-go/pkg/store"
+	"github.com/asdf8601/takt-go/pkg/takt"
+)
+
+// Keys of the "# key: value" lines that may precede the CSV header of a log
+const (
+	KeyUser     = "user"     // the person, instead of the file name
+	KeyTarget   = "target"   // target hours per workday, e.g. 7.5 or 7:30
+	KeyWorkdays = "workdays" // workdays, e.g. mon-thu
+)
+
+// Member is the log of a person.
+type Member struct {
+	User        string
+	FileName    string
+	TargetHours float64
+	Schedule    takt.Schedule
+	Records     []takt.Record
+	// InvalidLines are the 1-based numbers, after the header, of the lines
+	// left out.
+	InvalidLines []int
+}
+
+// Load reads the log of a person without writing to it. The target hours
+// and workdays of defaults apply unless the header of the log sets them;
+// the user is the file name without extension unless the header sets it.
+func Load(fileName string, defaults Member, now time.Time) (Member, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return Member{}, err
+	}
+	m := Member{
+		User:        strings.TrimSuffix(filepath.Base(fileName), filepath.Ext(fileName)),
+		FileName:    fileName,
+		TargetHours: defaults.TargetHours,
+		Schedule:    defaults.Schedule,
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "#") {
+			break
+		}
+		key, value, ok := strings.Cut(strings.TrimSpace(strings.TrimPrefix(line, "#")), ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case KeyUser:
+			m.User = value
+		case KeyTarget:
+			if m.TargetHours, err = parseTarget(value); err != nil {
+				return Member{}, fmt.Errorf("%s: %w", fileName, err)
+			}
+		case KeyWorkdays:
+			if m.Schedule.Workdays, err = takt.ParseWorkdays(value); err != nil {
+				return Member{}, fmt.Errorf("%s: %w", fileName, err)
+			}
+		}
+	}
+	if m.User == "" {
+		return Member{}, fmt.Errorf("%s: empty user", fileName)
+	}
+
+	m.Records, m.InvalidLines, err = store.Parse(bytes.NewReader(data), now)
+	if err != nil {
+		return Member{}, fmt.Errorf("%s: %w", fileName, err)
+	}
+	return m, nil
+}
+
+// parseTarget parses target hours as decimal hours or H:MM.
+func parseTarget(value string) (float64, error) {
+	if strings.Contains(value, ":") {
+		d, err := takt.ParseTimeOfDay(value)
+		if err != nil {
+			return 0, fmt.Errorf("invalid target %q: expected hours such as 7.5 or 7:30", value)
+		}
+		return d.Hours(), nil
+	}
+	hours, err := strconv.ParseFloat(value, 64)
+	if err != nil || hours < 0 {
+		return 0, fmt.Errorf("invalid target %q: expected hours such as 7.5 or 7:30", value)
+	}
+	return hours, nil
+}
+
+// LoadDir loads the logs (*.csv) of dir, sorted by user.
+func LoadDir(dir string, defaults Member, now time.Time) ([]Member, error) {
+	fileNames, err := filepath.Glob(filepath.Join(dir, "*.csv"))
+	if err != nil {
+		return nil, err
+	}
+	if len(fileNames) == 0 {
+		return nil, fmt.Errorf("no logs (*.csv) in %s", dir)
+	}
+	var members []Member
+	files := map[string]string{}
+	for _, fileName := range fileNames {
+		m, err := Load(fileName, defaults, now)
+		if err != nil {
+			return nil, err
+		}
+		if other, ok := files[m.User]; ok {
+			return nil, fmt.Errorf("user %s has two logs: %s and %s", m.User, other, fileName)
+		}
+		files[m.User] = fileName
+		members = append(members, m)
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].User < members[j].User })
+	return members, nil
+}
+
+// Options configure Compute.
+type Options struct {
+	// Config gives the rounding, the policy for open sessions and the
+	// current time; the target and schedule are those of each member.
+	Config report.Config
+	// Period is week or month.
+	Period string
+	// From and To are the first and the last day, inclusive.
+	From, To time.Time
+}
+
+// Row is the work of a member, or of the team, in a period.
+type Row struct {
+	User    string  `json:"user"`
+	Hours   float64 `json:"hours"`
+	Target  float64 `json:"target"`
+	Balance float64 `json:"balance"`
+	// Days are the days with work.
+	Days int `json:"days"`
+	// Missing are the past workdays without work, of a member.
+	Missing     []string `json:"missing,omitempty"`
+	MissingDays int      `json:"missing_days"`
+
+	// targetHours is the day unit of the balance
+	targetHours float64
+}
+
+// add adds the hours, targets and days of r to the row.
+func (row *Row) add(r Row) {
+	row.Hours += r.Hours
+	row.Target += r.Target
+	row.Balance += r.Balance
+	row.Days += r.Days
+	row.MissingDays += r.MissingDays
+}
+
+// Period is the work of the team in a week or month.
+type Period struct {
+	Period  string `json:"period"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Members []Row  `json:"members"`
+	Team    Row    `json:"team"`
+}
+
+// Report is the work of a team, period by period.
+type Report struct {
+	From    string   `json:"from"`
+	To      string   `json:"to"`
+	Period  string   `json:"period"`
+	Periods []Period `json:"periods"`
+	// Total adds up the periods.
+	Total Period `json:"total"`
+
+	config report.Config
+}
+
+// TeamUser is the user of the rows of the whole team.
+const TeamUser = "Team"
+
+// Compute adds up the work of the members in the periods from opts.From to
+// opts.To. Targets run to today; days before today without work are
+// missing when they are workdays.
+func Compute(members []Member, opts Options) (Report, error) {
+	if opts.Period != report.PeriodWeek && opts.Period != report.PeriodMonth {
+		return Report{}, fmt.Errorf("unsupported team period: %s (must be %s or %s)", opts.Period, report.PeriodWeek, report.PeriodMonth)
+	}
+	from, to := dateOf(opts.From), dateOf(opts.To)
+	if to.Before(from) {
+		return Report{}, fmt.Errorf("the range ends (%s) before it starts (%s)", to.Format(takt.DateFormat), from.Format(takt.DateFormat))
+	}
+	now := opts.Config.Now()
+	today := now.Format(takt.DateFormat)
+
+	worked := make([]map[string]float64, len(members))
+	configs := make([]report.Config, len(members))
+	for i, m := range members {
+		c := opts.Config
+		c.TargetHours, c.Schedule = m.TargetHours, m.Schedule
+		configs[i] = c
+		worked[i] = map[string]float64{}
+		if len(m.Records) == 0 {
+			continue
+		}
+		days, err := c.CalculateDuration(m.Records, report.PeriodDay)
+		if err != nil {
+			return Report{}, fmt.Errorf("%s: %w", m.User, err)
+		}
+		for _, d := range days {
+			worked[i][d.Group] = d.TotalHours
+		}
+	}
+
+	r := Report{
+		From:   from.Format(takt.DateFormat),
+		To:     to.Format(takt.DateFormat),
+		Period: opts.Period,
+		Total:  Period{Period: "total", From: from.Format(takt.DateFormat), To: to.Format(takt.DateFormat)},
+		config: opts.Config,
+	}
+	r.Total.Team = Row{User: TeamUser, targetHours: opts.Config.TargetHours}
+	for i, m := range members {
+		r.Total.Members = append(r.Total.Members, Row{User: m.User, targetHours: configs[i].TargetHours})
+	}
+
+	for start := from; !start.After(to); {
+		_, end, label, err := report.PeriodRange(opts.Period, start)
+		if err != nil {
+			return Report{}, err
+		}
+		if end.After(to) {
+			end = to
+		}
+		p := Period{Period: label, From: start.Format(takt.DateFormat), To: end.Format(takt.DateFormat)}
+		p.Team = Row{User: TeamUser, targetHours: opts.Config.TargetHours}
+		for i, m := range members {
+			row := Row{User: m.User, targetHours: configs[i].TargetHours}
+			for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+				date := day.Format(takt.DateFormat)
+				hours := worked[i][date]
+				row.Hours += hours
+				if hours > 0 {
+					row.Days++
+				}
+				if date > today || !m.Schedule.IsWorkday(day) {
+					continue
+				}
+				row.Target += m.TargetHours
+				if hours == 0 && date < today {
+					row.Missing = append(row.Missing, date)
+				}
+			}
+			row.Balance = row.Hours - row.Target
+			row.MissingDays = len(row.Missing)
+			p.Members = append(p.Members, row)
+			p.Team.add(row)
+			r.Total.Members[i].add(row)
+			r.Total.Members[i].Missing = append(r.Total.Members[i].Missing, row.Missing...)
+			r.Total.Team.add(row)
+		}
+		r.Periods = append(r.Periods, p)
+		start = end.AddDate(0, 0, 1)
+	}
+	return r, nil
+}
+
+// dateOf returns the start of the day of t.
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,136 @@
+package team
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/report"
+	"github.com/asdf8601/takt-go/pkg/takt"
+)
+
+// writeLogs writes the logs by file name to a temporary directory.
+func writeLogs(t *testing.T, logs map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range logs {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+var testNow = time.Date(2025, 1, 9, 18, 0, 0, 0, time.UTC) // Thu
+
+func TestLoadDir(t *testing.T) {
+	dir := writeLogs(t, map[string]string{
+		"zoe.csv": "timestamp,kind,notes\n2025-01-06T17:00:00Z,out,\n2025-01-06T09:00:00Z,in,Work\n",
+		"b.csv": "# user: ben\n# target: 7:30\n# workdays: mon-thu\ntimestamp,kind,notes\n" +
+			"2025-01-06T16:30:00Z,out,\nnot a time,in,\n2025-01-06T09:00:00Z,in,Support\n",
+		"notes.txt": "not a log",
+	})
+	defaults := Member{TargetHours: 8}
+	members, err := LoadDir(dir, defaults, testNow)
+	if err != nil {
+		t.Fatalf("LoadDir() failed: %v", err)
+	}
+	if len(members) != 2 || members[0].User != "ben" || members[1].User != "zoe" {
+		t.Fatalf("Expected ben and zoe, got %+v", members)
+	}
+	ben, zoe := members[0], members[1]
+	if ben.TargetHours != 7.5 || len(ben.Schedule.Workdays) != 4 || len(ben.Records) != 2 || len(ben.InvalidLines) != 1 {
+		t.Errorf("Unexpected ben: %+v", ben)
+	}
+	if zoe.TargetHours != 8 || zoe.Schedule.Workdays != nil || len(zoe.Records) != 2 {
+		t.Errorf("Unexpected zoe: %+v", zoe)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "b.csv")); !strings.Contains(string(data), "not a time") {
+		t.Error("Expected the logs to be left alone")
+	}
+
+	dir = writeLogs(t, map[string]string{"a.csv": "# user: ben\n", "ben.csv": ""})
+	if _, err := LoadDir(dir, defaults, testNow); err == nil || !strings.Contains(err.Error(), "user ben has two logs") {
+		t.Errorf("Expected an error for two logs of a user, got %v", err)
+	}
+	dir = writeLogs(t, map[string]string{"a.csv": "# target: soon\n"})
+	if _, err := LoadDir(dir, defaults, testNow); err == nil || !strings.Contains(err.Error(), `invalid target "soon"`) {
+		t.Errorf("Expected an invalid target error, got %v", err)
+	}
+	if _, err := LoadDir(t.TempDir(), defaults, testNow); err == nil {
+		t.Error("Expected an error without logs")
+	}
+}
+
+func TestCompute(t *testing.T) {
+	at := func(day, hour int) time.Time { return time.Date(2025, 1, day, hour, 0, 0, 0, time.UTC) }
+	session := func(day, from, to int) []takt.Record {
+		return []takt.Record{{Timestamp: at(day, to), Kind: takt.KindOut}, {Timestamp: at(day, from), Kind: takt.KindIn}}
+	}
+	var ana, ben []takt.Record
+	ana = append(ana, session(9, 9, 17)...) // Thu
+	ana = append(ana, session(6, 9, 19)...) // Mon; Tue and Wed missing
+	ben = append(ben, session(7, 9, 15)...) // Tue; the other workdays are missing
+	members := []Member{
+		{User: "ana", TargetHours: 8, Records: ana},
+		{User: "ben", TargetHours: 6, Schedule: takt.Schedule{Workdays: []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday}}, Records: ben},
+	}
+	opts := Options{
+		Config: report.Config{TargetHours: 8, Clock: takt.FixedClock(testNow)},
+		Period: report.PeriodWeek,
+		From:   at(1, 0), // Wed
+		To:     at(12, 0),
+	}
+
+	r, err := Compute(members, opts)
+	if err != nil {
+		t.Fatalf("Compute() failed: %v", err)
+	}
+	if len(r.Periods) != 2 || r.Periods[0].Period != "2025-W01" || r.Periods[0].To != "2025-01-05" || r.Periods[1].From != "2025-01-06" {
+		t.Fatalf("Unexpected periods: %+v", r.Periods)
+	}
+
+	w02 := r.Periods[1]
+	// targets run to today, Thursday
+	if got := w02.Members[0]; got.Hours != 18 || got.Target != 32 || got.Balance != -14 || got.Days != 2 ||
+		strings.Join(got.Missing, " ") != "2025-01-07 2025-01-08" {
+		t.Errorf("Unexpected ana in 2025-W02: %+v", got)
+	}
+	if got := w02.Members[1]; got.Hours != 6 || got.Target != 18 || strings.Join(got.Missing, " ") != "2025-01-06 2025-01-08" {
+		t.Errorf("Unexpected ben in 2025-W02: %+v", got)
+	}
+	if got := w02.Team; got.Hours != 24 || got.Target != 50 || got.MissingDays != 4 {
+		t.Errorf("Unexpected team in 2025-W02: %+v", got)
+	}
+	// Wed 1 to Sun 5: ana misses Wed to Fri, ben Wed and Sun
+	if got := r.Total.Members[1]; got.Target != 30 || got.MissingDays != 4 ||
+		strings.Join(got.Missing, " ") != "2025-01-01 2025-01-05 2025-01-06 2025-01-08" {
+		t.Errorf("Unexpected total of ben: %+v", got)
+	}
+	if got := r.Total.Team; got.Hours != 24 || got.Target != 86 || got.MissingDays != 9 {
+		t.Errorf("Unexpected team total: %+v", got)
+	}
+
+	var b bytes.Buffer
+	if err := r.WriteText(&b); err != nil {
+		t.Fatalf("WriteText() failed: %v", err)
+	}
+	for _, want := range []string{
+		"Team of 2 from 2025-01-01 to 2025-01-12, by week",
+		"  ana     18h00m  1d08h00m      -1d6h     2  2025-01-07, 2025-01-08",
+		"  Team    24h00m  2d02h00m      -3d2h     3  4 days",
+		"Total  2025-01-01 to 2025-01-12",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("Expected %q in:\n%s", want, b.String())
+		}
+	}
+
+	opts.Period = report.PeriodDay
+	if _, err := Compute(members, opts); err == nil {
+		t.Error("Expected an error for an unsupported period")
+	}
+}
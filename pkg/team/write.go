@@ -0,0 +1,72 @@
+package team
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/asdf8601/takt-go/pkg/report"
+)
+
+// WriteJSON writes the report as indented JSON.
+func (r Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteText writes a table per period, and one of the totals when there
+// are several periods. Balances use each member's target hours as the day
+// unit, and the configured ones for the team.
+func (r Report) WriteText(w io.Writer) error {
+	members := 0
+	if len(r.Periods) > 0 {
+		members = len(r.Periods[0].Members)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Team of %d from %s to %s, by %s\n", members, r.From, r.To, r.Period)
+
+	width := len(TeamUser)
+	for _, row := range r.Total.Members {
+		width = max(width, len(row.User))
+	}
+	periods := r.Periods
+	if len(periods) > 1 {
+		periods = append(periods, r.Total)
+	}
+	for _, p := range periods {
+		label := p.Period
+		if p.Period == r.Total.Period {
+			label = "Total"
+		}
+		fmt.Fprintf(&b, "\n%s  %s to %s\n", label, p.From, p.To)
+		fmt.Fprintf(&b, "  %-*s  %8s  %8s  %9s  %4s  %s\n", width, "User", "Total", "Target", "Balance", "Days", "Missing")
+		for _, row := range append(p.Members, p.Team) {
+			missing := strings.Join(row.Missing, ", ")
+			if row.User == TeamUser && row.MissingDays > 0 {
+				missing = plural(row.MissingDays, "day")
+			}
+			line := fmt.Sprintf("  %-*s  %8s  %8s  %9s  %4d  %s", width, row.User,
+				report.HoursToText(row.Hours), report.HoursToText(row.Target), r.formatBalance(row), row.Days, missing)
+			b.WriteString(strings.TrimRight(line, " ") + "\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// formatBalance formats the balance of a row in days of its target hours.
+func (r Report) formatBalance(row Row) string {
+	c := r.config
+	c.TargetHours = row.targetHours
+	return c.FormatOvertime(row.Balance)
+}
+
+// plural returns n and the noun, plural unless n is 1.
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
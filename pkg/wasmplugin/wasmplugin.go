@@ -0,0 +1,382 @@
+// Package wasmplugin runs takt plugins compiled to WebAssembly in a sandbox.
+//
+// A plugin is a WASI reactor module (exporting _initialize, if anything, and
+// no _start) with no access to the filesystem, the network or the
+// environment. It exports its memory and:
+//
+//	takt_alloc(size i32) i32                 // buffer for the host's input
+//	takt_transform(ptr, len i32) i64         // optional
+//	takt_columns(ptr, len i32) i64           // optional
+//
+// The host writes JSON input into a buffer from takt_alloc and calls the
+// function with it; the result packs the pointer of the JSON output in the
+// high 32 bits and its length in the low 32 bits. A zero length means no
+// output. The host module "takt" provides:
+//
+//	records(ptr, cap i32) i32  // writes the records as JSON if cap allows, returns the length
+//	log(ptr, len i32)          // writes a message to takt's log
+package wasmplugin
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/report"
+	"github.com/asdf8601/takt-go/pkg/takt"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+const (
+	// Extension of plugin files in plugin directories
+	Extension = ".wasm"
+
+	// DefaultTimeout bounds each call into a plugin
+	DefaultTimeout = 5 * time.Second
+
+	// memoryLimitPages caps each plugin's memory at 64 MiB
+	memoryLimitPages = 1024
+)
+
+// Options configures the plugins' runtime.
+type Options struct {
+	// Records returns the records, newest first, for the records host
+	// function; nil means no records.
+	Records func() ([]takt.Record, error)
+	// Log receives log messages and the plugins' stdout and stderr; nil
+	// discards them.
+	Log io.Writer
+	// Timeout bounds each call into a plugin; zero means DefaultTimeout.
+	Timeout time.Duration
+	// CacheDir keeps compiled plugins between runs; empty means no cache.
+	CacheDir string
+}
+
+// Runtime holds loaded plugins.
+type Runtime struct {
+	Plugins []*Plugin
+	runtime wazero.Runtime
+	options Options
+}
+
+// Plugin is a loaded plugin.
+type Plugin struct {
+	Name      string
+	module    api.Module
+	alloc     api.Function
+	transform api.Function
+	columns   api.Function
+}
+
+// recordJSON is a record as exchanged with plugins.
+type recordJSON struct {
+	Timestamp string `json:"timestamp"`
+	Kind      string `json:"kind"`
+	Notes     string `json:"notes"`
+}
+
+// rowJSON is a summary row as passed to takt_columns.
+type rowJSON struct {
+	Group      string       `json:"group"`
+	TotalHours float64      `json:"total_hours"`
+	Dates      []string     `json:"dates"`
+	Records    []recordJSON `json:"records"`
+}
+
+// columnsInput is the input of takt_columns.
+type columnsInput struct {
+	Period string    `json:"period"`
+	Rows   []rowJSON `json:"rows"`
+}
+
+// columnsOutput is the output of takt_columns.
+type columnsOutput struct {
+	Columns []struct {
+		Name   string            `json:"name"`
+		Values map[string]string `json:"values"`
+	} `json:"columns"`
+}
+
+// Paths expands a list of plugin files and directories into the plugin
+// files, in name order within each directory.
+func Paths(list []string) ([]string, error) {
+	var paths []string
+	for _, path := range list {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			paths = append(paths, path)
+			continue
+		}
+		matches, err := filepath.Glob(filepath.Join(path, "*"+Extension))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(matches)
+		paths = append(paths, matches...)
+	}
+	return paths, nil
+}
+
+// Load compiles and instantiates the plugins at paths.
+func Load(ctx context.Context, paths []string, options Options) (*Runtime, error) {
+	if options.Log == nil {
+		options.Log = io.Discard
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = DefaultTimeout
+	}
+
+	runtimeConfig := wazero.NewRuntimeConfig().
+		WithCloseOnContextDone(true).
+		WithMemoryLimitPages(memoryLimitPages)
+	if options.CacheDir != "" {
+		cache, err := wazero.NewCompilationCacheWithDir(options.CacheDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open the plugin cache: %w", err)
+		}
+		runtimeConfig = runtimeConfig.WithCompilationCache(cache)
+	}
+
+	r := &Runtime{runtime: wazero.NewRuntimeWithConfig(ctx, runtimeConfig), options: options}
+	if err := r.instantiateHost(ctx); err != nil {
+		_ = r.Close(ctx)
+		return nil, err
+	}
+	for _, path := range paths {
+		p, err := r.load(ctx, path)
+		if err != nil {
+			_ = r.Close(ctx)
+			return nil, fmt.Errorf("failed to load plugin %s: %w", path, err)
+		}
+		r.Plugins = append(r.Plugins, p)
+	}
+	return r, nil
+}
+
+// Close releases the plugins.
+func (r *Runtime) Close(ctx context.Context) error {
+	return r.runtime.Close(ctx)
+}
+
+// instantiateHost provides WASI without a filesystem, for modules built
+// with a WASI toolchain, and the takt host module.
+func (r *Runtime) instantiateHost(ctx context.Context) error {
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, r.runtime); err != nil {
+		return fmt.Errorf("failed to instantiate WASI: %w", err)
+	}
+	_, err := r.runtime.NewHostModuleBuilder("takt").
+		NewFunctionBuilder().WithFunc(r.hostRecords).Export("records").
+		NewFunctionBuilder().WithFunc(r.hostLog).Export("log").
+		Instantiate(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to instantiate the takt host module: %w", err)
+	}
+	return nil
+}
+
+// hostRecords writes the records as JSON at ptr if they fit in size bytes
+// and returns their length, so a plugin can retry with a larger buffer.
+func (r *Runtime) hostRecords(ctx context.Context, m api.Module, ptr, size uint32) uint32 {
+	var records []takt.Record
+	if r.options.Records != nil {
+		var err error
+		if records, err = r.options.Records(); err != nil {
+			fmt.Fprintf(r.options.Log, "%s: failed to read records: %v\n", m.Name(), err)
+		}
+	}
+	data, err := json.Marshal(toJSON(records))
+	if err != nil {
+		panic(err)
+	}
+	if uint32(len(data)) <= size && !m.Memory().Write(ptr, data) {
+		panic(fmt.Errorf("records buffer out of range"))
+	}
+	return uint32(len(data))
+}
+
+// hostLog writes a message of a plugin to the log.
+func (r *Runtime) hostLog(ctx context.Context, m api.Module, ptr, size uint32) {
+	data, ok := m.Memory().Read(ptr, size)
+	if !ok {
+		panic(fmt.Errorf("log message out of range"))
+	}
+	fmt.Fprintf(r.options.Log, "%s: %s\n", m.Name(), strings.TrimRight(string(data), "\n"))
+}
+
+// load instantiates the plugin at path.
+func (r *Runtime) load(ctx context.Context, path string) (*Plugin, error) {
+	code, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	compiled, err := r.runtime.CompileModule(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSuffix(filepath.Base(path), Extension)
+	moduleConfig := wazero.NewModuleConfig().
+		WithName(name).
+		WithStartFunctions("_initialize").
+		WithStdout(r.options.Log).
+		WithStderr(r.options.Log).
+		WithSysWalltime().
+		WithSysNanotime().
+		WithRandSource(rand.Reader)
+	callCtx, cancel := context.WithTimeout(ctx, r.options.Timeout)
+	defer cancel()
+	module, err := r.runtime.InstantiateModule(callCtx, compiled, moduleConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Plugin{
+		Name:      name,
+		module:    module,
+		alloc:     module.ExportedFunction("takt_alloc"),
+		transform: module.ExportedFunction("takt_transform"),
+		columns:   module.ExportedFunction("takt_columns"),
+	}
+	if p.alloc == nil || module.Memory() == nil {
+		return nil, errors.New("plugin must export memory and takt_alloc")
+	}
+	return p, nil
+}
+
+// call passes input to fn and returns its output, if any.
+func (r *Runtime) call(ctx context.Context, p *Plugin, fn api.Function, input []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.options.Timeout)
+	defer cancel()
+
+	results, err := p.alloc.Call(ctx, uint64(len(input)))
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: takt_alloc: %w", p.Name, err)
+	}
+	ptr := uint32(results[0])
+	if !p.module.Memory().Write(ptr, input) {
+		return nil, fmt.Errorf("plugin %s: takt_alloc returned a buffer out of range", p.Name)
+	}
+
+	results, err = fn.Call(ctx, uint64(ptr), uint64(len(input)))
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %s: %w", p.Name, fn.Definition().Name(), err)
+	}
+	outPtr, outLen := uint32(results[0]>>32), uint32(results[0])
+	if outLen == 0 {
+		return nil, nil
+	}
+	output, ok := p.module.Memory().Read(outPtr, outLen)
+	if !ok {
+		return nil, fmt.Errorf("plugin %s: %s returned output out of range", p.Name, fn.Definition().Name())
+	}
+	return append([]byte(nil), output...), nil
+}
+
+// Transform passes a new record through the plugins' takt_transform, in
+// order, before it is written. Plugins may change only the notes.
+func (r *Runtime) Transform(ctx context.Context, record takt.Record) (takt.Record, error) {
+	for _, p := range r.Plugins {
+		if p.transform == nil {
+			continue
+		}
+		input, err := json.Marshal(toJSON([]takt.Record{record})[0])
+		if err != nil {
+			return record, err
+		}
+		output, err := r.call(ctx, p, p.transform, input)
+		if err != nil {
+			return record, err
+		}
+		if output == nil {
+			continue
+		}
+		var changed recordJSON
+		if err := json.Unmarshal(output, &changed); err != nil {
+			return record, fmt.Errorf("plugin %s: invalid takt_transform output: %w", p.Name, err)
+		}
+		record.Notes = changed.Notes
+	}
+	return record, nil
+}
+
+// Columns collects the plugins' takt_columns for the rows of a summary. It
+// has the signature of report.ColumnsFunc, plus a context.
+func (r *Runtime) Columns(ctx context.Context, period string, rows []report.AggregatedRecord, records []takt.Record) ([]report.Column, error) {
+	var withColumns []*Plugin
+	for _, p := range r.Plugins {
+		if p.columns != nil {
+			withColumns = append(withColumns, p)
+		}
+	}
+	if len(withColumns) == 0 {
+		return nil, nil
+	}
+
+	label, err := report.Labeler(period)
+	if err != nil {
+		return nil, err
+	}
+	byGroup := map[string][]takt.Record{}
+	for _, record := range records {
+		group := label(record.Timestamp)
+		byGroup[group] = append(byGroup[group], record)
+	}
+	in := columnsInput{Period: period, Rows: []rowJSON{}}
+	for _, row := range rows {
+		in.Rows = append(in.Rows, rowJSON{
+			Group:      row.Group,
+			TotalHours: row.TotalHours,
+			Dates:      row.Dates,
+			Records:    toJSON(byGroup[row.Group]),
+		})
+	}
+	input, err := json.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+
+	var columns []report.Column
+	for _, p := range withColumns {
+		output, err := r.call(ctx, p, p.columns, input)
+		if err != nil {
+			return nil, err
+		}
+		if output == nil {
+			continue
+		}
+		var out columnsOutput
+		if err := json.Unmarshal(output, &out); err != nil {
+			return nil, fmt.Errorf("plugin %s: invalid takt_columns output: %w", p.Name, err)
+		}
+		for _, col := range out.Columns {
+			columns = append(columns, report.Column{Name: col.Name, Values: col.Values})
+		}
+	}
+	return columns, nil
+}
+
+// toJSON converts records for plugins.
+func toJSON(records []takt.Record) []recordJSON {
+	out := make([]recordJSON, 0, len(records))
+	for _, record := range records {
+		out = append(out, recordJSON{
+			Timestamp: record.Timestamp.Format(takt.TimeFormat),
+			Kind:      record.Kind,
+			Notes:     record.Notes,
+		})
+	}
+	return out
+}
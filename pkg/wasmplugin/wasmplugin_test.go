@@ -0,0 +1,243 @@
+package wasmplugin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/report"
+	"github.com/asdf8601/takt-go/pkg/takt"
+)
+
+// WebAssembly opcodes used by the test modules
+const (
+	opLoop     = 0x03
+	opBr       = 0x0c
+	opEnd      = 0x0b
+	opCall     = 0x10
+	opLocalGet = 0x20
+	opI32Const = 0x41
+	opI64Const = 0x42
+	blockEmpty = 0x40
+	typeI32    = 0x7f
+	typeI64    = 0x7e
+)
+
+// Offsets in the test modules' memory
+const (
+	transformAt = 16
+	columnsAt   = 512
+	allocAt     = 4096
+	recordsAt   = 8192
+	recordsCap  = 32768
+)
+
+func uleb(v uint64) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if v == 0 {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+func sleb(v int64) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && b&0x40 == 0) || (v == -1 && b&0x40 != 0) {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+func vec(items ...[]byte) []byte {
+	out := uleb(uint64(len(items)))
+	for _, item := range items {
+		out = append(out, item...)
+	}
+	return out
+}
+
+func name(s string) []byte {
+	return append(uleb(uint64(len(s))), s...)
+}
+
+func section(id byte, content []byte) []byte {
+	return append(append([]byte{id}, uleb(uint64(len(content)))...), content...)
+}
+
+func funcType(params, results []byte) []byte {
+	return append(append([]byte{0x60}, vec(bytesOf(params)...)...), vec(bytesOf(results)...)...)
+}
+
+func bytesOf(types []byte) [][]byte {
+	var out [][]byte
+	for _, t := range types {
+		out = append(out, []byte{t})
+	}
+	return out
+}
+
+func packed(ptr, size int) []byte {
+	return append([]byte{opI64Const}, sleb(int64(ptr)<<32|int64(size))...)
+}
+
+// testModule encodes a plugin that logs its transform input and returns
+// transform, and logs the records from the host and returns columns. With
+// loop, takt_transform never returns.
+func testModule(transform, columns string, loop bool) []byte {
+	i32 := []byte{typeI32}
+	types := vec(
+		funcType([]byte{typeI32, typeI32}, i32),             // 0: records
+		funcType([]byte{typeI32, typeI32}, nil),             // 1: log
+		funcType(i32, i32),                                  // 2: takt_alloc
+		funcType([]byte{typeI32, typeI32}, []byte{typeI64}), // 3: exports
+	)
+	imports := vec(
+		append(append(name("takt"), name("records")...), 0x00, 0),
+		append(append(name("takt"), name("log")...), 0x00, 1),
+	)
+	functions := vec([]byte{2}, []byte{3}, []byte{3})
+	memory := vec([]byte{0x00, 1})
+	exports := vec(
+		append(name("memory"), 0x02, 0),
+		append(name("takt_alloc"), 0x00, 2),
+		append(name("takt_transform"), 0x00, 3),
+		append(name("takt_columns"), 0x00, 4),
+	)
+
+	alloc := append(append([]byte{opI32Const}, sleb(allocAt)...), opEnd)
+	transformBody := []byte{opLocalGet, 0, opLocalGet, 1, opCall, 1}
+	if loop {
+		transformBody = append(transformBody, opLoop, blockEmpty, opBr, 0, opEnd)
+	}
+	transformBody = append(append(transformBody, packed(transformAt, len(transform))...), opEnd)
+	columnsBody := []byte{opI32Const}
+	columnsBody = append(columnsBody, sleb(recordsAt)...)
+	columnsBody = append(columnsBody, opI32Const)
+	columnsBody = append(columnsBody, sleb(recordsAt)...)
+	columnsBody = append(columnsBody, opI32Const)
+	columnsBody = append(columnsBody, sleb(recordsCap)...)
+	columnsBody = append(columnsBody, opCall, 0, opCall, 1)
+	columnsBody = append(append(columnsBody, packed(columnsAt, len(columns))...), opEnd)
+	body := func(code []byte) []byte {
+		code = append([]byte{0}, code...) // no locals
+		return append(uleb(uint64(len(code))), code...)
+	}
+	code := vec(body(alloc), body(transformBody), body(columnsBody))
+
+	data := func(offset int, s string) []byte {
+		out := []byte{0x00, opI32Const}
+		out = append(out, sleb(int64(offset))...)
+		out = append(out, opEnd)
+		return append(out, name(s)...)
+	}
+	dataSegments := vec(data(transformAt, transform), data(columnsAt, columns))
+
+	module := []byte{0x00, 'a', 's', 'm', 1, 0, 0, 0}
+	module = append(module, section(1, types)...)
+	module = append(module, section(2, imports)...)
+	module = append(module, section(3, functions)...)
+	module = append(module, section(5, memory)...)
+	module = append(module, section(7, exports)...)
+	module = append(module, section(10, code)...)
+	module = append(module, section(11, dataSegments)...)
+	return module
+}
+
+func writeModule(t *testing.T, dir, fileName string, module []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, fileName)
+	if err := os.WriteFile(path, module, 0o644); err != nil {
+		t.Fatalf("Failed to write module: %v", err)
+	}
+	return path
+}
+
+var testRecords = []takt.Record{
+	{Timestamp: time.Date(2025, 1, 9, 17, 0, 0, 0, time.UTC), Kind: takt.KindOut},
+	{Timestamp: time.Date(2025, 1, 9, 9, 0, 0, 0, time.UTC), Kind: takt.KindIn, Notes: "+acme"},
+}
+
+func TestRuntime(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	writeModule(t, dir, "projects.wasm", testModule(
+		`{"notes":"standup +acme"}`,
+		`{"columns":[{"name":"Project","values":{"2025-01-09":"acme"}}]}`,
+		false,
+	))
+	writeModule(t, dir, "notes.txt", nil)
+
+	paths, err := Paths([]string{dir})
+	if err != nil {
+		t.Fatalf("Paths() failed: %v", err)
+	}
+	if len(paths) != 1 || filepath.Base(paths[0]) != "projects.wasm" {
+		t.Fatalf("Paths() = %q, want the .wasm file only", paths)
+	}
+
+	var log strings.Builder
+	r, err := Load(ctx, paths, Options{
+		Records: func() ([]takt.Record, error) { return testRecords, nil },
+		Log:     &log,
+	})
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	defer func() { _ = r.Close(ctx) }()
+
+	record := takt.Record{Timestamp: time.Date(2025, 1, 10, 9, 0, 0, 0, time.UTC), Kind: takt.KindIn, Notes: "standup"}
+	got, err := r.Transform(ctx, record)
+	if err != nil {
+		t.Fatalf("Transform() failed: %v", err)
+	}
+	if got.Notes != "standup +acme" || !got.Timestamp.Equal(record.Timestamp) || got.Kind != record.Kind {
+		t.Errorf("Transform() = %+v, want only the notes changed", got)
+	}
+	if !strings.Contains(log.String(), `projects: {"timestamp":"2025-01-10T09:00:00Z","kind":"in","notes":"standup"}`) {
+		t.Errorf("Plugin didn't log its input:\n%s", log.String())
+	}
+
+	rows := []report.AggregatedRecord{{Group: "2025-01-09", TotalHours: 8, Dates: []string{"2025-01-09"}}}
+	columns, err := r.Columns(ctx, report.PeriodDay, rows, testRecords)
+	if err != nil {
+		t.Fatalf("Columns() failed: %v", err)
+	}
+	if len(columns) != 1 || columns[0].Name != "Project" || columns[0].Values["2025-01-09"] != "acme" {
+		t.Errorf("Columns() = %+v", columns)
+	}
+	if !strings.Contains(log.String(), `"notes":"+acme"`) {
+		t.Errorf("Plugin didn't read the records:\n%s", log.String())
+	}
+}
+
+func TestRuntimeTimeout(t *testing.T) {
+	ctx := context.Background()
+	path := writeModule(t, t.TempDir(), "loop.wasm", testModule("", "", true))
+	r, err := Load(ctx, []string{path}, Options{Timeout: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	defer func() { _ = r.Close(ctx) }()
+
+	if _, err := r.Transform(ctx, testRecords[1]); err == nil {
+		t.Error("Expected a timeout error from a plugin that never returns")
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	path := writeModule(t, t.TempDir(), "bad.wasm", []byte("not wasm"))
+	if _, err := Load(context.Background(), []string{path}, Options{}); err == nil {
+		t.Error("Expected an error for an invalid module")
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// PluginPrefix is the prefix of plugin executables: takt foo runs takt-foo.
+const PluginPrefix = "takt-"
+
+// pluginArgs splits the command line into a plugin name and its arguments
+// when the first argument isn't a built-in command or a flag.
+func pluginArgs(args []string) (string, []string, bool) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return "", nil, false
+	}
+	if cmd, _, err := rootCmd.Find(args); err == nil && cmd != rootCmd {
+		return "", nil, false
+	}
+	return args[0], args[1:], true
+}
+
+// findPlugin returns the path of the plugin executable on the PATH.
+func findPlugin(name string) (string, error) {
+	return exec.LookPath(PluginPrefix + name)
+}
+
+// runPlugin runs a plugin with the configuration in its environment and
+// returns its exit code.
+func runPlugin(path string, args []string, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	cmd := exec.Command(path, args...)
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.Env = taktEnv()
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode(), nil
+		}
+		return 1, fmt.Errorf("failed to run plugin %s: %w", filepath.Base(path), err)
+	}
+	return 0, nil
+}
+
+// listPlugins returns the names of the plugins on the PATH, without the
+// prefix. The first one on the PATH wins, as when running them.
+func listPlugins() []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if !strings.HasPrefix(name, PluginPrefix) || entry.IsDir() {
+				continue
+			}
+			if runtime.GOOS == "windows" {
+				name = strings.TrimSuffix(name, filepath.Ext(name))
+			} else if info, err := entry.Info(); err != nil || info.Mode()&0o111 == 0 {
+				continue
+			}
+			name = strings.TrimPrefix(name, PluginPrefix)
+			if name != "" && !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+var pluginsCmd = &cobra.Command{
+	Use:   "plugins",
+	Short: "List the plugins found on the PATH",
+	Long: `List the plugins found on the PATH. Any executable named takt-NAME is a
+plugin: 'takt NAME [ARGS]' runs it with ARGS. Built-in commands take
+precedence over plugins: 'takt invoice' always runs the built-in invoice,
+never a takt-invoice on the PATH.
+
+Plugins inherit the environment plus the resolved configuration:
+  - TAKT_FILE: Absolute path of the records file
+  - TAKT_TARGET_HOURS: Target hours per day, in decimal
+  - TAKT_GRID_SYMBOLS: Grid symbol set
+  - TAKT_NOW: Pinned current time, when set
+  - TAKT_VERSION: takt version
+
+EXAMPLES:
+  takt plugins
+  takt export --month 2025-01    # runs takt-export --month 2025-01
+
+OUTPUT:
+  export   /usr/local/bin/takt-export
+  slack    /home/me/bin/takt-slack`,
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, name := range listPlugins() {
+			path, err := findPlugin(name)
+			if err != nil {
+				continue
+			}
+			fmt.Printf("%-8s %s\n", name, path)
+		}
+	},
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestPluginArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		name string
+		rest []string
+		ok   bool
+	}{
+		{nil, "", nil, false},
+		{[]string{"day"}, "", nil, false},
+		{[]string{"--help"}, "", nil, false},
+		{[]string{"hello", "-x", "world"}, "hello", []string{"-x", "world"}, true},
+	}
+
+	for _, tt := range tests {
+		name, rest, ok := pluginArgs(tt.args)
+		if name != tt.name || ok != tt.ok || strings.Join(rest, " ") != strings.Join(tt.rest, " ") {
+			t.Errorf("pluginArgs(%q) = %q, %q, %v; want %q, %q, %v", tt.args, name, rest, ok, tt.name, tt.rest, tt.ok)
+		}
+	}
+}
+
+func TestRunPlugin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test plugin is a shell script")
+	}
+	fileName := newTestRecordsFile(t, editTestCSV)
+	dir := t.TempDir()
+	script := "#!/bin/sh\necho \"$TAKT_FILE $*\"\nexit 3\n"
+	if err := os.WriteFile(filepath.Join(dir, "takt-hello"), []byte(script), 0o755); err != nil {
+		t.Fatalf("Failed to write plugin: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "takt-notes.txt"), nil, 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	t.Setenv("PATH", dir)
+
+	if got := listPlugins(); strings.Join(got, ",") != "hello" {
+		t.Errorf("listPlugins() = %q, want [hello]", got)
+	}
+
+	path, err := findPlugin("hello")
+	if err != nil {
+		t.Fatalf("findPlugin() failed: %v", err)
+	}
+	var out strings.Builder
+	code, err := runPlugin(path, []string{"a", "b"}, strings.NewReader(""), &out, &out)
+	if err != nil {
+		t.Fatalf("runPlugin() failed: %v", err)
+	}
+	if code != 3 {
+		t.Errorf("Exit code = %d, want 3", code)
+	}
+	if got, want := strings.TrimSpace(out.String()), fileName+" a b"; got != want {
+		t.Errorf("Plugin output = %q, want %q", got, want)
+	}
+}
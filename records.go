@@ -0,0 +1,412 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/filter"
+	"github.com/asdf8601/takt-go/pkg/report"
+	"github.com/asdf8601/takt-go/pkg/settings"
+	"github.com/asdf8601/takt-go/pkg/store"
+	"github.com/asdf8601/takt-go/pkg/takt"
+	"github.com/asdf8601/takt-go/pkg/wasmplugin"
+)
+
+// Record and AggregatedRecord are the library types, aliased so that the
+// commands read as before.
+type (
+	Record           = takt.Record
+	AggregatedRecord = report.AggregatedRecord
+)
+
+// Header is the CSV header of the records file.
+var Header = takt.Header
+
+// clock is the time source of every command.
+var clock takt.Clock = takt.SystemClock
+
+// ForgottenNotes are the notes of the check-out of a forgotten session.
+const ForgottenNotes = "Forgotten check-out"
+
+// setClock pins the clock to the --now value, or else to TAKT_NOW, or
+// leaves it on the wall clock.
+func setClock(flagValue string) error {
+	now, err := settings.ParseNow(flagValue)
+	if err != nil {
+		return fmt.Errorf("invalid --now: %w", err)
+	}
+	if config != nil {
+		if now.IsZero() {
+			now = config.Now
+		}
+		config.Now = now // passed on to hooks and plugins
+	}
+
+	clock = takt.SystemClock
+	if !now.IsZero() {
+		clock = takt.FixedClock(now)
+	}
+	return nil
+}
+
+// where selects the sessions of reports, from --where.
+var where *filter.Filter
+
+// setWhere parses the --where expression of the commands that have one.
+func setWhere(value string) error {
+	f, err := filter.Parse(value)
+	if err != nil {
+		return fmt.Errorf("invalid --where: %w", err)
+	}
+	where = f
+	return nil
+}
+
+// filterRecords returns the records of the sessions matching --where.
+func filterRecords(records []Record) []Record {
+	return matchRecords(records, where)
+}
+
+// matchRecords returns the records of the sessions matching f, all of them
+// when f is nil; open sessions last until now.
+func matchRecords(records []Record, f *filter.Filter) []Record {
+	if f == nil {
+		return records
+	}
+	now := clock.Now()
+	var kept []Session
+	for _, s := range pairSessions(records) {
+		if f.Match(filter.Subject{Start: s.Start, End: s.End, Notes: s.Notes, OutNotes: s.OutNotes}, now) {
+			kept = append(kept, s)
+		}
+	}
+	return sessionRecords(kept)
+}
+
+// readReportRecords reads head records (all when negative) of the sessions
+// matching --where.
+func readReportRecords(head int) ([]Record, error) {
+	if where == nil {
+		return readRecords(head)
+	}
+	records, err := readRecords(-1)
+	if err != nil {
+		return nil, err
+	}
+	records = filterRecords(records)
+	if head >= 0 && head < len(records) {
+		records = records[:head]
+	}
+	return records, nil
+}
+
+// reportConfig returns the report settings of the configuration.
+func reportConfig() report.Config {
+	c := report.Config{Clock: clock, Schedule: takt.Schedule{DayEnd: takt.DefaultDayEnd}}
+	if config != nil {
+		c.TargetHours = config.TargetHours
+		c.Schedule = config.Schedule
+		c.OpenPolicy = config.OpenPolicy
+		c.Rounding = config.Rounding
+		c.RoundPer = config.RoundPer
+		c.MinSession = config.MinSession
+		c.FiscalYearStart = config.FiscalYearStart
+		c.Sprint = config.Sprint
+	}
+	return c
+}
+
+// recordStore returns the store of a records file, which refuses changes
+// inside its locked periods.
+func recordStore(fileName string) *store.Store {
+	s := store.New(fileName, clock)
+	s.Guard = lockGuard(fileName)
+	return s
+}
+
+// calculateDuration aggregates records by period with the configured target.
+func calculateDuration(records []Record, period string) ([]AggregatedRecord, error) {
+	return reportConfig().CalculateDuration(records, period)
+}
+
+// aggregateBy aggregates the records by the groupFunc.
+func aggregateBy(records []Record, groupFunc func(time.Time) string) map[string]AggregatedRecord {
+	return report.AggregateBy(records, groupFunc)
+}
+
+// inferLastOut closes an open session at the current time.
+func inferLastOut(records *[]Record) int {
+	return reportConfig().InferLastOut(records)
+}
+
+// balanceHours returns the overtime (positive) or undertime (negative) of an
+// aggregation against the configured target.
+func balanceHours(a AggregatedRecord) float64 {
+	return reportConfig().Balance(a)
+}
+
+// hoursToText converts hours to a human-readable format.
+func hoursToText(totalHours float64) string {
+	return report.HoursToText(totalHours)
+}
+
+// formatOvertime formats the overtime/undertime difference using the
+// configured target hours as the day unit.
+func formatOvertime(difference float64) string {
+	return reportConfig().FormatOvertime(difference)
+}
+
+// summary prints the summary of the head latest periods.
+func summary(period string, head int, notes bool) error {
+	records, err := readReportRecords(-1)
+	if err != nil {
+		return err
+	}
+	c := reportConfig()
+	ctx := context.Background()
+	plugins, err := loadWasmPlugins(ctx)
+	if err != nil {
+		return err
+	}
+	if plugins != nil {
+		defer func() { _ = plugins.Close(ctx) }()
+	}
+	c.Columns = summaryColumns(ctx, plugins)
+	c.Notes = notes
+	return c.Summary(os.Stdout, records, period, head)
+}
+
+// summaryColumns returns the extra columns of summaries: the focus counts
+// and those of the WASM plugins, if any.
+func summaryColumns(ctx context.Context, plugins *wasmplugin.Runtime) report.ColumnsFunc {
+	return func(period string, rows []AggregatedRecord, records []Record) ([]report.Column, error) {
+		columns, err := focusColumns(period, records)
+		if err != nil || plugins == nil {
+			return columns, err
+		}
+		more, err := plugins.Columns(ctx, period, rows, records)
+		if err != nil {
+			return nil, err
+		}
+		return append(columns, more...), nil
+	}
+}
+
+// validateRecord checks a record against the current time.
+func validateRecord(record Record) error {
+	return takt.ValidateRecord(record, clock.Now())
+}
+
+// sortRecords sorts records newest first, the order used in the file.
+func sortRecords(records []Record) {
+	takt.SortRecords(records)
+}
+
+// createFile creates the configured records file with just the header.
+func createFile() error {
+	if config == nil {
+		return errors.New("config not initialized")
+	}
+	return recordStore(config.FileName).Create()
+}
+
+// readRecords reads head records from the configured file.
+func readRecords(head int) ([]Record, error) {
+	if config == nil {
+		return nil, errors.New("config not initialized")
+	}
+	return readRecordsFromFile(config.FileName, head)
+}
+
+// readRecordsFromFile reads head records (all when negative) from fileName.
+func readRecordsFromFile(fileName string, head int) ([]Record, error) {
+	return recordStore(fileName).Read(head)
+}
+
+// backupFile copies fileName to its backup.
+func backupFile(fileName string) error {
+	return recordStore(fileName).Backup()
+}
+
+// recoverFromBackup reads the records of the backup of fileName.
+func recoverFromBackup(fileName string) ([]Record, error) {
+	return recordStore(fileName).Recover()
+}
+
+// writeValidRecords writes records to fileName in place.
+func writeValidRecords(fileName string, records []Record) error {
+	return recordStore(fileName).WriteValid(records)
+}
+
+// writeRecordsAtomic replaces fileName with records atomically.
+func writeRecordsAtomic(fileName string, records []Record) error {
+	return recordStore(fileName).WriteAtomic(records)
+}
+
+// replaceRecords replaces the records of fileName atomically, then runs the
+// post-edit hook, which puts the old records back when it vetoes.
+func replaceRecords(fileName string, records []Record) error {
+	old, err := readRecordsFromFile(fileName, -1)
+	if err != nil {
+		return err
+	}
+	if err := writeRecordsAtomic(fileName, records); err != nil {
+		return fmt.Errorf("failed to write records: %w", err)
+	}
+	if err := runHook(HookPostEdit, nil, records); err != nil {
+		if rollbackErr := writeRecordsAtomic(fileName, old); rollbackErr != nil {
+			return fmt.Errorf("%w (and restoring the records failed: %v)", err, rollbackErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// toggleRecord appends an "in" or "out" record, whichever follows the
+// latest one. WASM plugins may change its notes first; then the pre-check
+// hook can veto it before it is written, and the post-check hook after,
+// which removes it again.
+func toggleRecord(fileName, notes string) (Record, error) {
+	s := recordStore(fileName)
+	record, err := s.Next(notes)
+	if err != nil {
+		return Record{}, err
+	}
+	if record, err = transformRecord(record); err != nil {
+		return Record{}, err
+	}
+	if err := prependRecord(s, record); err != nil {
+		return Record{}, err
+	}
+	return record, nil
+}
+
+// prependRecord writes a new latest record between the pre-check and
+// post-check hooks.
+func prependRecord(s *store.Store, record Record) error {
+	if err := runHook(HookPreCheck, &record, nil); err != nil {
+		return err
+	}
+	if err := s.Prepend(record); err != nil {
+		return fmt.Errorf("failed to write records: %w", err)
+	}
+	if err := runHook(HookPostCheck, &record, nil); err != nil {
+		if rollbackErr := removeLatest(s, record); rollbackErr != nil {
+			return fmt.Errorf("%w (and removing the record failed: %v)", err, rollbackErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// removeLatest removes record from the top of the store.
+func removeLatest(s *store.Store, record Record) error {
+	records, err := s.Read(-1)
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 || !records[0].Timestamp.Equal(record.Timestamp) || records[0].Kind != record.Kind {
+		return errors.New("the record is no longer the latest")
+	}
+	return s.WriteAtomic(records[1:])
+}
+
+// resolveStaleSession asks when to check out of an open session that looks
+// forgotten (see report.Config.Forgotten) before takt check toggles it.
+// Without an answer it leaves the session to be checked out now, as before.
+// It returns whether the caller should still toggle: after a forgotten
+// check-out only when askCheckIn is set and the user asks to check in again.
+func resolveStaleSession(fileName string, in io.Reader, out io.Writer, askCheckIn bool) (bool, error) {
+	records, err := readRecordsFromFile(fileName, 1)
+	if err != nil {
+		return false, err
+	}
+	if len(records) == 0 || records[0].Kind != "in" {
+		return true, nil
+	}
+	open := records[0]
+	rc := reportConfig()
+	if !rc.Forgotten(open) {
+		return true, nil
+	}
+	staleAt := rc.StaleAt(open)
+	now := clock.Now()
+
+	answers := bufio.NewReader(in)
+	for {
+		fmt.Fprintf(out, "Open session since %s (%s). Check out at %s, at HH:MM or [n]ow? [%s/HH:MM/n] ",
+			open.Timestamp.Format("Mon 2006-01-02 15:04"), hoursToText(now.Sub(open.Timestamp).Hours()),
+			staleAt.Format("15:04"), staleAt.Format("15:04"))
+		answer, err := answers.ReadString('\n')
+		if err != nil && answer == "" {
+			fmt.Fprintln(out)
+			return true, nil
+		}
+
+		at := staleAt
+		switch answer = strings.ToLower(strings.TrimSpace(answer)); answer {
+		case "n", "now":
+			return true, nil
+		case "":
+		default:
+			t, err := parseTimeOfDay(open.Timestamp, answer)
+			if err != nil {
+				fmt.Fprintf(out, "%v\n", err)
+				continue
+			}
+			if !t.After(open.Timestamp) {
+				t = t.AddDate(0, 0, 1)
+			}
+			if t.After(now) {
+				fmt.Fprintf(out, "%s is in the future\n", t.Format("2006-01-02 15:04"))
+				continue
+			}
+			at = t
+		}
+
+		record := Record{Timestamp: at, Kind: "out", Notes: ForgottenNotes}
+		if err := prependRecord(recordStore(fileName), record); err != nil {
+			return false, err
+		}
+		fmt.Fprintf(out, "Check out at %s\n", record.Timestamp.Format(TimeFormat))
+		if !askCheckIn {
+			return false, nil
+		}
+		fmt.Fprint(out, "Check in now? [y/N] ")
+		answer, err = answers.ReadString('\n')
+		if err != nil && answer == "" {
+			fmt.Fprintln(out)
+			return false, nil
+		}
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+			return true, nil
+		}
+		return false, nil
+	}
+}
+
+// checkAction checks in or out.
+func checkAction(fileName, notes string) error {
+	record, err := toggleRecord(fileName, notes)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Check %s at %s\n", record.Kind, record.Timestamp.Format(TimeFormat))
+	return nil
+}
+
+// printRecords prints the records.
+func printRecords(records []Record) {
+	fmt.Printf("%-25s %-5s %s\n", Header[0], Header[1], Header[2])
+	for _, record := range records {
+		fmt.Printf("%-25s %-5s %s\n", record.Timestamp.Format(TimeFormat), record.Kind, record.Notes)
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/remind"
+	"github.com/asdf8601/takt-go/pkg/settings"
+)
+
+const (
+	// Notification sinks
+	SinkStdout  = "stdout"
+	SinkLog     = "log:"
+	SinkCommand = "command:"
+	SinkWebhook = "webhook:"
+
+	webhookTimeout = 5 * time.Second
+)
+
+// Sink delivers notifications.
+type Sink interface {
+	Notify(n remind.Notification) error
+}
+
+// writerSink prints notifications to a writer.
+type writerSink struct {
+	w io.Writer
+}
+
+func (s writerSink) Notify(n remind.Notification) error {
+	_, err := fmt.Fprintf(s.w, "[%s] %s\n", n.Time.Format("15:04"), n.Message)
+	return err
+}
+
+// logSink appends notifications to a file.
+type logSink struct {
+	path string
+}
+
+func (s logSink) Notify(n remind.Notification) error {
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(f, "%s %s %s\n", n.Time.Format(TimeFormat), n.Rule, n.Message)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// commandSink runs a command template such as "notify-send {title}
+// {message}". Placeholders are replaced within the arguments, without a
+// shell, so messages can't inject commands.
+type commandSink struct {
+	template []string
+}
+
+func (s commandSink) Notify(n remind.Notification) error {
+	replacer := strings.NewReplacer("{title}", n.Title, "{message}", n.Message, "{rule}", n.Rule)
+	args := make([]string, len(s.template))
+	for i, arg := range s.template {
+		args[i] = replacer.Replace(arg)
+	}
+	out, err := exec.Command(args[0], args[1:]...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %w: %s", args[0], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// webhookSink posts notifications as JSON to a URL.
+type webhookSink struct {
+	url    string
+	client *http.Client
+}
+
+func (s webhookSink) Notify(n remind.Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook %s: %s", s.url, resp.Status)
+	}
+	return nil
+}
+
+// newSink returns the sink named by spec: stdout, log:PATH,
+// command:TEMPLATE or webhook:URL.
+func newSink(spec string, stdout io.Writer) (Sink, error) {
+	switch {
+	case spec == SinkStdout:
+		return writerSink{w: stdout}, nil
+	case strings.HasPrefix(spec, SinkLog):
+		path, err := settings.AbsPath(strings.TrimPrefix(spec, SinkLog))
+		if err != nil {
+			return nil, err
+		}
+		return logSink{path: path}, nil
+	case strings.HasPrefix(spec, SinkCommand):
+		template := strings.Fields(strings.TrimPrefix(spec, SinkCommand))
+		if len(template) == 0 {
+			return nil, errors.New("empty notification command")
+		}
+		return commandSink{template: template}, nil
+	case strings.HasPrefix(spec, SinkWebhook):
+		return webhookSink{url: strings.TrimPrefix(spec, SinkWebhook), client: &http.Client{Timeout: webhookTimeout}}, nil
+	}
+	return nil, fmt.Errorf("unsupported notification sink: %s (must be %s, %sPATH, %sTEMPLATE or %sURL)",
+		spec, SinkStdout, SinkLog, SinkCommand, SinkWebhook)
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/remind"
+)
+
+// fakeSink records the notifications it gets.
+type fakeSink struct {
+	messages []string
+}
+
+func (s *fakeSink) Notify(n remind.Notification) error {
+	s.messages = append(s.messages, n.Time.Format("15:04")+" "+n.Message)
+	return nil
+}
+
+func TestDaemonReminders(t *testing.T) {
+	d, _, set := newTestDaemon(t, OnReturnAsk, "")
+	if err := os.WriteFile(d.fileName, []byte("timestamp,kind,notes\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write records: %v", err)
+	}
+	rules, err := remind.Parse(remind.Default)
+	if err != nil {
+		t.Fatalf("remind.Parse() failed: %v", err)
+	}
+	sink := &fakeSink{}
+	d.source, d.reminders, d.sinks = nil, rules, []Sink{sink}
+
+	check := func(at string) {
+		t.Helper()
+		set(at, false)
+		if _, err := toggleRecord(d.fileName, ""); err != nil {
+			t.Fatalf("toggleRecord() at %s failed: %v", at, err)
+		}
+	}
+	for _, step := range []string{
+		"09:00", "09:30", "09:45", "check 09:50",
+		"check 12:00", "12:50", "13:05", "13:06", "check 13:10",
+		"18:00", "18:10", "19:00", "20:00", "21:00", "21:30",
+	} {
+		if at, ok := strings.CutPrefix(step, "check "); ok {
+			check(at)
+			continue
+		}
+		tickAt(t, d, set, step, false)
+	}
+
+	want := []string{
+		"09:30 Not checked in by 09:30",
+		"13:05 On a break since 12:00, over 1h00m",
+		"19:00 Target of 8h00m reached",
+		"21:00 Over 10h00m today",
+	}
+	if strings.Join(sink.messages, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Notifications:\n%s\nwant:\n%s", strings.Join(sink.messages, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestDaemonRemindersOffDay(t *testing.T) {
+	d, _, set := newTestDaemon(t, OnReturnAsk, "")
+	if err := os.WriteFile(d.fileName, []byte("timestamp,kind,notes\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write records: %v", err)
+	}
+	rules, _ := remind.Parse("checkin-by=09:30")
+	sink := &fakeSink{}
+	d.source, d.reminders, d.sinks = nil, rules, []Sink{sink}
+
+	config.Schedule.Workdays = nil
+	tickAt(t, d, set, "10:00", false) // Thursday
+	config.Schedule.Workdays = []time.Weekday{time.Friday, time.Saturday, time.Sunday}
+	d.sent = nil
+	tickAt(t, d, set, "10:00", false)
+	if len(sink.messages) != 1 {
+		t.Errorf("Expected one reminder on the workday only, got %q", sink.messages)
+	}
+}
+
+func TestSinks(t *testing.T) {
+	n := remind.Notification{Rule: remind.Target, Title: "Target reached", Message: "Target of 8h00m reached"}
+	dir := t.TempDir()
+
+	var got remind.Notification
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("Invalid webhook body: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	var stdout strings.Builder
+	logFile := filepath.Join(dir, "reminders.log")
+	specs := []string{SinkStdout, SinkLog + logFile, SinkWebhook + server.URL}
+	if runtime.GOOS != "windows" {
+		specs = append(specs, SinkCommand+"touch "+filepath.Join(dir, "{rule}"))
+	}
+	for _, spec := range specs {
+		sink, err := newSink(spec, &stdout)
+		if err != nil {
+			t.Fatalf("newSink(%q) failed: %v", spec, err)
+		}
+		if err := sink.Notify(n); err != nil {
+			t.Errorf("Notify() with %q failed: %v", spec, err)
+		}
+	}
+
+	if !strings.Contains(stdout.String(), n.Message) {
+		t.Errorf("stdout sink printed %q", stdout.String())
+	}
+	if data, _ := os.ReadFile(logFile); !strings.Contains(string(data), "target "+n.Message) {
+		t.Errorf("log sink wrote %q", data)
+	}
+	if got.Rule != n.Rule || got.Message != n.Message {
+		t.Errorf("webhook got %+v", got)
+	}
+	if runtime.GOOS != "windows" {
+		if _, err := os.Stat(filepath.Join(dir, remind.Target)); err != nil {
+			t.Errorf("command sink didn't run: %v", err)
+		}
+	}
+
+	if _, err := newSink("pager:me", &stdout); err == nil {
+		t.Error("Expected an error for an unknown sink")
+	}
+}
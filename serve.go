@@ -0,0 +1,554 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/filter"
+	"github.com/asdf8601/takt-go/pkg/grid"
+	"github.com/spf13/cobra"
+)
+
+const (
+	DefaultAddr  = "127.0.0.1:8080"
+	DefaultLimit = 50
+)
+
+// recordJSON is the wire representation of a Record.
+type recordJSON struct {
+	Timestamp string `json:"timestamp"`
+	Kind      string `json:"kind"`
+	Notes     string `json:"notes"`
+}
+
+// summaryJSON is the wire representation of an AggregatedRecord.
+type summaryJSON struct {
+	Group        string   `json:"group"`
+	TotalHours   float64  `json:"total_hours"`
+	Total        string   `json:"total"`
+	RawHours     float64  `json:"raw_hours"`
+	Raw          string   `json:"raw"`
+	Days         int      `json:"days"`
+	AverageHours float64  `json:"average_hours"`
+	Average      string   `json:"average"`
+	BalanceHours float64  `json:"balance_hours"`
+	Balance      string   `json:"balance"`
+	Dates        []string `json:"dates"`
+	Notes        []string `json:"notes"`
+}
+
+// statusJSON describes whether the user is currently checked in.
+type statusJSON struct {
+	CheckedIn   bool        `json:"checked_in"`
+	Last        *recordJSON `json:"last,omitempty"`
+	TodayHours  float64     `json:"today_hours"`
+	Today       string      `json:"today"`
+	TargetHours float64     `json:"target_hours"`
+}
+
+// gridDayJSON is a single day of the activity grid.
+type gridDayJSON struct {
+	Date  string  `json:"date"`
+	Hours float64 `json:"hours"`
+	Level int     `json:"level"`
+}
+
+// gridJSON is the activity grid of a year.
+type gridJSON struct {
+	Year        int             `json:"year"`
+	TargetHours float64         `json:"target_hours"`
+	Thresholds  grid.Thresholds `json:"thresholds"`
+	Days        []gridDayJSON   `json:"days"`
+}
+
+// recordsPageJSON is a page of records, newest first.
+type recordsPageJSON struct {
+	Total   int          `json:"total"`
+	Offset  int          `json:"offset"`
+	Limit   int          `json:"limit"`
+	Records []recordJSON `json:"records"`
+}
+
+// server exposes the records file over HTTP. Every handler holds mu so that
+// concurrent requests can't interleave reads and writes of the CSV file.
+type server struct {
+	fileName string
+	token    string
+	mu       sync.Mutex
+}
+
+// newServer creates a server for fileName guarded by token.
+func newServer(fileName, token string) *server {
+	return &server{fileName: fileName, token: token}
+}
+
+// routes returns the HTTP handler with all API endpoints registered.
+func (s *server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/status", s.handleStatus)
+	mux.HandleFunc("POST /api/check", s.handleCheck)
+	mux.HandleFunc("GET /api/records", s.handleRecords)
+	mux.HandleFunc("POST /api/records", s.handleAddRecord)
+	mux.HandleFunc("PUT /api/records/{timestamp}", s.handleUpdateRecord)
+	mux.HandleFunc("DELETE /api/records/{timestamp}", s.handleDeleteRecord)
+	mux.HandleFunc("GET /api/summary/{period}", s.handleSummary)
+	mux.HandleFunc("GET /api/grid", s.handleGrid)
+	mux.HandleFunc("GET /{$}", handleDashboard)
+	return s.authenticate(mux)
+}
+
+// authenticate rejects API requests without a valid bearer token. The
+// dashboard page itself holds no data and is served without one.
+func (s *server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			writeError(w, http.StatusUnauthorized, errors.New("invalid or missing token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleStatus reports the latest record and today's hours.
+func (s *server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records, err := readRecordsFromFile(s.fileName, -1)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	status := statusJSON{TargetHours: config.TargetHours, Today: hoursToText(0)}
+	if len(records) == 0 {
+		writeJSON(w, http.StatusOK, status)
+		return
+	}
+
+	last := toRecordJSON(records[0])
+	status.Last = &last
+	status.CheckedIn = records[0].Kind == "in"
+
+	agg, err := calculateDuration(records, "day")
+	if err == nil && len(agg) > 0 && agg[0].Group == clock.Now().Format(DateFormat) {
+		status.TodayHours = agg[0].TotalHours
+		status.Today = hoursToText(agg[0].TotalHours)
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+// handleCheck toggles the check-in state. The body may carry {"notes": "..."}.
+func (s *server) handleCheck(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Notes string `json:"notes"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid body: %w", err))
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, err := toggleRecord(s.fileName, body.Notes)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, toRecordJSON(record))
+}
+
+// handleRecords returns a page of records, optionally restricted to the
+// [from, to] date range (YYYY-MM-DD, inclusive).
+func (s *server) handleRecords(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	offset, err := queryInt(query.Get("offset"), 0)
+	if err != nil || offset < 0 {
+		writeError(w, http.StatusBadRequest, errors.New("invalid offset"))
+		return
+	}
+	limit, err := queryInt(query.Get("limit"), DefaultLimit)
+	if err != nil || limit < 1 {
+		writeError(w, http.StatusBadRequest, errors.New("invalid limit"))
+		return
+	}
+	from, to := query.Get("from"), query.Get("to")
+	for _, date := range []string{from, to} {
+		if _, err := time.Parse(DateFormat, date); date != "" && err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid date %q: expected YYYY-MM-DD", date))
+			return
+		}
+	}
+
+	s.mu.Lock()
+	records, err := readRecordsFromFile(s.fileName, -1)
+	s.mu.Unlock()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	var selected []Record
+	for _, record := range records {
+		day := record.Timestamp.Format(DateFormat)
+		if (from != "" && day < from) || (to != "" && day > to) {
+			continue
+		}
+		selected = append(selected, record)
+	}
+
+	page := recordsPageJSON{Total: len(selected), Offset: offset, Limit: limit, Records: []recordJSON{}}
+	for i := offset; i < len(selected) && i < offset+limit; i++ {
+		page.Records = append(page.Records, toRecordJSON(selected[i]))
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+// handleSummary returns the same aggregation as the day/week/month/year
+// commands, of the sessions matching the where filter.
+func (s *server) handleSummary(w http.ResponseWriter, r *http.Request) {
+	head, err := queryInt(r.URL.Query().Get("head"), -1)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid head"))
+		return
+	}
+	f, err := queryFilter(r.URL.Query().Get("where"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	s.mu.Lock()
+	records, err := readRecordsFromFile(s.fileName, -1)
+	s.mu.Unlock()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	records = matchRecords(records, f)
+
+	out := []summaryJSON{}
+	if len(records) == 0 {
+		writeJSON(w, http.StatusOK, out)
+		return
+	}
+
+	agg, err := calculateDuration(records, r.PathValue("period"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if head < 1 || head > len(agg) {
+		head = len(agg)
+	}
+	for _, a := range agg[:head] {
+		out = append(out, toSummaryJSON(a))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// handleAddRecord inserts a record given as {"timestamp", "kind", "notes"}.
+func (s *server) handleAddRecord(w http.ResponseWriter, r *http.Request) {
+	record, err := decodeRecord(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = s.updateRecords(func(records []Record) ([]Record, error) {
+		return append(records, record), nil
+	})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, toRecordJSON(record))
+}
+
+// handleUpdateRecord replaces the record with the given timestamp.
+func (s *server) handleUpdateRecord(w http.ResponseWriter, r *http.Request) {
+	record, err := decodeRecord(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = s.updateRecords(func(records []Record) ([]Record, error) {
+		idx, err := findRecord(records, r.PathValue("timestamp"))
+		if err != nil {
+			return nil, err
+		}
+		records[idx] = record
+		return records, nil
+	})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toRecordJSON(record))
+}
+
+// handleDeleteRecord removes the record with the given timestamp.
+func (s *server) handleDeleteRecord(w http.ResponseWriter, r *http.Request) {
+	err := s.updateRecords(func(records []Record) ([]Record, error) {
+		idx, err := findRecord(records, r.PathValue("timestamp"))
+		if err != nil {
+			return nil, err
+		}
+		return append(records[:idx], records[idx+1:]...), nil
+	})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleGrid returns every day of a year with its hours and grid level, the
+// same buckets used by 'takt grid', of the sessions matching the where
+// filter.
+func (s *server) handleGrid(w http.ResponseWriter, r *http.Request) {
+	year := r.URL.Query().Get("year")
+	if year == "" {
+		year = clock.Now().Format("2006")
+	}
+	start, err := time.Parse("2006", year)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid year %q", year))
+		return
+	}
+	f, err := queryFilter(r.URL.Query().Get("where"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	s.mu.Lock()
+	records, err := readRecordsFromFile(s.fileName, -1)
+	s.mu.Unlock()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	records = matchRecords(records, f)
+
+	days, err := grid.Days(reportConfig(), records, grid.YearRange(start.Year()))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	th := config.GridThresholdsOrDefault()
+	resp := gridJSON{
+		Year:        start.Year(),
+		TargetHours: config.TargetHours,
+		Thresholds:  th,
+		Days:        []gridDayJSON{},
+	}
+	for _, day := range days {
+		resp.Days = append(resp.Days, gridDayJSON{
+			Date:  day.Date.Format(DateFormat),
+			Hours: day.Hours,
+			Level: th.Level(day.Hours),
+		})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// updateRecords applies change to all records, validates the result and
+// writes it back sorted newest first.
+func (s *server) updateRecords(change func([]Record) ([]Record, error)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records, err := readRecordsFromFile(s.fileName, -1)
+	if err != nil {
+		return err
+	}
+	records, err = change(records)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		if err := validateRecord(record); err != nil {
+			return err
+		}
+	}
+	sortRecords(records)
+	return replaceRecords(s.fileName, records)
+}
+
+// decodeRecord reads a record from the request body.
+func decodeRecord(r *http.Request) (Record, error) {
+	var body recordJSON
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return Record{}, fmt.Errorf("invalid body: %w", err)
+	}
+	timestamp, err := time.Parse(TimeFormat, body.Timestamp)
+	if err != nil {
+		return Record{}, fmt.Errorf("invalid timestamp: %w", err)
+	}
+	record := Record{Timestamp: timestamp, Kind: body.Kind, Notes: body.Notes}
+	return record, validateRecord(record)
+}
+
+// findRecord returns the index of the record with the given timestamp.
+func findRecord(records []Record, timestamp string) (int, error) {
+	t, err := time.Parse(TimeFormat, timestamp)
+	if err != nil {
+		return -1, fmt.Errorf("invalid timestamp: %w", err)
+	}
+	for i, record := range records {
+		if record.Timestamp.Equal(t) {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("no record at %s", timestamp)
+}
+
+// toRecordJSON converts a Record to its wire representation.
+func toRecordJSON(record Record) recordJSON {
+	return recordJSON{
+		Timestamp: record.Timestamp.Format(TimeFormat),
+		Kind:      record.Kind,
+		Notes:     record.Notes,
+	}
+}
+
+// toSummaryJSON converts an AggregatedRecord to its wire representation.
+func toSummaryJSON(a AggregatedRecord) summaryJSON {
+	balance := balanceHours(a)
+	return summaryJSON{
+		Group:        a.Group,
+		TotalHours:   a.TotalHours,
+		Total:        hoursToText(a.TotalHours),
+		RawHours:     a.RawHours,
+		Raw:          hoursToText(a.RawHours),
+		Days:         len(a.Dates),
+		AverageHours: a.AverageHours,
+		Average:      hoursToText(a.AverageHours),
+		BalanceHours: balance,
+		Balance:      formatOvertime(balance),
+		Dates:        a.Dates,
+		Notes:        a.Notes,
+	}
+}
+
+// queryInt parses an integer query parameter, returning dflt when empty.
+func queryInt(value string, dflt int) (int, error) {
+	if value == "" {
+		return dflt, nil
+	}
+	return strconv.Atoi(value)
+}
+
+// queryFilter parses the where parameter, the same filter as --where; nil
+// when empty.
+func queryFilter(value string) (*filter.Filter, error) {
+	f, err := filter.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid where: %w", err)
+	}
+	return f, nil
+}
+
+// writeJSON writes v as a JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
+
+// writeError writes an error as a JSON response.
+func writeError(w http.ResponseWriter, status int, err error) {
+	var hookErr *hookError
+	if errors.As(err, &hookErr) {
+		status = http.StatusConflict
+	}
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// generateToken returns a random hex token.
+func generateToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve a local HTTP/JSON API",
+	Long: `Serve a local HTTP/JSON API to check in/out and read records and summaries,
+plus an offline web dashboard at the root URL.
+API requests must send the token in an "Authorization: Bearer <token>" header.
+The token is read from TAKT_TOKEN; if unset, a random one is generated and printed.
+
+EXAMPLES:
+  takt serve                           # Listen on 127.0.0.1:8080
+  takt serve --addr 127.0.0.1:9000     # Listen on another port
+
+ENDPOINTS:
+  GET  /api/status                     Current state and today's hours
+  POST /api/check                      Check in/out, body: {"notes": "..."}
+  GET  /api/records?offset=0&limit=50&from=2025-01-01&to=2025-01-31
+  POST /api/records                    Add a record, body: {"timestamp", "kind", "notes"}
+  PUT  /api/records/{timestamp}        Replace a record
+  DELETE /api/records/{timestamp}      Delete a record
+  GET  /api/summary/{day|week|month|year}?head=10&where=project=acme
+  GET  /api/grid?year=2025&where=...   Daily hours and grid levels
+
+The where parameter of the summaries and the grid takes the same filter as
+--where, URL-encoded.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if config == nil {
+			fmt.Println("Error: config not initialized")
+			return
+		}
+
+		addr, _ := cmd.Flags().GetString("addr")
+		token := os.Getenv("TAKT_TOKEN")
+		if token == "" {
+			var err error
+			token, err = generateToken()
+			if err != nil {
+				log.Fatalf("Failed to generate token: %v", err)
+			}
+			fmt.Printf("Generated token: %s\n", token)
+		}
+
+		srv := newServer(config.FileName, token)
+		fmt.Printf("Serving %s on http://%s\n", config.FileName, addr)
+		fmt.Printf("Dashboard: http://%s/#token=%s\n", addr, token)
+		if err := http.ListenAndServe(addr, srv.routes()); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	serveCmd.Flags().String("addr", DefaultAddr, "address to listen on")
+	rootCmd.AddCommand(serveCmd)
+}
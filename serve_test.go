@@ -0,0 +1,263 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/settings"
+)
+
+// newTestServer creates a server over a temporary records file.
+func newTestServer(t *testing.T, csvContent string) (*server, string) {
+	t.Helper()
+
+	tempFile, err := os.CreateTemp("", "takt_serve_test_*.csv")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = os.Remove(tempFile.Name())
+		_ = os.Remove(tempFile.Name() + ".bak")
+	})
+	if _, err := tempFile.WriteString(csvContent); err != nil {
+		t.Fatalf("Failed to write test data: %v", err)
+	}
+	if err := tempFile.Close(); err != nil {
+		t.Fatalf("Failed to close temp file: %v", err)
+	}
+
+	cfg, err := settings.Load()
+	if err != nil {
+		t.Fatalf("settings.Load() failed: %v", err)
+	}
+	originalConfig := config
+	config = cfg
+	config.FileName = tempFile.Name()
+	config.TargetHours = 8.0
+	t.Cleanup(func() { config = originalConfig })
+
+	return newServer(tempFile.Name(), "secret"), tempFile.Name()
+}
+
+// doRequest performs an authenticated request against handler.
+func doRequest(t *testing.T, handler http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestServeAuth(t *testing.T) {
+	srv, _ := newTestServer(t, "timestamp,kind,notes\n")
+	handler := srv.routes()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/status", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without token, got %d", rec.Code)
+	}
+
+	req.Header.Set("Authorization", "Bearer wrong")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 with wrong token, got %d", rec.Code)
+	}
+}
+
+func TestServeCheckAndStatus(t *testing.T) {
+	srv, _ := newTestServer(t, "timestamp,kind,notes\n")
+	handler := srv.routes()
+
+	rec := doRequest(t, handler, http.MethodPost, "/api/check", `{"notes": "from api, with comma"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var record recordJSON
+	if err := json.Unmarshal(rec.Body.Bytes(), &record); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if record.Kind != "in" || record.Notes != "from api, with comma" {
+		t.Errorf("Unexpected record: %+v", record)
+	}
+
+	rec = doRequest(t, handler, http.MethodGet, "/api/status", "")
+	var status statusJSON
+	if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if !status.CheckedIn {
+		t.Error("Expected to be checked in")
+	}
+	if status.Last == nil || status.Last.Notes != "from api, with comma" {
+		t.Errorf("Unexpected last record: %+v", status.Last)
+	}
+}
+
+func TestServeConcurrentChecks(t *testing.T) {
+	srv, fileName := newTestServer(t, "timestamp,kind,notes\n")
+	handler := srv.routes()
+
+	const n = 20
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			doRequest(t, handler, http.MethodPost, "/api/check", "")
+		}()
+	}
+	wg.Wait()
+
+	records, err := readRecordsFromFile(fileName, -1)
+	if err != nil {
+		t.Fatalf("readRecordsFromFile() failed: %v", err)
+	}
+	if len(records) != n {
+		t.Fatalf("Expected %d records, got %d", n, len(records))
+	}
+	for i := 1; i < len(records); i++ {
+		if records[i].Kind == records[i-1].Kind {
+			t.Fatalf("Records %d and %d have the same kind %q", i-1, i, records[i].Kind)
+		}
+	}
+}
+
+func TestServeRecordsAndSummary(t *testing.T) {
+	csvContent := "timestamp,kind,notes\n" +
+		"2024-07-26T18:00:00+02:00,out,\n" +
+		"2024-07-26T09:00:00+02:00,in,\n" +
+		"2024-07-25T15:00:00+02:00,out,\n" +
+		"2024-07-25T14:00:00+02:00,in,\n"
+	srv, _ := newTestServer(t, csvContent)
+	handler := srv.routes()
+
+	rec := doRequest(t, handler, http.MethodGet, "/api/records?from=2024-07-26&limit=1&offset=1", "")
+	var page recordsPageJSON
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if page.Total != 2 || len(page.Records) != 1 || page.Records[0].Kind != "in" {
+		t.Errorf("Unexpected page: %+v", page)
+	}
+
+	rec = doRequest(t, handler, http.MethodGet, "/api/summary/day", "")
+	var rows []summaryJSON
+	if err := json.Unmarshal(rec.Body.Bytes(), &rows); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("Expected 2 rows, got %d", len(rows))
+	}
+	if rows[0].TotalHours != 9.0 || rows[0].Balance != "+1h00m" {
+		t.Errorf("Unexpected first row: %+v", rows[0])
+	}
+
+	rec = doRequest(t, handler, http.MethodGet, "/api/summary/day?where=duration%3E2h", "")
+	rows = nil
+	if err := json.Unmarshal(rec.Body.Bytes(), &rows); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(rows) != 1 || rows[0].TotalHours != 9.0 {
+		t.Errorf("Expected only the 9h day with where, got %+v", rows)
+	}
+	rec = doRequest(t, handler, http.MethodGet, "/api/summary/day?where=bogus%3D1", "")
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "invalid where") {
+		t.Errorf("Expected 400 for an invalid where, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = doRequest(t, handler, http.MethodGet, "/api/summary/fortnight", "")
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for unsupported period, got %d", rec.Code)
+	}
+
+	rec = doRequest(t, handler, http.MethodGet, fmt.Sprintf("/api/records?to=%s", time.Now().Format("01/02")), "")
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid date, got %d", rec.Code)
+	}
+}
+
+func TestServeRecordEditorAndGrid(t *testing.T) {
+	csvContent := "timestamp,kind,notes\n" +
+		"2024-07-26T18:00:00+02:00,out,\n" +
+		"2024-07-26T09:00:00+02:00,in,\n"
+	srv, fileName := newTestServer(t, csvContent)
+	handler := srv.routes()
+
+	rec := doRequest(t, handler, http.MethodPost, "/api/records", `{"timestamp": "2024-07-25T09:00:00+02:00", "kind": "in", "notes": "added"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = doRequest(t, handler, http.MethodPut, "/api/records/2024-07-26T09:00:00%2B02:00", `{"timestamp": "2024-07-26T10:00:00+02:00", "kind": "in", "notes": "edited"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = doRequest(t, handler, http.MethodPost, "/api/records", `{"timestamp": "2024-07-25T09:00:00+02:00", "kind": "maybe"}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid kind, got %d", rec.Code)
+	}
+	rec = doRequest(t, handler, http.MethodDelete, "/api/records/2024-07-25T09:00:00%2B02:00", "")
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("Expected 204, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	records, err := readRecordsFromFile(fileName, -1)
+	if err != nil {
+		t.Fatalf("readRecordsFromFile() failed: %v", err)
+	}
+	if len(records) != 2 || records[1].Notes != "edited" || records[1].Timestamp.Hour() != 10 {
+		t.Errorf("Unexpected records: %+v", records)
+	}
+
+	rec = doRequest(t, handler, http.MethodGet, "/api/grid?year=2024", "")
+	var grid gridJSON
+	if err := json.Unmarshal(rec.Body.Bytes(), &grid); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(grid.Days) != 366 {
+		t.Fatalf("Expected 366 days in 2024, got %d", len(grid.Days))
+	}
+	day := grid.Days[207]
+	if day.Date != "2024-07-26" || day.Hours != 8.0 || day.Level != config.GridThresholdsOrDefault().Level(8.0) {
+		t.Errorf("Unexpected day: %+v", day)
+	}
+
+	rec = doRequest(t, handler, http.MethodGet, "/api/grid?year=2024&where=duration%3C1h", "")
+	grid = gridJSON{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &grid); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(grid.Days) != 366 {
+		t.Fatalf("Expected 366 days in 2024 with where, got %d", len(grid.Days))
+	}
+	if grid.Days[207].Hours != 0 {
+		t.Errorf("Expected no hours on 2024-07-26 with where, got %+v", grid.Days[207])
+	}
+}
+
+func TestServeDashboard(t *testing.T) {
+	srv, _ := newTestServer(t, "timestamp,kind,notes\n")
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, "/api/grid") {
+		t.Error("Dashboard does not use the grid endpoint")
+	}
+	if strings.Contains(body, "https://") {
+		t.Error("Dashboard must not load external resources")
+	}
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// Session line layout: date, start, end and notes
+	SessionTimeFormat   = "15:04"
+	SessionSecondFormat = "15:04:05"
+	OpenSessionEnd      = "-"
+	OutNotesSeparator   = " || "
+)
+
+// Session is a check-in paired with its check-out. End is zero while the
+// session is open; Start is zero for a check-out without a check-in.
+type Session struct {
+	Start    time.Time
+	End      time.Time
+	Notes    string // notes of the check-in
+	OutNotes string // notes of the check-out
+}
+
+// IsOpen reports whether the session has no check-out yet.
+func (s Session) IsOpen() bool {
+	return s.End.IsZero()
+}
+
+// Hours returns the session duration, counting an open session until now.
+func (s Session) Hours(now time.Time) float64 {
+	if s.Start.IsZero() {
+		return 0
+	}
+	end := s.End
+	if s.IsOpen() {
+		end = now
+	}
+	return end.Sub(s.Start).Hours()
+}
+
+// sessionError is a validation error of the session at Index.
+type sessionError struct {
+	Index int
+	Err   error
+}
+
+func (e sessionError) Error() string {
+	return e.Err.Error()
+}
+
+// pairSessions pairs records (newest first) into sessions (newest first).
+// Unpaired records are kept as sessions with a zero Start or End so that
+// checkSessions can report them instead of silently dropping them.
+func pairSessions(records []Record) []Session {
+	sessions, _ := pairSessionsIndexed(records)
+	return sessions
+}
+
+// pairSessionsIndexed is pairSessions that also returns, for each session,
+// the index in records of its first record (the check-in when it has one).
+func pairSessionsIndexed(records []Record) ([]Session, []int) {
+	var sessions []Session
+	var first []int
+	for i := len(records) - 1; i >= 0; i-- {
+		record := records[i]
+		switch {
+		case record.Kind == "in":
+			sessions = append(sessions, Session{Start: record.Timestamp, Notes: record.Notes})
+			first = append(first, i)
+		case len(sessions) > 0 && sessions[len(sessions)-1].IsOpen() && !sessions[len(sessions)-1].Start.IsZero():
+			sessions[len(sessions)-1].End = record.Timestamp
+			sessions[len(sessions)-1].OutNotes = record.Notes
+		default:
+			sessions = append(sessions, Session{End: record.Timestamp, OutNotes: record.Notes})
+			first = append(first, i)
+		}
+	}
+
+	// newest first, like records
+	for i, j := 0, len(sessions)-1; i < j; i, j = i+1, j-1 {
+		sessions[i], sessions[j] = sessions[j], sessions[i]
+		first[i], first[j] = first[j], first[i]
+	}
+	return sessions, first
+}
+
+// sessionRecords converts sessions back to records, newest first.
+func sessionRecords(sessions []Session) []Record {
+	var records []Record
+	for _, s := range sessions {
+		if !s.Start.IsZero() {
+			records = append(records, Record{Timestamp: s.Start, Kind: "in", Notes: s.Notes})
+		}
+		if !s.IsOpen() {
+			records = append(records, Record{Timestamp: s.End, Kind: "out", Notes: s.OutNotes})
+		}
+	}
+	sortRecords(records)
+	return records
+}
+
+// sessionTime is the time a session is sorted by: its start, or its end
+// when it has no start.
+func sessionTime(s Session) time.Time {
+	if s.Start.IsZero() {
+		return s.End
+	}
+	return s.Start
+}
+
+// sortSessions sorts sessions newest first.
+func sortSessions(sessions []Session) {
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessionTime(sessions[i]).After(sessionTime(sessions[j]))
+	})
+}
+
+// checkSessions validates sessions (newest first) with the rules of
+// validateRecord plus alternation (every check-in has a check-out, except
+// the latest one) and overlap checks.
+func checkSessions(sessions []Session) []sessionError {
+	var errs []sessionError
+	for i, s := range sessions {
+		fail := func(err error) {
+			errs = append(errs, sessionError{i, err})
+		}
+		if s.Start.IsZero() {
+			fail(errors.New("check-out without a check-in"))
+			continue
+		}
+		if err := validateRecord(Record{Timestamp: s.Start, Kind: "in", Notes: s.Notes}); err != nil {
+			fail(err)
+			continue
+		}
+		if s.IsOpen() {
+			if i > 0 {
+				fail(errors.New("session is never checked out"))
+			}
+			continue
+		}
+		if err := validateRecord(Record{Timestamp: s.End, Kind: "out", Notes: s.OutNotes}); err != nil {
+			fail(err)
+			continue
+		}
+		if !s.End.After(s.Start) {
+			fail(errors.New("session ends before it starts"))
+			continue
+		}
+		if i > 0 && !sessions[i-1].Start.IsZero() && s.End.After(sessions[i-1].Start) {
+			fail(fmt.Errorf("session overlaps with the one starting at %s", formatSessionTime(sessions[i-1].Start)))
+		}
+	}
+	return errs
+}
+
+// formatSessionLine formats a session as "DATE START END NOTES", where END
+// is "-" for an open session and NOTES may end with " || OUT NOTES".
+func formatSessionLine(s Session) string {
+	start, end := "????-??-?? ??:??", OpenSessionEnd
+	if !s.Start.IsZero() {
+		start = s.Start.In(time.Local).Format(DateFormat) + " " + formatSessionTime(s.Start)
+	}
+	if !s.IsOpen() {
+		end = formatSessionTime(s.End)
+		if !s.Start.IsZero() && s.End.In(time.Local).Format(DateFormat) != s.Start.In(time.Local).Format(DateFormat) &&
+			s.End.Sub(s.Start) >= 24*time.Hour {
+			end = s.End.In(time.Local).Format(DateFormat) + "T" + end
+		}
+	}
+
+	line := start + " " + end
+	notes := s.Notes
+	if s.OutNotes != "" {
+		notes += OutNotesSeparator + s.OutNotes
+	}
+	if notes != "" {
+		line += " " + notes
+	}
+	return line
+}
+
+// formatSessionTime formats the local time of day, with seconds only when
+// they are not zero so that unchanged sessions round-trip exactly.
+func formatSessionTime(t time.Time) string {
+	t = t.In(time.Local)
+	if t.Second() != 0 {
+		return t.Format(SessionSecondFormat)
+	}
+	return t.Format(SessionTimeFormat)
+}
+
+// parseSessionLine parses a line written by formatSessionLine. END may be
+// "-" (open), a time of day (on the start date, or the next day when it is
+// not after START) or DATE"T"TIME for sessions longer than a day.
+func parseSessionLine(line string) (Session, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return Session{}, errors.New("expected: DATE START END [NOTES]")
+	}
+
+	date, err := time.ParseInLocation(DateFormat, fields[0], time.Local)
+	if err != nil {
+		return Session{}, fmt.Errorf("invalid date %q: expected YYYY-MM-DD", fields[0])
+	}
+	start, err := parseTimeOfDay(date, fields[1])
+	if err != nil {
+		return Session{}, err
+	}
+
+	s := Session{Start: start}
+	switch end := fields[2]; {
+	case end == OpenSessionEnd:
+	case strings.Contains(end, "T"):
+		parts := strings.SplitN(end, "T", 2)
+		endDate, err := time.ParseInLocation(DateFormat, parts[0], time.Local)
+		if err != nil {
+			return Session{}, fmt.Errorf("invalid end date %q: expected YYYY-MM-DD", parts[0])
+		}
+		if s.End, err = parseTimeOfDay(endDate, parts[1]); err != nil {
+			return Session{}, err
+		}
+	default:
+		if s.End, err = parseTimeOfDay(date, end); err != nil {
+			return Session{}, err
+		}
+		if !s.End.After(s.Start) {
+			s.End = s.End.AddDate(0, 0, 1)
+		}
+	}
+
+	// Keep the notes verbatim, after the first three fields
+	rest := strings.TrimSpace(line)
+	for i := 0; i < 3; i++ {
+		rest = strings.TrimSpace(strings.TrimPrefix(rest, fields[i]))
+	}
+	s.Notes, s.OutNotes, _ = strings.Cut(rest, strings.TrimSpace(OutNotesSeparator))
+	s.Notes, s.OutNotes = strings.TrimSpace(s.Notes), strings.TrimSpace(s.OutNotes)
+	if s.IsOpen() && s.OutNotes != "" {
+		return Session{}, errors.New("an open session can't have check-out notes")
+	}
+	return s, nil
+}
+
+// parseTimeOfDay parses HH:MM or HH:MM:SS on the given local date.
+func parseTimeOfDay(date time.Time, value string) (time.Time, error) {
+	layout := SessionTimeFormat
+	if strings.Count(value, ":") == 2 {
+		layout = SessionSecondFormat
+	}
+	t, err := time.ParseInLocation(layout, value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: expected HH:MM", value)
+	}
+	return time.Date(date.Year(), date.Month(), date.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.Local), nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPairSessions(t *testing.T) {
+	at := func(hour int) time.Time {
+		return time.Date(2025, 1, 9, hour, 0, 0, 0, time.Local)
+	}
+	records := []Record{
+		{Timestamp: at(18), Kind: "in", Notes: "open"},
+		{Timestamp: at(17), Kind: "out", Notes: "done"},
+		{Timestamp: at(13), Kind: "in", Notes: "afternoon"},
+		{Timestamp: at(12), Kind: "out", Notes: "orphan"},
+		{Timestamp: at(11), Kind: "out", Notes: "lunch"},
+		{Timestamp: at(9), Kind: "in", Notes: "morning"},
+	}
+
+	sessions := pairSessions(records)
+	expected := []Session{
+		{Start: at(18), Notes: "open"},
+		{Start: at(13), End: at(17), Notes: "afternoon", OutNotes: "done"},
+		{End: at(12), OutNotes: "orphan"},
+		{Start: at(9), End: at(11), Notes: "morning", OutNotes: "lunch"},
+	}
+	if len(sessions) != len(expected) {
+		t.Fatalf("Expected %d sessions, got %d: %+v", len(expected), len(sessions), sessions)
+	}
+	for i, s := range sessions {
+		if !s.Start.Equal(expected[i].Start) || !s.End.Equal(expected[i].End) ||
+			s.Notes != expected[i].Notes || s.OutNotes != expected[i].OutNotes {
+			t.Errorf("Session %d: expected %+v, got %+v", i, expected[i], s)
+		}
+	}
+
+	back := sessionRecords(sessions)
+	if len(back) != len(records) {
+		t.Fatalf("Expected %d records back, got %d", len(records), len(back))
+	}
+	for i := range records {
+		if !back[i].Timestamp.Equal(records[i].Timestamp) || back[i].Kind != records[i].Kind || back[i].Notes != records[i].Notes {
+			t.Errorf("Record %d: expected %+v, got %+v", i, records[i], back[i])
+		}
+	}
+
+	errs := checkSessions(sessions)
+	if len(errs) != 1 || errs[0].Index != 2 {
+		t.Errorf("Expected the orphan check-out to be reported, got %v", errs)
+	}
+}
+
+func TestSessionLineRoundTrip(t *testing.T) {
+	lines := []string{
+		"2025-01-09 09:00 12:30 Meeting prep, with commas",
+		"2025-01-09 13:15 -",
+		"2025-01-09 09:00:15 17:30 Notes || Check-out notes",
+		"2025-01-09 22:00 2025-01-11T02:00 Long shift",
+	}
+	for _, line := range lines {
+		s, err := parseSessionLine(line)
+		if err != nil {
+			t.Errorf("parseSessionLine(%q) failed: %v", line, err)
+			continue
+		}
+		if got := formatSessionLine(s); got != line {
+			t.Errorf("Round trip of %q gave %q", line, got)
+		}
+	}
+
+	s, err := parseSessionLine("2025-01-09 22:00 02:00 Night shift")
+	if err != nil {
+		t.Fatalf("parseSessionLine failed: %v", err)
+	}
+	if s.Hours(time.Now()) != 4 {
+		t.Errorf("Expected a session past midnight to last 4h, got %v", s.Hours(time.Now()))
+	}
+
+	for _, line := range []string{
+		"2025-01-09 09:00",
+		"09-01-2025 09:00 10:00",
+		"2025-01-09 9am 10:00",
+		"2025-01-09 09:00 - notes || out notes",
+	} {
+		if _, err := parseSessionLine(line); err == nil {
+			t.Errorf("Expected an error for %q", line)
+		}
+	}
+}
+
+func TestCheckSessions(t *testing.T) {
+	parse := func(lines ...string) []Session {
+		var sessions []Session
+		for _, line := range lines {
+			s, err := parseSessionLine(line)
+			if err != nil {
+				t.Fatalf("parseSessionLine(%q) failed: %v", line, err)
+			}
+			sessions = append(sessions, s)
+		}
+		sortSessions(sessions)
+		return sessions
+	}
+	future := time.Now().AddDate(0, 0, 2).Format(DateFormat)
+
+	tests := []struct {
+		name     string
+		sessions []Session
+		errors   []string
+	}{
+		{"valid", parse("2025-01-09 13:00 -", "2025-01-09 09:00 12:00"), nil},
+		{"overlap", parse("2025-01-09 11:00 13:00", "2025-01-09 09:00 12:00"), []string{"overlaps"}},
+		{"open not latest", parse("2025-01-09 13:00 14:00", "2025-01-09 09:00 -"), []string{"never checked out"}},
+		{"future", parse(future + " 09:00 10:00"), []string{"future"}},
+		{"empty", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := checkSessions(tt.sessions)
+			if len(errs) != len(tt.errors) {
+				t.Fatalf("Expected %d errors, got %v", len(tt.errors), errs)
+			}
+			for i, err := range errs {
+				if !strings.Contains(err.Error(), tt.errors[i]) {
+					t.Errorf("Expected error containing %q, got %q", tt.errors[i], err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/asdf8601/takt-go/pkg/query"
+	"github.com/asdf8601/takt-go/pkg/report"
+	"github.com/spf13/cobra"
+)
+
+// runSQL runs a query over the records and writes its result in format.
+func runSQL(w io.Writer, records []Record, sql, format string) error {
+	tables := query.Tables{Records: records}
+	for _, s := range pairSessions(records) {
+		tables.Sessions = append(tables.Sessions, query.Session{Start: s.Start, End: s.End, Notes: s.Notes, OutNotes: s.OutNotes})
+	}
+	c := reportConfig()
+	if len(records) > 0 {
+		days, err := c.CalculateDuration(records, report.PeriodDay)
+		if err != nil {
+			return err
+		}
+		tables.Days = days
+	}
+
+	result, err := query.Run(context.Background(), c, tables, sql)
+	if err != nil {
+		return err
+	}
+	return result.Write(w, format)
+}
+
+var sqlCmd = &cobra.Command{
+	Use:   "sql [QUERY]",
+	Short: "Query the records, sessions and days with SQL",
+	Long: `Run a SQL query (SQLite dialect) over three read-only tables built from
+the records file:
+
+  records   timestamp, kind, notes, date
+  sessions  start, "end", date, week, month, weekday, hours, duration, note,
+            out_note, project, projects, tags, open
+  days      date, week, month, weekday, hours, raw_hours, target, balance,
+            sessions
+
+Sessions pair each check-in with its check-out; open ones count as in the
+days, by TAKT_OPEN_SESSIONS (until now by default, no hours when excluded).
+Their project is the first +project of the notes, and projects and tags
+list all of them, comma-separated. Days add up as in 'takt day', with the
+configured rounding and target. Without a query, it prints the schema.
+
+EXAMPLES:
+  takt sql "SELECT project, round(sum(hours), 2) AS hours FROM sessions GROUP BY project"
+  takt sql "SELECT weekday, avg(hours) FROM days GROUP BY weekday" --format csv
+  takt sql "SELECT * FROM days WHERE balance < 0 ORDER BY date DESC" --format json
+
+OUTPUT:
+  project  hours
+  acme      31.5
+  beta        12`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			fmt.Println(strings.TrimSpace(query.Schema))
+			return
+		}
+		records, err := readRecords(-1)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		format, _ := cmd.Flags().GetString("format")
+		if err := runSQL(os.Stdout, records, args[0], format); err != nil {
+			fmt.Printf("Error: %v\n", err)
+		}
+	},
+}
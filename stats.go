@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/stats"
+	"github.com/spf13/cobra"
+)
+
+// computeStats analyzes records between the --from and --to flags of cmd,
+// up to today by default, counting an open session as the open session
+// policy says.
+func computeStats(cmd *cobra.Command, records []Record) (stats.Stats, error) {
+	opts := stats.Options{TargetHours: config.TargetHours, Schedule: config.Schedule, Now: clock.Now()}
+	for name, t := range map[string]*time.Time{"from": &opts.From, "to": &opts.To} {
+		value, _ := cmd.Flags().GetString(name)
+		if value == "" {
+			continue
+		}
+		var err error
+		if *t, err = time.ParseInLocation(DateFormat, value, time.Local); err != nil {
+			return stats.Stats{}, fmt.Errorf("invalid --%s date %q: expected YYYY-MM-DD", name, value)
+		}
+	}
+	inferLastOut(&records)
+	var sessions []stats.Session
+	for _, s := range pairSessions(records) {
+		sessions = append(sessions, stats.Session{Start: s.Start, End: s.End})
+	}
+	return stats.Compute(sessions, opts)
+}
+
+// writeStatsJSON writes the stats as indented JSON.
+func writeStatsJSON(w io.Writer, s stats.Stats) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Analyze when you work: weekdays, start and end times, streaks",
+	Long: `Analyze the sessions of a range of days (from the first one up to today
+by default): the average hours per weekday worked, histograms of the first
+check-in and the last check-out of each day, the longest and the current
+streak of workdays at the target (days off don't break a streak, nor does
+today before it is over), and the latest check-out of each week. A
+sparkline shows the hours of every day.
+
+EXAMPLES:
+  takt stats
+  takt stats --from 2025-01-01 --to 2025-03-31
+  takt stats --json | jq .longest_streak
+
+OUTPUT:
+  Stats from 2025-01-06 to 2025-01-10
+    Active days: 5 of 5 (100.0%), 1d16h30m in total
+    Daily hours: ▇█▆▇▇
+
+  Average per weekday worked
+    Mon  8h15m   1d  ████████████████████████████
+    ...
+
+  First check-in of the day
+    08:00   2  ████████████████████
+    09:00   3  ██████████████████████████████
+
+  Streaks of workdays at the target
+    Longest: 5 days (2025-01-06 to 2025-01-10)
+    Current: 5 days (2025-01-06 to 2025-01-10)
+
+  Latest check-out per week
+    2025-W02  2025-01-09 19:30  ██████████████████████████████`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if config == nil {
+			fmt.Println("Error: config not initialized")
+			return
+		}
+		records, err := readReportRecords(-1)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		s, err := computeStats(cmd, records)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		asJSON, _ := cmd.Flags().GetBool("json")
+		if asJSON {
+			err = writeStatsJSON(os.Stdout, s)
+		} else {
+			err = s.WriteText(os.Stdout)
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+		}
+	},
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/report"
+	"github.com/asdf8601/takt-go/pkg/takt"
+	"github.com/asdf8601/takt-go/pkg/team"
+	"github.com/spf13/cobra"
+)
+
+// runTeam loads the logs of --dir and writes the team report of the
+// --period weeks or months from --from to --to.
+func runTeam(cmd *cobra.Command, w io.Writer) error {
+	dir, _ := cmd.Flags().GetString("dir")
+	if dir == "" {
+		return errors.New("missing --dir")
+	}
+	period, _ := cmd.Flags().GetString("period")
+	c := reportConfig()
+	now := clock.Now()
+
+	defaults := team.Member{TargetHours: c.TargetHours, Schedule: c.Schedule}
+	members, err := team.LoadDir(dir, defaults, now)
+	if err != nil {
+		return err
+	}
+	for i, m := range members {
+		if len(m.InvalidLines) > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: %s: left out %d invalid records at lines: %v\n", m.FileName, len(m.InvalidLines), m.InvalidLines)
+		}
+		members[i].Records = filterRecords(m.Records)
+	}
+
+	from, to := now, now
+	if value, _ := cmd.Flags().GetString("to"); value != "" {
+		if to, err = time.ParseInLocation(takt.DateFormat, value, time.Local); err != nil {
+			return fmt.Errorf("invalid --to %q: expected YYYY-MM-DD", value)
+		}
+	}
+	if value, _ := cmd.Flags().GetString("from"); value != "" {
+		if from, err = time.ParseInLocation(takt.DateFormat, value, time.Local); err != nil {
+			return fmt.Errorf("invalid --from %q: expected YYYY-MM-DD", value)
+		}
+	} else if from, _, _, err = report.PeriodRange(period, to); err != nil {
+		return err
+	}
+
+	r, err := team.Compute(members, team.Options{Config: c, Period: period, From: from, To: to})
+	if err != nil {
+		return err
+	}
+	if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+		return r.WriteJSON(w)
+	}
+	return r.WriteText(w)
+}
+
+var teamCmd = &cobra.Command{
+	Use:   "team --dir DIR",
+	Short: "Add up the logs of a team: totals, balances and missing days",
+	Long: `Report the work of a team from a directory with one records file (*.csv)
+per person, such as a shared git repository of timesheets: per week or
+month, the total, target and balance of every person and of the team, the
+days worked and the past workdays without any work. The logs are only read.
+
+The user is the file name without .csv, unless the log starts with a
+"# user: NAME" line. Balances follow TAKT_TARGET_HOURS and TAKT_WORKDAYS,
+unless the log sets its own with "# target: 7:30" or "# workdays: mon-thu"
+lines before the CSV header. Targets run to today.
+
+Without --from, the report starts at the beginning of the period of --to
+(default today).
+
+EXAMPLES:
+  takt team --dir ./timesheets
+  takt team --dir ./timesheets --period month --from 2025-01-01 --to 2025-03-31
+  takt team --dir ./timesheets --json | jq '.total.members'
+
+OUTPUT:
+  Team of 2 from 2025-01-06 to 2025-01-10, by week
+
+  2025-W02  2025-01-06 to 2025-01-10
+    User     Total    Target    Balance  Days  Missing
+    ana   1d07h00m  1d16h00m      -1d1h     4  2025-01-08
+    ben     22h30m  1d06h00m        -1d     3  2025-01-06
+    Team  2d05h30m  2d22h00m   -2d0h30m     7  2 days`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runTeam(cmd, os.Stdout); err != nil {
+			fmt.Printf("Error: %v\n", err)
+		}
+	},
+}
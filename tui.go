@@ -0,0 +1,638 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/asdf8601/takt-go/pkg/ansi"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+const (
+	// Terminal control sequences
+	termAltScreen   = "\033[?1049h"
+	termMainScreen  = "\033[?1049l"
+	termHideCursor  = "\033[?25l"
+	termShowCursor  = "\033[?25h"
+	termHome        = "\033[H"
+	termClearLine   = "\033[K"
+	termClearBelow  = "\033[J"
+	termReverse     = "\033[7m"
+	tuiRefreshEvery = time.Second
+
+	// Default terminal size when it can't be queried
+	tuiDefaultWidth  = 80
+	tuiDefaultHeight = 24
+
+	// Rows used by the header and footer around the session list
+	tuiChromeRows = 6
+)
+
+// tuiHelp lists the key bindings shown in the footer.
+const tuiHelp = "a add  e edit  s split  m merge  d delete  c check  r reload  q quit"
+
+// tuiPrompt is a line of input being typed at the bottom of the screen.
+type tuiPrompt struct {
+	Label  string
+	Input  []rune
+	Submit func(input string) error
+}
+
+// tui is the state of the terminal UI. It has no terminal I/O of its own:
+// handleKey applies a key and render draws the screen, so tests can drive it.
+type tui struct {
+	fileName string
+	records  []Record  // as last read from the file
+	sessions []Session // newest first
+	cursor   int
+	offset   int // first visible session
+	width    int
+	height   int
+	message  string
+	prompt   *tuiPrompt
+	quit     bool
+	color    bool
+	now      func() time.Time
+}
+
+// newTUI loads the sessions of fileName.
+func newTUI(fileName string) (*tui, error) {
+	t := &tui{
+		fileName: fileName,
+		width:    tuiDefaultWidth,
+		height:   tuiDefaultHeight,
+		now:      clock.Now,
+	}
+	if err := t.reload(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// reload reads the sessions from the file again.
+func (t *tui) reload() error {
+	records, err := readRecordsFromFile(t.fileName, -1)
+	if err != nil {
+		return fmt.Errorf("failed to read records: %w", err)
+	}
+	t.records = records
+	t.sessions = pairSessions(records)
+	t.moveCursor(0)
+	return nil
+}
+
+// selected returns the session under the cursor.
+func (t *tui) selected() (Session, bool) {
+	if t.cursor < 0 || t.cursor >= len(t.sessions) {
+		return Session{}, false
+	}
+	return t.sessions[t.cursor], true
+}
+
+// save validates sessions and, when they are valid, writes them atomically
+// and selects the session starting at focus. When the file changed since it
+// was loaded (by takt check, the daemon or serve), it reloads it instead and
+// refuses, so that nothing written meanwhile is lost.
+func (t *tui) save(sessions []Session, focus time.Time) error {
+	sortSessions(sessions)
+	if errs := checkSessions(sessions); len(errs) > 0 {
+		return fmt.Errorf("%s: %w", formatSessionLine(sessions[errs[0].Index]), errs[0])
+	}
+	current, err := readRecordsFromFile(t.fileName, -1)
+	if err != nil {
+		return fmt.Errorf("failed to read records: %w", err)
+	}
+	if !sameRecords(current, t.records) {
+		if err := t.reload(); err != nil {
+			return err
+		}
+		return errors.New("the records changed since they were loaded: reloaded, try again")
+	}
+	records := sessionRecords(sessions)
+	if err := replaceRecords(t.fileName, records); err != nil {
+		return err
+	}
+
+	t.records = records
+	t.sessions = sessions
+	for i, s := range sessions {
+		if s.Start.Equal(focus) {
+			t.cursor = i
+		}
+	}
+	t.moveCursor(0)
+	return nil
+}
+
+// sameRecords reports whether a and b hold the same records in the same order.
+func sameRecords(a, b []Record) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Timestamp.Equal(b[i].Timestamp) || a[i].Kind != b[i].Kind || a[i].Notes != b[i].Notes {
+			return false
+		}
+	}
+	return true
+}
+
+// replace returns a copy of the sessions with the one at index i replaced by
+// the given sessions.
+func (t *tui) replace(i int, with ...Session) []Session {
+	sessions := append([]Session{}, t.sessions[:i]...)
+	sessions = append(sessions, with...)
+	return append(sessions, t.sessions[i+1:]...)
+}
+
+// add adds a session parsed from a session line.
+func (t *tui) add(line string) error {
+	s, err := parseSessionLine(line)
+	if err != nil {
+		return err
+	}
+	return t.save(append([]Session{s}, t.sessions...), s.Start)
+}
+
+// edit replaces the selected session with one parsed from a session line.
+func (t *tui) edit(line string) error {
+	s, err := parseSessionLine(line)
+	if err != nil {
+		return err
+	}
+	return t.save(t.replace(t.cursor, s), s.Start)
+}
+
+// split splits the selected session in two at a time of day.
+func (t *tui) split(value string) error {
+	s, _ := t.selected()
+	at, err := parseTimeOfDay(s.Start.In(time.Local), strings.TrimSpace(value))
+	if err != nil {
+		return err
+	}
+	if !at.After(s.Start) {
+		// a time on the next day, for sessions past midnight
+		at = at.AddDate(0, 0, 1)
+	}
+	if !at.After(s.Start) || (!s.IsOpen() && !at.Before(s.End)) {
+		return fmt.Errorf("%s is not within the session", formatSessionTime(at))
+	}
+
+	first := Session{Start: s.Start, End: at, Notes: s.Notes}
+	second := Session{Start: at, End: s.End, Notes: s.Notes, OutNotes: s.OutNotes}
+	return t.save(t.replace(t.cursor, second, first), at)
+}
+
+// merge joins the selected session with the next older one, keeping the gap
+// between them as working time.
+func (t *tui) merge() error {
+	if t.cursor+1 >= len(t.sessions) {
+		return errors.New("no older session to merge with")
+	}
+	newer, older := t.sessions[t.cursor], t.sessions[t.cursor+1]
+	merged := Session{Start: older.Start, End: newer.End, Notes: older.Notes, OutNotes: newer.OutNotes}
+	if newer.Notes != "" && newer.Notes != older.Notes {
+		merged.Notes = strings.TrimPrefix(older.Notes+" / "+newer.Notes, " / ")
+	}
+
+	sessions := t.replace(t.cursor, merged)
+	sessions = append(sessions[:t.cursor+1], sessions[t.cursor+2:]...)
+	return t.save(sessions, merged.Start)
+}
+
+// remove deletes the selected session.
+func (t *tui) remove() error {
+	sessions := t.replace(t.cursor)
+	focus := time.Time{}
+	if t.cursor < len(sessions) {
+		focus = sessions[t.cursor].Start
+	}
+	return t.save(sessions, focus)
+}
+
+// check checks in or out now, like takt check.
+func (t *tui) check(notes string) error {
+	record, err := toggleRecord(t.fileName, notes)
+	if err != nil {
+		return err
+	}
+	if err := t.reload(); err != nil {
+		return err
+	}
+	t.cursor = 0
+	t.message = fmt.Sprintf("Check %s at %s", record.Kind, formatSessionTime(record.Timestamp))
+	return nil
+}
+
+// ask starts a prompt with prefilled input.
+func (t *tui) ask(label, input string, submit func(string) error) {
+	t.prompt = &tuiPrompt{Label: label, Input: []rune(input), Submit: submit}
+}
+
+// handleKey applies a key as returned by readKeys.
+func (t *tui) handleKey(key string) {
+	if t.prompt != nil {
+		t.handlePromptKey(key)
+		return
+	}
+
+	t.message = ""
+	s, ok := t.selected()
+	switch key {
+	case "q", "ctrl-c":
+		t.quit = true
+	case "j", "down":
+		t.moveCursor(1)
+	case "k", "up":
+		t.moveCursor(-1)
+	case "pgdown":
+		t.moveCursor(t.listHeight())
+	case "pgup":
+		t.moveCursor(-t.listHeight())
+	case "g", "home":
+		t.moveCursor(-len(t.sessions))
+	case "G", "end":
+		t.moveCursor(len(t.sessions))
+	case "a":
+		now := t.now().In(time.Local)
+		t.ask("Add", now.Format(DateFormat)+" "+now.Format(SessionTimeFormat)+" "+OpenSessionEnd+" ", t.add)
+	case "c":
+		t.ask("Check notes", "", t.check)
+	case "r":
+		if err := t.reload(); err != nil {
+			t.message = "Error: " + err.Error()
+		} else {
+			t.message = "Reloaded " + t.fileName
+		}
+	case "e", "enter", "s", "m", "d":
+		if !ok {
+			t.message = "No session selected"
+			return
+		}
+		switch key {
+		case "e", "enter":
+			t.ask("Edit", formatSessionLine(s), t.edit)
+		case "s":
+			t.ask("Split at (HH:MM)", "", t.split)
+		case "m":
+			t.run(t.merge, "Merged")
+		case "d":
+			t.ask("Delete "+formatSessionLine(s)+"? (y/N)", "", func(answer string) error {
+				if !strings.EqualFold(strings.TrimSpace(answer), "y") {
+					t.message = "Not deleted"
+					return nil
+				}
+				return t.remove()
+			})
+		}
+	}
+}
+
+// handlePromptKey edits the prompt input, submitting it on enter.
+func (t *tui) handlePromptKey(key string) {
+	p := t.prompt
+	switch key {
+	case "enter":
+		t.prompt, t.message = nil, ""
+		if err := p.Submit(string(p.Input)); err != nil {
+			// keep the input so it can be fixed
+			t.prompt = p
+			t.message = "Error: " + err.Error()
+		} else if t.message == "" {
+			t.message = "Saved"
+		}
+	case "esc", "ctrl-c":
+		t.prompt = nil
+		t.message = ""
+	case "backspace":
+		if len(p.Input) > 0 {
+			p.Input = p.Input[:len(p.Input)-1]
+		}
+	case "ctrl-u":
+		p.Input = nil
+	default:
+		if r, size := utf8.DecodeRuneInString(key); size == len(key) && unicode.IsPrint(r) {
+			p.Input = append(p.Input, r)
+		}
+	}
+}
+
+// run applies an operation and reports its outcome.
+func (t *tui) run(op func() error, done string) {
+	if err := op(); err != nil {
+		t.message = "Error: " + err.Error()
+		return
+	}
+	t.message = done
+}
+
+// listHeight returns the number of session rows that fit on the screen.
+func (t *tui) listHeight() int {
+	return max(t.height-tuiChromeRows, 1)
+}
+
+// moveCursor moves the cursor by delta, keeping it visible.
+func (t *tui) moveCursor(delta int) {
+	t.cursor = min(max(t.cursor+delta, 0), max(len(t.sessions)-1, 0))
+	if t.cursor < t.offset {
+		t.offset = t.cursor
+	}
+	if t.cursor >= t.offset+t.listHeight() {
+		t.offset = t.cursor - t.listHeight() + 1
+	}
+}
+
+// totals returns the hours and balance of today and of this week.
+func (t *tui) totals() (day, dayBalance, week, weekBalance float64) {
+	now := t.now()
+	records := sessionRecords(t.sessions)
+	if days, err := calculateDuration(records, "day"); err == nil {
+		for _, a := range days {
+			if a.Group == now.Format(DateFormat) {
+				day, dayBalance = a.TotalHours, balanceHours(a)
+			}
+		}
+	}
+	if weeks, err := calculateDuration(records, "week"); err == nil {
+		year, isoWeek := now.ISOWeek()
+		for _, a := range weeks {
+			if a.Group == fmt.Sprintf("%d-W%02d", year, isoWeek) {
+				week, weekBalance = a.TotalHours, balanceHours(a)
+			}
+		}
+	}
+	return day, dayBalance, week, weekBalance
+}
+
+// render draws the screen as lines without trailing newlines.
+func (t *tui) render() []string {
+	now := t.now()
+	day, dayBalance, week, weekBalance := t.totals()
+	status := "out"
+	if len(t.sessions) > 0 && t.sessions[0].IsOpen() && !t.sessions[0].Start.IsZero() {
+		status = "in since " + formatSessionTime(t.sessions[0].Start)
+	}
+
+	lines := []string{
+		t.paint(ansi.Bold, fmt.Sprintf("takt  %s  Today %s (%s)  Week %s (%s)  %s",
+			now.In(time.Local).Format("15:04:05"), hoursToText(day), formatOvertime(dayBalance),
+			hoursToText(week), formatOvertime(weekBalance), status)),
+		strings.Repeat("─", t.width),
+		fmt.Sprintf("  %-10s  %-8s  %-8s  %8s  %s", "Date", "Start", "End", "Duration", "Notes"),
+	}
+
+	errs := map[int]error{}
+	for _, e := range checkSessions(t.sessions) {
+		errs[e.Index] = e.Err
+	}
+	end := min(t.offset+t.listHeight(), len(t.sessions))
+	for i := t.offset; i < end; i++ {
+		line := t.sessionRow(t.sessions[i], now, errs[i])
+		if i == t.cursor {
+			line = t.paint(termReverse, line)
+		} else if errs[i] != nil {
+			line = t.paint(ansi.Red, line)
+		}
+		lines = append(lines, line)
+	}
+	for i := end - t.offset; i < t.listHeight(); i++ {
+		lines = append(lines, "")
+	}
+	if len(t.sessions) == 0 {
+		lines[3] = "  No sessions yet. Press a to add one or c to check in."
+	}
+
+	lines = append(lines, strings.Repeat("─", t.width))
+	switch {
+	case t.prompt != nil:
+		lines = append(lines, t.prompt.Label+": "+string(t.prompt.Input)+"█")
+	case strings.HasPrefix(t.message, "Error: "):
+		lines = append(lines, t.paint(ansi.Red, t.message))
+	default:
+		lines = append(lines, t.message)
+	}
+	lines = append(lines, t.paint(ansi.Gray, tuiHelp))
+
+	for i, line := range lines {
+		lines[i] = truncateRunes(line, t.width)
+	}
+	return lines
+}
+
+// sessionRow formats a session as a row of the list.
+func (t *tui) sessionRow(s Session, now time.Time, err error) string {
+	date, start, end := "", "?", OpenSessionEnd
+	if !s.Start.IsZero() {
+		date, start = s.Start.In(time.Local).Format(DateFormat), formatSessionTime(s.Start)
+	} else {
+		date = s.End.In(time.Local).Format(DateFormat)
+	}
+	if !s.IsOpen() {
+		end = formatSessionTime(s.End)
+	}
+
+	notes := s.Notes
+	if s.OutNotes != "" {
+		notes += OutNotesSeparator + s.OutNotes
+	}
+	if err != nil {
+		notes = "! " + err.Error() + "  " + notes
+	}
+	return fmt.Sprintf("  %-10s  %-8s  %-8s  %8s  %s", date, start, end, hoursToText(s.Hours(now)), notes)
+}
+
+// paint colors text when colors are enabled.
+func (t *tui) paint(code, text string) string {
+	return ansi.Paint(t.color, code, text)
+}
+
+// truncateRunes cuts s to width runes, ignoring ANSI escape sequences.
+func truncateRunes(s string, width int) string {
+	var sb strings.Builder
+	n, escape := 0, false
+	for _, r := range s {
+		switch {
+		case r == '\033':
+			escape = true
+		case escape:
+			if r >= '@' && r <= '~' && r != '[' {
+				escape = false
+			}
+		default:
+			if n >= width {
+				continue
+			}
+			n++
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// keyNames maps terminal input sequences to key names.
+var keyNames = map[string]string{
+	"\r":      "enter",
+	"\n":      "enter",
+	"\x7f":    "backspace",
+	"\b":      "backspace",
+	"\x1b":    "esc",
+	"\x03":    "ctrl-c",
+	"\x15":    "ctrl-u",
+	"\x1b[A":  "up",
+	"\x1b[B":  "down",
+	"\x1b[H":  "home",
+	"\x1b[F":  "end",
+	"\x1b[5~": "pgup",
+	"\x1b[6~": "pgdown",
+	"\x1bOA":  "up",
+	"\x1bOB":  "down",
+}
+
+// parseKeys splits raw terminal input into key names; printable characters
+// are returned as themselves.
+func parseKeys(input string) []string {
+	var keys []string
+	for len(input) > 0 {
+		if input[0] == '\x1b' && len(input) > 1 {
+			// longest escape sequence first
+			matched := false
+			for size := min(len(input), 4); size > 1; size-- {
+				if name, ok := keyNames[input[:size]]; ok {
+					keys = append(keys, name)
+					input = input[size:]
+					matched = true
+					break
+				}
+			}
+			if matched {
+				continue
+			}
+		}
+		if name, ok := keyNames[input[:1]]; ok {
+			keys = append(keys, name)
+			input = input[1:]
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(input)
+		keys = append(keys, input[:size])
+		input = input[size:]
+	}
+	return keys
+}
+
+// readKeys sends the keys read from r until it fails.
+func readKeys(r io.Reader, keys chan<- string) {
+	defer close(keys)
+	buf := make([]byte, 64)
+	for {
+		n, err := r.Read(buf)
+		for _, key := range parseKeys(string(buf[:n])) {
+			keys <- key
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// runTUI runs the terminal UI on the terminal until the user quits.
+func runTUI(t *tui, in, out *os.File) error {
+	inFd, outFd := int(in.Fd()), int(out.Fd())
+	if !term.IsTerminal(inFd) || !term.IsTerminal(outFd) {
+		return errors.New("takt tui needs a terminal")
+	}
+	state, err := term.MakeRaw(inFd)
+	if err != nil {
+		return fmt.Errorf("failed to set up the terminal: %w", err)
+	}
+	defer func() {
+		_ = term.Restore(inFd, state)
+	}()
+	fmt.Fprint(out, termAltScreen+termHideCursor)
+	defer fmt.Fprint(out, termShowCursor+termMainScreen)
+
+	keys := make(chan string)
+	go readKeys(in, keys)
+	ticker := time.NewTicker(tuiRefreshEvery)
+	defer ticker.Stop()
+
+	for !t.quit {
+		if width, height, err := term.GetSize(outFd); err == nil && width > 0 && height > 0 {
+			t.width, t.height = width, height
+			t.moveCursor(0)
+		}
+		screen := strings.Join(t.render(), termClearLine+"\r\n")
+		fmt.Fprint(out, termHome+screen+termClearLine+termClearBelow)
+
+		select {
+		case key, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			t.handleKey(key)
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+var tuiCmd = &cobra.Command{
+	Use:   "tui",
+	Short: "Curate sessions in a full-screen terminal UI",
+	Long: `Open a full-screen terminal UI listing sessions (a check-in paired with its
+check-out), newest first. The header shows today's and this week's totals and
+balance, updated every second.
+
+Every change is validated (valid timestamps and notes, every session checked
+out except the latest, no overlaps) and written atomically to the records
+file right away; invalid changes are rejected with a message. Invalid
+sessions already in the file are marked with "!".
+
+KEYS:
+  j/k, ↑/↓, PgUp/PgDn   Move
+  a                     Add a session: DATE START END [NOTES]
+  e, Enter              Edit the selected session
+  s                     Split the selected session at a time (HH:MM)
+  m                     Merge the selected session with the previous one
+  d                     Delete the selected session
+  c                     Check in/out now
+  r                     Reload the file
+  q, Ctrl-C             Quit (Esc cancels a prompt)
+
+SESSION LINES:
+  2025-01-09 09:00 12:30 Meeting prep
+  2025-01-09 13:15 -     Open session
+  2025-01-09 22:00 02:00 Night shift (ends the next day)
+  2025-01-09 09:00 17:30 Notes || Check-out notes
+
+EXAMPLES:
+  takt tui
+  takt tui --color=never`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if config == nil {
+			fmt.Println("Error: config not initialized")
+			return
+		}
+
+		t, err := newTUI(config.FileName)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		colorMode, _ := cmd.Flags().GetString("color")
+		if t.color, err = ansi.UseColor(colorMode, os.Stdout); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		if err := runTUI(t, os.Stdin, os.Stdout); err != nil {
+			fmt.Printf("Error: %v\n", err)
+		}
+	},
+}
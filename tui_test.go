@@ -0,0 +1,185 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asdf8601/takt-go/pkg/settings"
+)
+
+// newTestTUI creates a TUI over a temporary records file.
+func newTestTUI(t *testing.T, csvContent string) *tui {
+	t.Helper()
+
+	tempFile, err := os.CreateTemp("", "takt_tui_test_*.csv")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = os.Remove(tempFile.Name())
+		_ = os.Remove(tempFile.Name() + ".bak")
+	})
+	if _, err := tempFile.WriteString(csvContent); err != nil {
+		t.Fatalf("Failed to write test data: %v", err)
+	}
+	if err := tempFile.Close(); err != nil {
+		t.Fatalf("Failed to close temp file: %v", err)
+	}
+
+	cfg, err := settings.Load()
+	if err != nil {
+		t.Fatalf("settings.Load() failed: %v", err)
+	}
+	originalConfig := config
+	config = cfg
+	config.FileName = tempFile.Name()
+	config.TargetHours = 8.0
+	t.Cleanup(func() { config = originalConfig })
+
+	tu, err := newTUI(tempFile.Name())
+	if err != nil {
+		t.Fatalf("newTUI failed: %v", err)
+	}
+	return tu
+}
+
+// typeKeys sends each key, typing strings in prompts rune by rune.
+func typeKeys(tu *tui, keys ...string) {
+	for _, key := range keys {
+		if tu.prompt != nil && len([]rune(key)) > 1 && key != "enter" && key != "esc" && key != "backspace" && key != "ctrl-u" {
+			for _, r := range key {
+				tu.handleKey(string(r))
+			}
+			continue
+		}
+		tu.handleKey(key)
+	}
+}
+
+// fileLines returns the session lines of the records file.
+func fileLines(t *testing.T, tu *tui) []string {
+	t.Helper()
+	records, err := readRecordsFromFile(tu.fileName, -1)
+	if err != nil {
+		t.Fatalf("Failed to read records: %v", err)
+	}
+	var lines []string
+	for _, s := range pairSessions(records) {
+		lines = append(lines, formatSessionLine(s))
+	}
+	return lines
+}
+
+func TestTUIOperations(t *testing.T) {
+	tu := newTestTUI(t, "timestamp,kind,notes\n")
+
+	// add two sessions
+	typeKeys(tu, "a", "ctrl-u", "2025-01-09 09:00 12:00 morning", "enter")
+	typeKeys(tu, "a", "ctrl-u", "2025-01-09 13:00 18:00 afternoon", "enter")
+	expected := []string{"2025-01-09 13:00 18:00 afternoon", "2025-01-09 09:00 12:00 morning"}
+	if got := fileLines(t, tu); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("After add: expected %v, got %v", expected, got)
+	}
+
+	// an overlapping add is rejected and keeps the prompt open
+	typeKeys(tu, "a", "ctrl-u", "2025-01-09 11:00 14:00 overlap", "enter")
+	if tu.prompt == nil || !strings.Contains(tu.message, "overlaps") {
+		t.Fatalf("Expected an overlap error, got prompt %v and message %q", tu.prompt, tu.message)
+	}
+	typeKeys(tu, "esc")
+	if got := fileLines(t, tu); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("After rejected add: expected %v, got %v", expected, got)
+	}
+
+	// split the afternoon, then edit the second half
+	typeKeys(tu, "g", "s", "15:30", "enter")
+	expected = []string{"2025-01-09 15:30 18:00 afternoon", "2025-01-09 13:00 15:30 afternoon", "2025-01-09 09:00 12:00 morning"}
+	if got := fileLines(t, tu); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("After split: expected %v, got %v", expected, got)
+	}
+	typeKeys(tu, "e", "ctrl-u", "2025-01-09 15:30 18:00 review || done", "enter")
+	expected[0] = "2025-01-09 15:30 18:00 review || done"
+	if got := fileLines(t, tu); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("After edit: expected %v, got %v", expected, got)
+	}
+
+	// merge the morning into the first half of the afternoon
+	typeKeys(tu, "j", "m")
+	expected = []string{"2025-01-09 15:30 18:00 review || done", "2025-01-09 09:00 15:30 morning / afternoon"}
+	if got := fileLines(t, tu); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("After merge: expected %v, got %v", expected, got)
+	}
+
+	// delete asks for confirmation
+	typeKeys(tu, "d", "n", "enter")
+	if got := fileLines(t, tu); len(got) != 2 {
+		t.Fatalf("Expected no deletion without confirmation, got %v", got)
+	}
+	typeKeys(tu, "d", "y", "enter")
+	expected = expected[:1]
+	if got := fileLines(t, tu); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("After delete: expected %v, got %v", expected, got)
+	}
+
+	typeKeys(tu, "q")
+	if !tu.quit {
+		t.Error("Expected q to quit")
+	}
+}
+
+func TestTUISaveAfterExternalWrite(t *testing.T) {
+	originalLocal := time.Local
+	time.Local = time.UTC
+	t.Cleanup(func() { time.Local = originalLocal })
+	tu := newTestTUI(t, "timestamp,kind,notes\n2025-01-09T12:00:00Z,out,\n2025-01-09T09:00:00Z,in,morning\n")
+
+	// takt check writes behind the TUI's back
+	if err := prependRecord(recordStore(tu.fileName), Record{Timestamp: time.Date(2025, 1, 9, 13, 0, 0, 0, time.UTC), Kind: "in", Notes: "afternoon"}); err != nil {
+		t.Fatalf("prependRecord() failed: %v", err)
+	}
+
+	typeKeys(tu, "e", "ctrl-u", "2025-01-09 09:00 12:00 review", "enter")
+	if !strings.Contains(tu.message, "changed since they were loaded") {
+		t.Fatalf("Expected the save to be refused, got message %q", tu.message)
+	}
+	records, err := readRecordsFromFile(tu.fileName, -1)
+	if err != nil {
+		t.Fatalf("Failed to read records: %v", err)
+	}
+	if len(records) != 3 || records[0].Notes != "afternoon" || records[2].Notes != "morning" {
+		t.Fatalf("Expected the file to be left alone, got %v", records)
+	}
+
+	// the TUI reloaded: saving again keeps the new check-in
+	typeKeys(tu, "esc", "G", "e", "ctrl-u", "2025-01-09 09:00 12:00 review", "enter")
+	expected := []string{"2025-01-09 13:00 - afternoon", "2025-01-09 09:00 12:00 review"}
+	if got := fileLines(t, tu); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("After edit: expected %v, got %v", expected, got)
+	}
+}
+
+func TestTUIRender(t *testing.T) {
+	start := time.Now().Add(-time.Minute).Truncate(time.Second)
+	tu := newTestTUI(t, "timestamp,kind,notes\n"+start.Format(TimeFormat)+",in,coding\n")
+
+	screen := strings.Join(tu.render(), "\n")
+	for _, want := range []string{"Today ", "Week ", "in since " + formatSessionTime(start), "coding", tuiHelp} {
+		if !strings.Contains(screen, want) {
+			t.Errorf("Expected screen to contain %q:\n%s", want, screen)
+		}
+	}
+	if lines := tu.render(); len(lines) != tu.height {
+		t.Errorf("Expected %d lines, got %d", tu.height, len(lines))
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	got := parseKeys("jk\x1b[A\x1b[B\r\x7f\x1bä\x03")
+	expected := []string{"j", "k", "up", "down", "enter", "backspace", "esc", "ä", "ctrl-c"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/asdf8601/takt-go/pkg/takt"
+	"github.com/asdf8601/takt-go/pkg/wasmplugin"
+)
+
+// loadWasmPlugins loads the configured WASM plugins, or returns nil when
+// there are none. The caller closes the runtime.
+func loadWasmPlugins(ctx context.Context) (*wasmplugin.Runtime, error) {
+	if config == nil || len(config.WasmPlugins) == 0 {
+		return nil, nil
+	}
+	paths, err := wasmplugin.Paths(config.WasmPlugins)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find WASM plugins: %w", err)
+	}
+	options := wasmplugin.Options{
+		Records: func() ([]takt.Record, error) { return readRecords(-1) },
+		Log:     os.Stderr,
+	}
+	if dir, err := os.UserCacheDir(); err == nil {
+		options.CacheDir = filepath.Join(dir, "takt", "wasm")
+	}
+	return wasmplugin.Load(ctx, paths, options)
+}
+
+// transformRecord passes a new record through the WASM plugins.
+func transformRecord(record Record) (Record, error) {
+	ctx := context.Background()
+	plugins, err := loadWasmPlugins(ctx)
+	if err != nil || plugins == nil {
+		return record, err
+	}
+	defer func() { _ = plugins.Close(ctx) }()
+	return plugins.Transform(ctx, record)
+}